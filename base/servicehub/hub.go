@@ -25,6 +25,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -41,6 +42,7 @@ import (
 
 // Hub .
 type Hub struct {
+	name          string
 	logger        logs.Logger
 	providersMap  map[string][]*providerContext
 	providers     []*providerContext
@@ -58,8 +60,13 @@ type Hub struct {
 	// problematic providers
 	problematicProviderNames []string
 	problemLock              sync.Mutex
+
+	exitDeadline atomic.Value // time.Time
 }
 
+// ExitTimeout is the max duration to wait for providers to close after quitting signal received.
+var ExitTimeout = 30 * time.Second
+
 // New .
 func New(options ...interface{}) *Hub {
 	hub := &Hub{}
@@ -325,12 +332,13 @@ func (h *Hub) Start(closer ...<-chan os.Signal) (err error) {
 			}
 			elock.Lock()
 			fmt.Println()
+			h.exitDeadline.Store(time.Now().Add(ExitTimeout))
 			wait := make(chan error)
 			go func() {
 				wait <- h.Close()
 			}()
 			select {
-			case <-time.After(30 * time.Second):
+			case <-time.After(ExitTimeout):
 				h.logger.Errorf("exit service manager timeout !")
 				h.printProblematicProviders()
 				os.Exit(1)
@@ -377,6 +385,15 @@ func (h *Hub) printProblematicProviders() {
 	h.logger.Errorf("problematic providers: %v", h.problematicProviderNames)
 }
 
+// ShutdownContext returns a context with the deadline to exit forcibly while the hub is quitting,
+// the context has no deadline if the hub is not quitting by signal.
+func (h *Hub) ShutdownContext() (context.Context, context.CancelFunc) {
+	if deadline, ok := h.exitDeadline.Load().(time.Time); ok {
+		return context.WithDeadline(context.Background(), deadline)
+	}
+	return context.WithCancel(context.Background())
+}
+
 // Close .
 func (h *Hub) Close() error {
 	h.lock.Lock()
@@ -401,6 +418,9 @@ func (h *Hub) Close() error {
 	return errs.MaybeUnwrap()
 }
 
+// Name returns the application name the hub runs with.
+func (h *Hub) Name() string { return h.name }
+
 // ForeachServices .
 func (h *Hub) ForeachServices(fn func(service string) bool) {
 	for key := range h.servicesMap {
@@ -508,6 +528,7 @@ func (h *Hub) RunWithOptions(opts *RunOptions) {
 	if len(name) <= 0 {
 		name = getAppName(opts.Args...)
 	}
+	h.name = name
 	config.LoadEnvFile()
 
 	var err error
@@ -7,6 +7,8 @@ import (
 	context "context"
 
 	pb "github.com/erda-project/erda-infra/examples/service/protocol/pb"
+	transport "github.com/erda-project/erda-infra/pkg/transport"
+	errors "github.com/erda-project/erda-infra/pkg/transport/errors"
 	grpc "github.com/erda-project/erda-infra/pkg/transport/grpc"
 	grpc1 "google.golang.org/grpc"
 )
@@ -46,7 +48,12 @@ type greeterServiceWrapper struct {
 }
 
 func (s *greeterServiceWrapper) SayHello(ctx context.Context, req *pb.HelloRequest) (*pb.HelloResponse, error) {
-	return s.client.SayHello(ctx, req, append(grpc.CallOptionFromContext(ctx), s.opts...)...)
+	ctx = transport.WithDeadlineHeader(ctx)
+	resp, err := s.client.SayHello(ctx, req, append(grpc.CallOptionFromContext(ctx), s.opts...)...)
+	if err != nil {
+		return nil, errors.FromError(err)
+	}
+	return resp, nil
 }
 
 type userServiceWrapper struct {
@@ -55,9 +62,19 @@ type userServiceWrapper struct {
 }
 
 func (s *userServiceWrapper) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
-	return s.client.GetUser(ctx, req, append(grpc.CallOptionFromContext(ctx), s.opts...)...)
+	ctx = transport.WithDeadlineHeader(ctx)
+	resp, err := s.client.GetUser(ctx, req, append(grpc.CallOptionFromContext(ctx), s.opts...)...)
+	if err != nil {
+		return nil, errors.FromError(err)
+	}
+	return resp, nil
 }
 
 func (s *userServiceWrapper) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*pb.UpdateUserResponse, error) {
-	return s.client.UpdateUser(ctx, req, append(grpc.CallOptionFromContext(ctx), s.opts...)...)
+	ctx = transport.WithDeadlineHeader(ctx)
+	resp, err := s.client.UpdateUser(ctx, req, append(grpc.CallOptionFromContext(ctx), s.opts...)...)
+	if err != nil {
+		return nil, errors.FromError(err)
+	}
+	return resp, nil
 }
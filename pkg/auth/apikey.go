@@ -0,0 +1,89 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// DefaultAPIKeyHeader is the default header of API key.
+const DefaultAPIKeyHeader = "X-API-Key"
+
+// APIKey .
+type APIKey struct {
+	// Name is the subject of principal.
+	Name string
+	// Key is the API key in plain text, Hash is used if it's empty.
+	Key string
+	// Hash is the hex encoded sha256 digest of API key.
+	Hash   string
+	Scopes []string
+	Roles  []string
+}
+
+type apiKeyAuthenticator struct {
+	header string
+	keys   map[[sha256.Size]byte]*APIKey
+}
+
+// NewAPIKeyAuthenticator returns an Authenticator verifying the API key in header, DefaultAPIKeyHeader is used if header is empty.
+func NewAPIKeyAuthenticator(header string, keys []APIKey) (Authenticator, error) {
+	if len(header) <= 0 {
+		header = DefaultAPIKeyHeader
+	}
+	a := &apiKeyAuthenticator{
+		header: strings.ToLower(header),
+		keys:   make(map[[sha256.Size]byte]*APIKey),
+	}
+	for i := range keys {
+		key := &keys[i]
+		var digest [sha256.Size]byte
+		if len(key.Key) > 0 {
+			digest = sha256.Sum256([]byte(key.Key))
+		} else {
+			data, err := hex.DecodeString(key.Hash)
+			if err != nil || len(data) != sha256.Size {
+				return nil, fmt.Errorf("invalid hash of api key %q", key.Name)
+			}
+			copy(digest[:], data)
+		}
+		if _, ok := a.keys[digest]; ok {
+			return nil, fmt.Errorf("duplicate api key %q", key.Name)
+		}
+		a.keys[digest] = key
+	}
+	return a, nil
+}
+
+func (a *apiKeyAuthenticator) Authenticate(ctx context.Context, req *Request) (*Principal, error) {
+	val := req.Get(a.header)
+	if len(val) <= 0 {
+		return nil, ErrNoCredentials
+	}
+	key, ok := a.keys[sha256.Sum256([]byte(val))]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown api key", ErrInvalidCredentials)
+	}
+	return &Principal{
+		Subject: key.Name,
+		Method:  MethodAPIKey,
+		Scopes:  key.Scopes,
+		Roles:   key.Roles,
+	}, nil
+}
@@ -0,0 +1,191 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"github.com/erda-project/erda-infra/pkg/transport"
+	transhttp "github.com/erda-project/erda-infra/pkg/transport/http"
+)
+
+// Principal is the authenticated identity of a request.
+type Principal struct {
+	// Subject identifies the caller, e.g. the sub claim of JWT, the name of API key or the subject of client certificate.
+	Subject string
+	// Method is the authentication method, jwt, api_key or mtls.
+	Method string
+	// Scopes are the permissions granted to the caller.
+	Scopes []string
+	// Roles are the roles of the caller.
+	Roles []string
+	// Claims are the claims of JWT.
+	Claims map[string]interface{}
+}
+
+// Authentication methods.
+const (
+	MethodJWT    = "jwt"
+	MethodAPIKey = "api_key"
+	MethodMTLS   = "mtls"
+)
+
+// String returns the subject.
+func (p *Principal) String() string { return p.Subject }
+
+// HasAnyRole returns true if the principal has one of the roles.
+func (p *Principal) HasAnyRole(roles ...string) bool {
+	for _, role := range roles {
+		if contains(p.Roles, role) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasScopes returns true if the principal has all the scopes.
+func (p *Principal) HasScopes(scopes ...string) bool {
+	for _, scope := range scopes {
+		found := false
+		for _, s := range p.Scopes {
+			if s == scope {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+type principalContextKey int8
+
+// WithPrincipal .
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey(0), p)
+}
+
+// ContextPrincipal returns the principal of request, nil is returned if the request is not authenticated.
+func ContextPrincipal(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalContextKey(0)).(*Principal)
+	return p
+}
+
+// Request holds the credentials of a request.
+type Request struct {
+	// Header is the request header, its keys are lower case.
+	Header transport.Header
+	// PeerCertificates are the verified certificates of client.
+	PeerCertificates []*x509.Certificate
+}
+
+// NewHTTPRequest returns the Request of http request.
+func NewHTTPRequest(r *http.Request) *Request {
+	req := &Request{Header: metadata.MD{}}
+	for key, values := range r.Header {
+		req.Header[strings.ToLower(key)] = values
+	}
+	if r.TLS != nil {
+		req.PeerCertificates = verifiedCertificates(r.TLS)
+	}
+	return req
+}
+
+// RequestFromContext returns the Request of service method called by grpc or http.
+func RequestFromContext(ctx context.Context) *Request {
+	if r := transhttp.ContextRequest(ctx); r != nil {
+		return NewHTTPRequest(r)
+	}
+	req := &Request{Header: transport.ContextHeader(ctx)}
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			req.PeerCertificates = verifiedCertificates(&info.State)
+		}
+	}
+	return req
+}
+
+// verifiedCertificates returns the verified chain of client certificates,
+// certificates sent by client are ignored if the client auth policy of tls doesn't verify them.
+func verifiedCertificates(state *tls.ConnectionState) []*x509.Certificate {
+	if len(state.VerifiedChains) <= 0 {
+		return nil
+	}
+	return state.VerifiedChains[0]
+}
+
+// Get returns the first value of header key.
+func (r *Request) Get(key string) string {
+	if vals := r.Header.Get(key); len(vals) > 0 {
+		return vals[0]
+	}
+	return ""
+}
+
+// BearerToken returns the token of Authorization header with Bearer scheme.
+func (r *Request) BearerToken() string {
+	val := r.Get("authorization")
+	if len(val) > 7 && strings.EqualFold(val[:7], "bearer ") {
+		return strings.TrimSpace(val[7:])
+	}
+	return ""
+}
+
+var (
+	// ErrNoCredentials is returned if the request has no credentials for the authenticator.
+	ErrNoCredentials = errors.New("no credentials")
+	// ErrInvalidCredentials is returned if the credentials are invalid.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Authenticator authenticates requests.
+type Authenticator interface {
+	// Authenticate returns the principal of request, ErrNoCredentials is returned if the request has no credentials for it.
+	Authenticate(ctx context.Context, req *Request) (*Principal, error)
+}
+
+// AuthenticatorFunc .
+type AuthenticatorFunc func(ctx context.Context, req *Request) (*Principal, error)
+
+// Authenticate .
+func (f AuthenticatorFunc) Authenticate(ctx context.Context, req *Request) (*Principal, error) {
+	return f(ctx, req)
+}
+
+// Chain returns an Authenticator trying authenticators in order,
+// the first one returning a principal or an error other than ErrNoCredentials decides the result.
+func Chain(list ...Authenticator) Authenticator {
+	return AuthenticatorFunc(func(ctx context.Context, req *Request) (*Principal, error) {
+		for _, a := range list {
+			p, err := a.Authenticate(ctx, req)
+			if errors.Is(err, ErrNoCredentials) {
+				continue
+			}
+			return p, err
+		}
+		return nil, ErrNoCredentials
+	})
+}
@@ -0,0 +1,392 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/erda-project/erda-infra/pkg/transport"
+	transerrors "github.com/erda-project/erda-infra/pkg/transport/errors"
+)
+
+func sign(t *testing.T, alg, kid string, key interface{}, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	var sig []byte
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(signed))
+		sig = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		var err error
+		sig, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig = make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func bearer(token string) *Request {
+	return &Request{Header: metadata.Pairs("authorization", "Bearer "+token)}
+}
+
+func TestJWTAuthenticator(t *testing.T) {
+	secret := []byte("secret")
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	keys := StaticKeys{
+		{Key: secret},
+		{ID: "rsa", Key: &rsaKey.PublicKey},
+		{ID: "ec", Key: &ecKey.PublicKey},
+	}
+	now := time.Unix(1700000000, 0)
+	a := NewJWTAuthenticator(keys, JWTOptions{Issuer: "issuer", Audience: []string{"api"}, Leeway: time.Minute}).(*jwtAuthenticator)
+	a.now = func() time.Time { return now }
+	claims := func(kv ...interface{}) map[string]interface{} {
+		c := map[string]interface{}{"sub": "alice", "iss": "issuer", "aud": "api", "exp": now.Add(time.Hour).Unix(), "scope": "read write"}
+		for i := 0; i+1 < len(kv); i += 2 {
+			if kv[i+1] == nil {
+				delete(c, kv[i].(string))
+			} else {
+				c[kv[i].(string)] = kv[i+1]
+			}
+		}
+		return c
+	}
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{"hs256", sign(t, "HS256", "", secret, claims()), nil},
+		{"rs256", sign(t, "RS256", "rsa", rsaKey, claims()), nil},
+		{"es256", sign(t, "ES256", "ec", ecKey, claims("aud", []string{"other", "api"})), nil},
+		{"wrong secret", sign(t, "HS256", "", []byte("other"), claims()), ErrInvalidCredentials},
+		{"hmac with public key", sign(t, "HS256", "rsa", x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey), claims()), ErrInvalidCredentials},
+		{"none", "eyJhbGciOiJub25lIn0.eyJzdWIiOiJhbGljZSJ9.", ErrInvalidCredentials},
+		{"expired", sign(t, "HS256", "", secret, claims("exp", now.Add(-2*time.Minute).Unix())), ErrInvalidCredentials},
+		{"expired in leeway", sign(t, "HS256", "", secret, claims("exp", now.Add(-30*time.Second).Unix())), nil},
+		{"not before", sign(t, "HS256", "", secret, claims("nbf", now.Add(time.Hour).Unix())), ErrInvalidCredentials},
+		{"issuer", sign(t, "HS256", "", secret, claims("iss", "other")), ErrInvalidCredentials},
+		{"audience", sign(t, "HS256", "", secret, claims("aud", nil)), ErrInvalidCredentials},
+		{"opaque token", "opaque", ErrNoCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := a.Authenticate(context.Background(), bearer(tt.token))
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Fatalf("Authenticate() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if p.Subject != "alice" || p.Method != MethodJWT || !p.HasScopes("read", "write") || p.HasScopes("admin") {
+				t.Errorf("Authenticate() = %+v", p)
+			}
+		})
+	}
+}
+
+type failedKeys struct{ err error }
+
+func (k failedKeys) Keys(ctx context.Context, kid string) ([]*Key, error) { return nil, k.err }
+
+func TestJWTAuthenticatorKeysUnavailable(t *testing.T) {
+	fetchErr := errors.New("jwks unavailable")
+	a := NewJWTAuthenticator(failedKeys{err: fetchErr}, JWTOptions{})
+	_, err := a.Authenticate(context.Background(), bearer(sign(t, "HS256", "", []byte("secret"), map[string]interface{}{"sub": "alice"})))
+	if !errors.Is(err, fetchErr) || errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Authenticate() error = %v, want the error of keys, not %v", err, ErrInvalidCredentials)
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	key1, _ := rsa.GenerateKey(rand.Reader, 2048)
+	key2, _ := rsa.GenerateKey(rand.Reader, 2048)
+	jwk := func(kid string, k *rsa.PrivateKey) map[string]string {
+		return map[string]string{
+			"kty": "RSA", "kid": kid, "use": "sig", "alg": "RS256",
+			"n": base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+		}
+	}
+	var fetches int32
+	current := []map[string]string{jwk("k1", key1)}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": current})
+	}))
+	defer srv.Close()
+
+	now := time.Unix(1700000000, 0)
+	jwks := NewJWKS(srv.URL, WithMinRefetchInterval(time.Second))
+	jwks.now = func() time.Time { return now }
+	a := NewJWTAuthenticator(jwks, JWTOptions{})
+	claims := map[string]interface{}{"sub": "alice"}
+
+	if _, err := a.Authenticate(context.Background(), bearer(sign(t, "RS256", "k1", key1, claims))); err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if _, err := a.Authenticate(context.Background(), bearer(sign(t, "RS256", "k1", key1, claims))); err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if n := atomic.LoadInt32(&fetches); n != 1 {
+		t.Errorf("fetches = %d, want 1", n)
+	}
+
+	// keys are rotated
+	current = []map[string]string{jwk("k2", key2)}
+	if _, err := a.Authenticate(context.Background(), bearer(sign(t, "RS256", "k2", key2, claims))); err == nil {
+		t.Fatal("Authenticate() with unknown kid within min refetch interval, want error")
+	}
+	now = now.Add(2 * time.Second)
+	if _, err := a.Authenticate(context.Background(), bearer(sign(t, "RS256", "k2", key2, claims))); err != nil {
+		t.Fatalf("Authenticate() with rotated key error = %v", err)
+	}
+	if n := atomic.LoadInt32(&fetches); n != 2 {
+		t.Errorf("fetches = %d, want 2", n)
+	}
+}
+
+func TestJWKSFetchOnce(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	var fetches int32
+	requested, release := make(chan struct{}), make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&fetches, 1) == 1 {
+			close(requested)
+		}
+		<-release
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA", "kid": "k1", "alg": "RS256",
+			"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	}))
+	defer srv.Close()
+	jwks := NewJWKS(srv.URL)
+
+	// the first caller is cancelled while fetching, the fetch goes on for the others
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan error, 1)
+	go func() {
+		_, err := jwks.Keys(ctx, "k1")
+		cancelled <- err
+	}()
+	<-requested
+	cancel()
+	if err := <-cancelled; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Keys() with cancelled context error = %v, want %v", err, context.Canceled)
+	}
+	errs := make(chan error, 5)
+	for i := 0; i < cap(errs); i++ {
+		go func() {
+			_, err := jwks.Keys(context.Background(), "k1")
+			errs <- err
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	for i := 0; i < cap(errs); i++ {
+		if err := <-errs; err != nil {
+			t.Errorf("Keys() error = %v", err)
+		}
+	}
+	if n := atomic.LoadInt32(&fetches); n != 1 {
+		t.Errorf("fetches = %d, want 1", n)
+	}
+}
+
+func TestAPIKeyAndMTLS(t *testing.T) {
+	hash := sha256.Sum256([]byte("key-2"))
+	apikeys, err := NewAPIKeyAuthenticator("", []APIKey{
+		{Name: "svc-1", Key: "key-1", Scopes: []string{"read"}},
+		{Name: "svc-2", Hash: hex.EncodeToString(hash[:])},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spiffe, _ := url.Parse("spiffe://cluster/ns/default/sa/orders")
+	authn := Chain(apikeys, NewMTLSAuthenticator(map[string][]string{spiffe.String(): {"orders"}}))
+	tests := []struct {
+		name    string
+		req     *Request
+		subject string
+		method  string
+		wantErr error
+	}{
+		{"plain key", &Request{Header: metadata.Pairs("x-api-key", "key-1")}, "svc-1", MethodAPIKey, nil},
+		{"hashed key", &Request{Header: metadata.Pairs("x-api-key", "key-2")}, "svc-2", MethodAPIKey, nil},
+		{"unknown key", &Request{Header: metadata.Pairs("x-api-key", "key-3")}, "", "", ErrInvalidCredentials},
+		{"uri san", &Request{PeerCertificates: []*x509.Certificate{{URIs: []*url.URL{spiffe}}}}, spiffe.String(), MethodMTLS, nil},
+		{"no credentials", &Request{}, "", "", ErrNoCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := authn.Authenticate(context.Background(), tt.req)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Fatalf("Authenticate() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (p.Subject != tt.subject || p.Method != tt.method) {
+				t.Errorf("Authenticate() = %+v, want subject %q, method %q", p, tt.subject, tt.method)
+			}
+		})
+	}
+}
+
+func TestUnverifiedClientCertificate(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	spiffe, _ := url.Parse("spiffe://cluster/ns/default/sa/admin")
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "admin"},
+		URIs:         []*url.URL{spiffe},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	authn := NewMTLSAuthenticator(map[string][]string{spiffe.String(): {"admin"}})
+	tests := []struct {
+		name       string
+		clientAuth tls.ClientAuthType
+		wantErr    error
+	}{
+		{"request", tls.RequestClientCert, ErrNoCredentials},
+		{"require any", tls.RequireAnyClientCert, ErrNoCredentials},
+		{"require and verify", tls.RequireAndVerifyClientCert, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var authErr error
+			srv := httptest.NewUnstartedServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				_, authErr = authn.Authenticate(r.Context(), NewHTTPRequest(r))
+			}))
+			pool := x509.NewCertPool()
+			pool.AddCert(cert)
+			srv.TLS = &tls.Config{ClientAuth: tt.clientAuth, ClientCAs: pool}
+			srv.StartTLS()
+			defer srv.Close()
+			client := srv.Client()
+			client.Transport.(*http.Transport).TLSClientConfig.Certificates = []tls.Certificate{{
+				Certificate: [][]byte{der},
+				PrivateKey:  key,
+			}}
+			resp, err := client.Get(srv.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if !errors.Is(authErr, tt.wantErr) || (tt.wantErr == nil && authErr != nil) {
+				t.Errorf("Authenticate() error = %v, want %v", authErr, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestInterceptor(t *testing.T) {
+	authn := AuthenticatorFunc(func(ctx context.Context, req *Request) (*Principal, error) {
+		switch req.Get("x-user") {
+		case "":
+			return nil, ErrNoCredentials
+		case "admin":
+			return &Principal{Subject: "admin", Scopes: []string{"read", "write"}}, nil
+		case "reader":
+			return &Principal{Subject: "reader", Scopes: []string{"read"}}, nil
+		}
+		return nil, ErrInvalidCredentials
+	})
+	handler := Interceptor(authn, WithOptional(), WithScopes("read"), WithMethodScopes("pkg.Service/Write", "write"))(
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			if p := ContextPrincipal(ctx); p != nil {
+				return p.Subject, nil
+			}
+			return "anonymous", nil
+		},
+	)
+	tests := []struct {
+		user   string
+		method string
+		want   interface{}
+		code   codes.Code
+	}{
+		{"admin", "Write", "admin", codes.OK},
+		{"reader", "Read", "reader", codes.OK},
+		{"reader", "Write", nil, codes.PermissionDenied},
+		{"", "Read", nil, codes.Unauthenticated},
+		{"unknown", "Read", nil, codes.Unauthenticated},
+	}
+	for _, tt := range tests {
+		ctx := transport.WithServiceInfo(context.Background(), transport.NewServiceInfo("pkg.Service", tt.method, nil))
+		if len(tt.user) > 0 {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("x-user", tt.user))
+		}
+		got, err := handler(ctx, nil)
+		if code := transerrors.Code(err); code != tt.code {
+			t.Errorf("%s %s: code = %s, want %s", tt.user, tt.method, code, tt.code)
+		}
+		if got != tt.want {
+			t.Errorf("%s %s: got %v, want %v", tt.user, tt.method, got, tt.want)
+		}
+	}
+
+	// optional without required scopes
+	optional := Interceptor(authn, WithOptional())(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return ContextPrincipal(ctx) == nil, nil
+	})
+	if got, err := optional(context.Background(), nil); err != nil || got != true {
+		t.Errorf("optional interceptor = %v, %v, want anonymous request", got, err)
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package authz enforces the authorization rules declared by (custom.extension.auth) option of proto methods.
+package authz
+
+import (
+	"context"
+	"sync"
+
+	"github.com/erda-project/erda-infra/pkg/auth"
+	"github.com/erda-project/erda-infra/pkg/transport"
+	transerrors "github.com/erda-project/erda-infra/pkg/transport/errors"
+	"github.com/erda-project/erda-infra/pkg/transport/interceptor"
+)
+
+// Rule is the authorization rule of a method.
+type Rule struct {
+	// Public methods can be called without authentication and authorization.
+	Public bool
+	// Permissions are required to call the method, all of them are required.
+	Permissions []string
+	// Roles are allowed to call the method, one of them is required if it's not empty.
+	Roles []string
+}
+
+// Rules are keyed by the full method name of transport.ServiceInfo like "pkg.Service/Method", where Method is the Go name of method.
+type Rules map[string]*Rule
+
+var registry = struct {
+	lock  sync.RWMutex
+	rules Rules
+}{rules: make(Rules)}
+
+// RegisterRules registers rules of methods, it's called by generated code.
+func RegisterRules(rules Rules) {
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+	for method, rule := range rules {
+		registry.rules[method] = rule
+	}
+}
+
+// GetRule returns the registered rule of method.
+func GetRule(method string) *Rule {
+	registry.lock.RLock()
+	defer registry.lock.RUnlock()
+	return registry.rules[method]
+}
+
+// Checker checks whether the principal is allowed to call the method by rule.
+type Checker interface {
+	Check(ctx context.Context, p *auth.Principal, method string, rule *Rule) (bool, error)
+}
+
+// CheckerFunc .
+type CheckerFunc func(ctx context.Context, p *auth.Principal, method string, rule *Rule) (bool, error)
+
+// Check .
+func (f CheckerFunc) Check(ctx context.Context, p *auth.Principal, method string, rule *Rule) (bool, error) {
+	return f(ctx, p, method, rule)
+}
+
+// DefaultChecker allows the principal which has all permissions of rule in scopes and one of roles of rule.
+var DefaultChecker Checker = CheckerFunc(func(ctx context.Context, p *auth.Principal, method string, rule *Rule) (bool, error) {
+	if !p.HasScopes(rule.Permissions...) {
+		return false, nil
+	}
+	return len(rule.Roles) <= 0 || p.HasAnyRole(rule.Roles...), nil
+})
+
+type config struct {
+	rules       Rules
+	defaultRule *Rule
+}
+
+// Option .
+type Option func(*config)
+
+// WithRules setup rules overriding the registered rules.
+func WithRules(rules Rules) Option {
+	return func(c *config) {
+		for method, rule := range rules {
+			c.rules[method] = rule
+		}
+	}
+}
+
+// WithDefaultRule setup the rule of methods without rule, they are not checked by default.
+func WithDefaultRule(rule *Rule) Option {
+	return func(c *config) {
+		c.defaultRule = rule
+	}
+}
+
+// Interceptor returns an Interceptor for transport.WithInterceptors to enforce the rules of methods by checker,
+// it must be placed after the authentication interceptor which sets the principal to context,
+// use auth.WithOptional for the authentication interceptor to allow anonymous calls of public methods.
+// A PermissionDenied error is returned if the principal is not allowed, DefaultChecker is used if checker is nil.
+func Interceptor(checker Checker, opts ...Option) interceptor.Interceptor {
+	if checker == nil {
+		checker = DefaultChecker
+	}
+	cfg := &config{rules: make(Rules)}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return func(next interceptor.Handler) interceptor.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			method := transport.GetFullMethodName(ctx)
+			rule, ok := cfg.rules[method]
+			if !ok {
+				rule = GetRule(method)
+			}
+			if rule == nil {
+				rule = cfg.defaultRule
+			}
+			if rule == nil || rule.Public {
+				return next(ctx, req)
+			}
+			p := auth.ContextPrincipal(ctx)
+			if p == nil {
+				return nil, transerrors.Unauthenticated("authentication required")
+			}
+			allowed, err := checker.Check(ctx, p, method, rule)
+			if err != nil {
+				return nil, transerrors.Unavailable("fail to check permission: %s", err)
+			}
+			if !allowed {
+				return nil, transerrors.PermissionDenied("permission denied for %s", method)
+			}
+			return next(ctx, req)
+		}
+	}
+}
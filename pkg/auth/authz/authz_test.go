@@ -0,0 +1,75 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authz
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+
+	"github.com/erda-project/erda-infra/pkg/auth"
+	"github.com/erda-project/erda-infra/pkg/transport"
+	transerrors "github.com/erda-project/erda-infra/pkg/transport/errors"
+)
+
+func TestInterceptor(t *testing.T) {
+	RegisterRules(Rules{
+		"pkg.Orders/List":   {Permissions: []string{"orders.read"}},
+		"pkg.Orders/Delete": {Permissions: []string{"orders.write"}, Roles: []string{"admin", "owner"}},
+		"pkg.Orders/Ping":   {Public: true},
+	})
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+	reader := &auth.Principal{Subject: "reader", Scopes: []string{"orders.read"}}
+	writer := &auth.Principal{Subject: "writer", Scopes: []string{"orders.read", "orders.write"}}
+	admin := &auth.Principal{Subject: "admin", Scopes: []string{"orders.write"}, Roles: []string{"admin"}}
+	tests := []struct {
+		name      string
+		checker   Checker
+		opts      []Option
+		method    string
+		principal *auth.Principal
+		code      codes.Code
+	}{
+		{"allowed", nil, nil, "List", reader, codes.OK},
+		{"missing permission", nil, nil, "Delete", reader, codes.PermissionDenied},
+		{"missing role", nil, nil, "Delete", writer, codes.PermissionDenied},
+		{"permission and role", nil, nil, "Delete", admin, codes.OK},
+		{"unauthenticated", nil, nil, "List", nil, codes.Unauthenticated},
+		{"public", nil, nil, "Ping", nil, codes.OK},
+		{"no rule", nil, nil, "Other", nil, codes.OK},
+		{"default rule", nil, []Option{WithDefaultRule(&Rule{Permissions: []string{"orders.admin"}})}, "Other", reader, codes.PermissionDenied},
+		{"override rule", nil, []Option{WithRules(Rules{"pkg.Orders/List": {Public: true}})}, "List", nil, codes.OK},
+		{"custom checker", CheckerFunc(func(ctx context.Context, p *auth.Principal, method string, rule *Rule) (bool, error) {
+			return p.Subject == "reader" && method == "pkg.Orders/Delete", nil
+		}), nil, "Delete", reader, codes.OK},
+		{"checker error", CheckerFunc(func(ctx context.Context, p *auth.Principal, method string, rule *Rule) (bool, error) {
+			return false, errors.New("policy server is down")
+		}), nil, "List", reader, codes.Unavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := transport.WithServiceInfo(context.Background(), transport.NewServiceInfo("pkg.Orders", tt.method, nil))
+			if tt.principal != nil {
+				ctx = auth.WithPrincipal(ctx, tt.principal)
+			}
+			_, err := Interceptor(tt.checker, tt.opts...)(handler)(ctx, nil)
+			if code := transerrors.Code(err); code != tt.code {
+				t.Errorf("code = %s, want %s, err = %v", code, tt.code, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"errors"
+
+	"github.com/erda-project/erda-infra/pkg/transport"
+	transerrors "github.com/erda-project/erda-infra/pkg/transport/errors"
+	"github.com/erda-project/erda-infra/pkg/transport/interceptor"
+)
+
+type interceptorConfig struct {
+	scopes   []string
+	methods  map[string][]string
+	optional bool
+}
+
+// Option .
+type Option func(*interceptorConfig)
+
+// WithScopes setup the scopes required by all methods.
+func WithScopes(scopes ...string) Option {
+	return func(c *interceptorConfig) {
+		c.scopes = append(c.scopes, scopes...)
+	}
+}
+
+// WithMethodScopes setup the scopes required by method, name is the full method name like "pkg.Service/Method" or a service name like "pkg.Service".
+func WithMethodScopes(name string, scopes ...string) Option {
+	return func(c *interceptorConfig) {
+		c.methods[name] = append(c.methods[name], scopes...)
+	}
+}
+
+// WithOptional allows requests without credentials to methods requiring no scopes, they have no principal in context.
+func WithOptional() Option {
+	return func(c *interceptorConfig) {
+		c.optional = true
+	}
+}
+
+// Interceptor returns an Interceptor for transport.WithInterceptors to authenticate requests and check scopes,
+// the principal is set to context and can be got by ContextPrincipal.
+// The request is not authenticated again if there is a principal in context already, e.g. set by http route option.
+func Interceptor(authn Authenticator, opts ...Option) interceptor.Interceptor {
+	cfg := &interceptorConfig{methods: make(map[string][]string)}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return func(next interceptor.Handler) interceptor.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			scopes := cfg.scopes
+			if info := transport.ContextServiceInfo(ctx); info != nil {
+				scopes = append(append(scopes[:len(scopes):len(scopes)], cfg.methods[info.Service()]...), cfg.methods[info.Service()+"/"+info.Method()]...)
+			}
+			p := ContextPrincipal(ctx)
+			if p == nil {
+				var err error
+				p, err = authn.Authenticate(ctx, RequestFromContext(ctx))
+				if err != nil {
+					if errors.Is(err, ErrNoCredentials) && cfg.optional && len(scopes) <= 0 {
+						return next(ctx, req)
+					}
+					return nil, ToError(err)
+				}
+				ctx = WithPrincipal(ctx, p)
+			}
+			if !p.HasScopes(scopes...) {
+				return nil, transerrors.PermissionDenied("permission denied for %s", transport.GetFullMethodName(ctx))
+			}
+			return next(ctx, req)
+		}
+	}
+}
+
+// ToError converts the error of Authenticator to an Unauthenticated error,
+// or an Unavailable error if the credentials can't be verified, e.g. fail to fetch keys.
+func ToError(err error) error {
+	if errors.Is(err, ErrNoCredentials) {
+		return transerrors.Unauthenticated("authentication required")
+	}
+	if errors.Is(err, ErrInvalidCredentials) {
+		return transerrors.Unauthenticated("%s", err)
+	}
+	return transerrors.Unavailable("authentication unavailable: %s", err)
+}
@@ -0,0 +1,295 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// JWKS is a KeySource fetching keys from a JWKS URL, keys are cached and refreshed periodically,
+// they are also refetched if a token is signed by an unknown kid, e.g. the keys are rotated.
+type JWKS struct {
+	url        string
+	client     *http.Client
+	refresh    time.Duration
+	minRefetch time.Duration
+	timeout    time.Duration
+	now        func() time.Time
+	group      singleflight.Group
+
+	lock        sync.Mutex
+	keys        StaticKeys
+	fetched     time.Time
+	lastAttempt time.Time
+}
+
+// JWKSOption .
+type JWKSOption func(*JWKS)
+
+// WithHTTPClient setup the client to fetch keys.
+func WithHTTPClient(client *http.Client) JWKSOption {
+	return func(s *JWKS) {
+		s.client = client
+	}
+}
+
+// WithRefreshInterval setup the interval to refresh keys, 10m by default.
+func WithRefreshInterval(interval time.Duration) JWKSOption {
+	return func(s *JWKS) {
+		s.refresh = interval
+	}
+}
+
+// WithMinRefetchInterval setup the min interval between fetches, to avoid fetching for each token with unknown kid, 30s by default.
+func WithMinRefetchInterval(interval time.Duration) JWKSOption {
+	return func(s *JWKS) {
+		s.minRefetch = interval
+	}
+}
+
+// WithFetchTimeout setup the timeout of fetching keys, 10s by default.
+func WithFetchTimeout(timeout time.Duration) JWKSOption {
+	return func(s *JWKS) {
+		s.timeout = timeout
+	}
+}
+
+// NewJWKS .
+func NewJWKS(url string, opts ...JWKSOption) *JWKS {
+	s := &JWKS{
+		url:        url,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		refresh:    10 * time.Minute,
+		minRefetch: 30 * time.Second,
+		timeout:    10 * time.Second,
+		now:        time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Keys .
+func (s *JWKS) Keys(ctx context.Context, kid string) ([]*Key, error) {
+	var fetchErr error
+	if s.shouldFetch(kid) {
+		// fetch once for concurrent callers, and not bound to the ctx of any caller
+		ch := s.group.DoChan("", func() (interface{}, error) {
+			if !s.shouldFetch(kid) {
+				return nil, nil
+			}
+			return nil, s.refetch()
+		})
+		select {
+		case r := <-ch:
+			fetchErr = r.Err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	s.lock.Lock()
+	keys := s.keys
+	s.lock.Unlock()
+	if keys == nil {
+		if fetchErr != nil {
+			return nil, fetchErr
+		}
+		return nil, fmt.Errorf("no keys fetched from %s", s.url)
+	}
+	return keys.Keys(ctx, kid)
+}
+
+func (s *JWKS) shouldFetch(kid string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	now := s.now()
+	expired := s.keys == nil || now.Sub(s.fetched) >= s.refresh
+	unknown := len(kid) > 0 && !s.hasKey(kid)
+	return (expired || unknown) && (s.lastAttempt.IsZero() || now.Sub(s.lastAttempt) >= s.minRefetch)
+}
+
+func (s *JWKS) refetch() error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	now := s.now()
+	keys, err := s.fetch(ctx)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.lastAttempt = now
+	if err != nil {
+		return err // keep using the cached keys until the next attempt
+	}
+	s.keys, s.fetched = keys, now
+	return nil
+}
+
+func (s *JWKS) hasKey(kid string) bool {
+	for _, k := range s.keys {
+		if k.ID == kid {
+			return true
+		}
+	}
+	return false
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+func (s *JWKS) fetch(ctx context.Context) (StaticKeys, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := getJSON(ctx, s.client, s.url, &set); err != nil {
+		return nil, fmt.Errorf("fail to fetch jwks: %w", err)
+	}
+	keys := make(StaticKeys, 0, len(set.Keys))
+	for _, item := range set.Keys {
+		if len(item.Use) > 0 && item.Use != "sig" {
+			continue
+		}
+		key, err := item.key()
+		if err != nil {
+			return nil, fmt.Errorf("invalid jwk %q: %w", item.Kid, err)
+		}
+		if key == nil {
+			continue // unsupported key type
+		}
+		keys = append(keys, &Key{ID: item.Kid, Algorithm: item.Alg, Key: key})
+	}
+	return keys, nil
+}
+
+func (k *jwk) key() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("point is not on curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	case "oct":
+		return base64.RawURLEncoding.DecodeString(k.K)
+	}
+	return nil, nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(data), nil
+}
+
+// DiscoverJWKS returns the jwks_uri of OpenID Connect provider by its discovery document.
+func DiscoverJWKS(ctx context.Context, client *http.Client, issuer string) (string, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	var doc struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}
+	url := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+	if err := getJSON(ctx, client, url, &doc); err != nil {
+		return "", fmt.Errorf("fail to discover openid configuration: %w", err)
+	}
+	if doc.Issuer != issuer {
+		return "", fmt.Errorf("issuer %q of openid configuration mismatch %q", doc.Issuer, issuer)
+	}
+	if len(doc.JWKSURI) <= 0 {
+		return "", fmt.Errorf("no jwks_uri in openid configuration")
+	}
+	return doc.JWKSURI, nil
+}
+
+func getJSON(ctx context.Context, client *http.Client, url string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(out)
+}
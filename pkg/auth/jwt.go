@@ -0,0 +1,342 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+)
+
+// Key verifies signatures of JWT.
+type Key struct {
+	// ID is the kid of key, the key is used for tokens with any kid if it's empty.
+	ID string
+	// Algorithm restricts the alg of tokens verified by the key, any algorithm matching the key type is allowed if it's empty.
+	Algorithm string
+	// Key is []byte for HMAC, *rsa.PublicKey, *ecdsa.PublicKey or ed25519.PublicKey.
+	Key interface{}
+}
+
+// KeySource provides the keys to verify tokens.
+type KeySource interface {
+	// Keys returns the candidate keys for kid.
+	Keys(ctx context.Context, kid string) ([]*Key, error)
+}
+
+// StaticKeys .
+type StaticKeys []*Key
+
+// Keys .
+func (s StaticKeys) Keys(ctx context.Context, kid string) ([]*Key, error) {
+	var keys []*Key
+	for _, k := range s {
+		if len(k.ID) <= 0 || len(kid) <= 0 || k.ID == kid {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
+
+// LoadKeyFile loads a PEM encoded public key or certificate, the file content is used as HMAC secret if it's not PEM.
+func LoadKeyFile(path, kid string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		secret := []byte(strings.TrimSpace(string(data)))
+		if len(secret) <= 0 {
+			return nil, fmt.Errorf("empty secret in %q", path)
+		}
+		return &Key{ID: kid, Key: secret}, nil
+	}
+	var key interface{}
+	switch block.Type {
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		key = cert.PublicKey
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key in %q: %w", path, err)
+	}
+	return &Key{ID: kid, Key: key}, nil
+}
+
+// JWTOptions .
+type JWTOptions struct {
+	// Issuer is the expected iss claim, it's not verified if empty.
+	Issuer string
+	// Audience are the accepted aud claims, it's not verified if empty.
+	Audience []string
+	// Algorithms are the accepted algorithms, all supported algorithms are accepted if empty.
+	Algorithms []string
+	// Leeway is the allowed clock skew to verify exp, nbf and iat.
+	Leeway time.Duration
+	// SubjectClaim is the claim of subject, sub by default.
+	SubjectClaim string
+	// ScopesClaim is the claim of scopes, it's a space separated string or an array, scope by default.
+	ScopesClaim string
+	// RolesClaim is the claim of roles, it's an array or a string, roles by default.
+	RolesClaim string
+}
+
+type jwtAuthenticator struct {
+	keys KeySource
+	opts JWTOptions
+	now  func() time.Time
+}
+
+// NewJWTAuthenticator returns an Authenticator verifying bearer JWT by keys.
+func NewJWTAuthenticator(keys KeySource, opts JWTOptions) Authenticator {
+	if len(opts.SubjectClaim) <= 0 {
+		opts.SubjectClaim = "sub"
+	}
+	if len(opts.ScopesClaim) <= 0 {
+		opts.ScopesClaim = "scope"
+	}
+	if len(opts.RolesClaim) <= 0 {
+		opts.RolesClaim = "roles"
+	}
+	return &jwtAuthenticator{keys: keys, opts: opts, now: time.Now}
+}
+
+func (a *jwtAuthenticator) Authenticate(ctx context.Context, req *Request) (*Principal, error) {
+	token := req.BearerToken()
+	if len(token) <= 0 || strings.Count(token, ".") != 2 {
+		// not a JWT, e.g. an opaque token handled by other authenticators
+		return nil, ErrNoCredentials
+	}
+	claims, err := a.verify(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	subject, _ := claims[a.opts.SubjectClaim].(string)
+	return &Principal{
+		Subject: subject,
+		Method:  MethodJWT,
+		Scopes:  claimStrings(claims[a.opts.ScopesClaim], true),
+		Roles:   claimStrings(claims[a.opts.RolesClaim], false),
+		Claims:  claims,
+	}, nil
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+func (a *jwtAuthenticator) verify(ctx context.Context, token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: invalid header: %s", ErrInvalidCredentials, err)
+	}
+	if len(a.opts.Algorithms) > 0 && !contains(a.opts.Algorithms, header.Alg) {
+		return nil, fmt.Errorf("%w: algorithm %q is not allowed", ErrInvalidCredentials, header.Alg)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid signature: %s", ErrInvalidCredentials, err)
+	}
+	keys, err := a.keys.Keys(ctx, header.Kid)
+	if err != nil {
+		// not ErrInvalidCredentials, the token can't be verified for now
+		return nil, fmt.Errorf("fail to get keys: %w", err)
+	}
+	signed := []byte(parts[0] + "." + parts[1])
+	verified := false
+	for _, key := range keys {
+		if len(key.Algorithm) > 0 && key.Algorithm != header.Alg {
+			continue
+		}
+		if err := verifySignature(header.Alg, key.Key, signed, sig); err == nil {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, fmt.Errorf("%w: signature verification failed", ErrInvalidCredentials)
+	}
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: invalid claims: %s", ErrInvalidCredentials, err)
+	}
+	if err := a.validate(claims); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCredentials, err)
+	}
+	return claims, nil
+}
+
+func (a *jwtAuthenticator) validate(claims map[string]interface{}) error {
+	now := a.now()
+	if exp, ok := claimTime(claims["exp"]); ok && !now.Before(exp.Add(a.opts.Leeway)) {
+		return errors.New("token is expired")
+	}
+	if nbf, ok := claimTime(claims["nbf"]); ok && now.Add(a.opts.Leeway).Before(nbf) {
+		return errors.New("token is not valid yet")
+	}
+	if iat, ok := claimTime(claims["iat"]); ok && now.Add(a.opts.Leeway).Before(iat) {
+		return errors.New("token is issued in the future")
+	}
+	if len(a.opts.Issuer) > 0 {
+		if iss, _ := claims["iss"].(string); iss != a.opts.Issuer {
+			return fmt.Errorf("unexpected issuer %q", iss)
+		}
+	}
+	if len(a.opts.Audience) > 0 {
+		found := false
+		for _, aud := range claimStrings(claims["aud"], false) {
+			if contains(a.opts.Audience, aud) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errors.New("unexpected audience")
+		}
+	}
+	return nil
+}
+
+func verifySignature(alg string, key interface{}, signed, sig []byte) error {
+	hash, ok := map[string]crypto.Hash{
+		"HS256": crypto.SHA256, "RS256": crypto.SHA256, "PS256": crypto.SHA256, "ES256": crypto.SHA256,
+		"HS384": crypto.SHA384, "RS384": crypto.SHA384, "PS384": crypto.SHA384, "ES384": crypto.SHA384,
+		"HS512": crypto.SHA512, "RS512": crypto.SHA512, "PS512": crypto.SHA512, "ES512": crypto.SHA512,
+	}[alg]
+	if alg == "EdDSA" {
+		k, ok := key.(ed25519.PublicKey)
+		if !ok || !ed25519.Verify(k, signed, sig) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	}
+	if !ok {
+		return fmt.Errorf("unsupported algorithm %q", alg)
+	}
+	h := hash.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+	switch alg[:2] {
+	case "HS":
+		secret, ok := key.([]byte)
+		if !ok {
+			return errors.New("key type mismatch")
+		}
+		mac := hmac.New(hash.New, secret)
+		mac.Write(signed)
+		if !hmac.Equal(mac.Sum(nil), sig) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	case "RS":
+		k, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errors.New("key type mismatch")
+		}
+		return rsa.VerifyPKCS1v15(k, hash, digest, sig)
+	case "PS":
+		k, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errors.New("key type mismatch")
+		}
+		return rsa.VerifyPSS(k, hash, digest, sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto})
+	case "ES":
+		k, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return errors.New("key type mismatch")
+		}
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return errors.New("invalid signature")
+		}
+		r, s := new(big.Int).SetBytes(sig[:size]), new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(k, digest, r, s) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported algorithm %q", alg)
+}
+
+func decodeSegment(seg string, out interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.UseNumber()
+	return dec.Decode(out)
+}
+
+func claimTime(v interface{}) (time.Time, bool) {
+	if n, ok := v.(json.Number); ok {
+		if f, err := n.Float64(); err == nil {
+			return time.Unix(0, int64(f*float64(time.Second))), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// claimStrings returns the strings of an array claim, or a string claim split by space if split is true.
+func claimStrings(v interface{}, split bool) []string {
+	switch val := v.(type) {
+	case string:
+		if split {
+			return strings.Fields(val)
+		}
+		return []string{val}
+	case []interface{}:
+		list := make([]string, 0, len(val))
+		for _, item := range val {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
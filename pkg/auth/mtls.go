@@ -0,0 +1,54 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"crypto/x509"
+)
+
+type mtlsAuthenticator struct {
+	scopes map[string][]string
+}
+
+// NewMTLSAuthenticator returns an Authenticator using the identity of client certificate verified by TLS,
+// the subject is the first URI SAN (e.g. a SPIFFE ID) or the common name of certificate,
+// scopes are granted by subject.
+func NewMTLSAuthenticator(scopes map[string][]string) Authenticator {
+	return &mtlsAuthenticator{scopes: scopes}
+}
+
+func (a *mtlsAuthenticator) Authenticate(ctx context.Context, req *Request) (*Principal, error) {
+	if len(req.PeerCertificates) <= 0 {
+		return nil, ErrNoCredentials
+	}
+	subject := CertificateSubject(req.PeerCertificates[0])
+	if len(subject) <= 0 {
+		return nil, ErrNoCredentials
+	}
+	return &Principal{
+		Subject: subject,
+		Method:  MethodMTLS,
+		Scopes:  a.scopes[subject],
+	}, nil
+}
+
+// CertificateSubject returns the first URI SAN or the common name of cert.
+func CertificateSubject(cert *x509.Certificate) string {
+	if len(cert.URIs) > 0 {
+		return cert.URIs[0].String()
+	}
+	return cert.Subject.CommonName
+}
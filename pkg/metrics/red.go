@@ -0,0 +1,219 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metrics provides helpers to record metrics by prometheus.
+package metrics
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// OtherLabelValue replaces label values exceeding the max number of label values.
+const OtherLabelValue = "other"
+
+// DefaultSizeBuckets are the default buckets of size histogram in bytes.
+var DefaultSizeBuckets = prometheus.ExponentialBuckets(64, 4, 8)
+
+type redConfig struct {
+	registerer     prometheus.Registerer
+	namespace      string
+	subsystem      string
+	buckets        []float64
+	sizeBuckets    []float64
+	constLabels    prometheus.Labels
+	maxLabelValues int
+}
+
+// Option .
+type Option func(*redConfig)
+
+// WithRegisterer setup the registerer of metrics, prometheus.DefaultRegisterer is used by default.
+func WithRegisterer(r prometheus.Registerer) Option {
+	return func(c *redConfig) {
+		c.registerer = r
+	}
+}
+
+// WithNamespace setup the namespace and subsystem of metric names.
+func WithNamespace(namespace, subsystem string) Option {
+	return func(c *redConfig) {
+		c.namespace, c.subsystem = namespace, subsystem
+	}
+}
+
+// WithBuckets setup the buckets of latency histogram in seconds, prometheus.DefBuckets is used by default.
+func WithBuckets(buckets []float64) Option {
+	return func(c *redConfig) {
+		if len(buckets) > 0 {
+			c.buckets = buckets
+		}
+	}
+}
+
+// WithSizeBuckets setup the buckets of response size histogram in bytes, DefaultSizeBuckets is used by default.
+func WithSizeBuckets(buckets []float64) Option {
+	return func(c *redConfig) {
+		if len(buckets) > 0 {
+			c.sizeBuckets = buckets
+		}
+	}
+}
+
+// WithConstLabels setup constant labels of metrics.
+func WithConstLabels(labels prometheus.Labels) Option {
+	return func(c *redConfig) {
+		c.constLabels = labels
+	}
+}
+
+// WithMaxLabelValues limits the number of distinct label values of requests, to avoid high cardinality,
+// values of new requests beyond the limit are recorded as OtherLabelValue. It's unlimited if n <= 0.
+func WithMaxLabelValues(n int) Option {
+	return func(c *redConfig) {
+		c.maxLabelValues = n
+	}
+}
+
+// RED records the rate, errors and duration of requests, with in-flight requests and response size.
+// Metrics registered by another RED with the same names are shared.
+type RED struct {
+	requests *prometheus.CounterVec
+	latency  *prometheus.HistogramVec
+	inflight *prometheus.GaugeVec
+	size     *prometheus.HistogramVec
+	guard    *labelGuard
+}
+
+// NewRED creates RED metrics labelled by labels and a status label named code.
+func NewRED(labels []string, code string, opts ...Option) *RED {
+	cfg := &redConfig{
+		registerer:  prometheus.DefaultRegisterer,
+		buckets:     prometheus.DefBuckets,
+		sizeBuckets: DefaultSizeBuckets,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	withCode := append(labels[:len(labels):len(labels)], code)
+	return &RED{
+		requests: Register(cfg.registerer, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        "requests_total",
+			Help:        "Total number of requests handled.",
+			ConstLabels: cfg.constLabels,
+		}, withCode)).(*prometheus.CounterVec),
+		latency: Register(cfg.registerer, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        "request_duration_seconds",
+			Help:        "Latency of requests handled.",
+			Buckets:     cfg.buckets,
+			ConstLabels: cfg.constLabels,
+		}, withCode)).(*prometheus.HistogramVec),
+		inflight: Register(cfg.registerer, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        "requests_in_flight",
+			Help:        "Number of requests being handled.",
+			ConstLabels: cfg.constLabels,
+		}, labels)).(*prometheus.GaugeVec),
+		size: Register(cfg.registerer, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        "response_size_bytes",
+			Help:        "Size of responses in bytes.",
+			Buckets:     cfg.sizeBuckets,
+			ConstLabels: cfg.constLabels,
+		}, withCode)).(*prometheus.HistogramVec),
+		guard: newLabelGuard(cfg.maxLabelValues),
+	}
+}
+
+// Observation is a request being observed.
+type Observation struct {
+	red    *RED
+	labels []string
+	start  time.Time
+}
+
+// Begin starts to observe a request with label values.
+func (m *RED) Begin(labels ...string) *Observation {
+	labels = m.guard.check(labels)
+	m.inflight.WithLabelValues(labels...).Inc()
+	return &Observation{red: m, labels: labels, start: time.Now()}
+}
+
+// End finishes the observation with status code and response size, the size is not observed if it's negative.
+func (o *Observation) End(code string, size int) {
+	m := o.red
+	m.inflight.WithLabelValues(o.labels...).Dec()
+	labels := append(o.labels[:len(o.labels):len(o.labels)], code)
+	m.requests.WithLabelValues(labels...).Inc()
+	m.latency.WithLabelValues(labels...).Observe(time.Since(o.start).Seconds())
+	if size >= 0 {
+		m.size.WithLabelValues(labels...).Observe(float64(size))
+	}
+}
+
+// labelGuard limits the number of distinct label values.
+type labelGuard struct {
+	max    int64
+	count  int64
+	values sync.Map
+}
+
+func newLabelGuard(max int) *labelGuard {
+	return &labelGuard{max: int64(max)}
+}
+
+func (g *labelGuard) check(labels []string) []string {
+	if g.max <= 0 {
+		return labels
+	}
+	key := strings.Join(labels, "\x00")
+	if _, ok := g.values.Load(key); ok {
+		return labels
+	}
+	if atomic.AddInt64(&g.count, 1) <= g.max {
+		if _, loaded := g.values.LoadOrStore(key, struct{}{}); loaded {
+			atomic.AddInt64(&g.count, -1)
+		}
+		return labels
+	}
+	atomic.AddInt64(&g.count, -1)
+	other := make([]string, len(labels))
+	for i := range other {
+		other[i] = OtherLabelValue
+	}
+	return other
+}
+
+// Register registers c to r, the existing collector is returned if it's already registered.
+func Register(r prometheus.Registerer, c prometheus.Collector) prometheus.Collector {
+	if err := r.Register(c); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if errors.As(err, &are) {
+			return are.ExistingCollector
+		}
+		panic(err)
+	}
+	return c
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRED(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	red := NewRED([]string{"method"}, "code",
+		WithRegisterer(reg),
+		WithNamespace("", "test"),
+		WithMaxLabelValues(2),
+	)
+	for _, method := range []string{"a", "b", "a", "c", "d"} {
+		o := red.Begin(method)
+		o.End("OK", 10)
+	}
+	red.Begin("a").End("Internal", -1)
+
+	want := `
+# HELP test_requests_total Total number of requests handled.
+# TYPE test_requests_total counter
+test_requests_total{code="Internal",method="a"} 1
+test_requests_total{code="OK",method="a"} 2
+test_requests_total{code="OK",method="b"} 1
+test_requests_total{code="OK",method="other"} 2
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want), "test_requests_total"); err != nil {
+		t.Error(err)
+	}
+	if n := testutil.CollectAndCount(red.size); n != 3 {
+		t.Errorf("got %d size series, want 3", n)
+	}
+	if v := testutil.ToFloat64(red.inflight.WithLabelValues("a")); v != 0 {
+		t.Errorf("got %v in flight, want 0", v)
+	}
+
+	// registered again, the existing collectors are reused.
+	again := NewRED([]string{"method"}, "code", WithRegisterer(reg), WithNamespace("", "test"))
+	if again.requests != red.requests {
+		t.Errorf("collectors are not reused")
+	}
+}
+
+func TestLabelGuard(t *testing.T) {
+	tests := []struct {
+		name   string
+		max    int
+		labels [][]string
+		want   []string
+	}{
+		{
+			name:   "unlimited",
+			labels: [][]string{{"a", "1"}, {"b", "2"}, {"c", "3"}},
+			want:   []string{"c", "3"},
+		},
+		{
+			name:   "within",
+			max:    2,
+			labels: [][]string{{"a", "1"}, {"b", "2"}, {"a", "1"}},
+			want:   []string{"a", "1"},
+		},
+		{
+			name:   "exceeded",
+			max:    2,
+			labels: [][]string{{"a", "1"}, {"b", "2"}, {"c", "3"}},
+			want:   []string{OtherLabelValue, OtherLabelValue},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newLabelGuard(tt.max)
+			var got []string
+			for _, labels := range tt.labels {
+				got = g.check(labels)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,271 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	// UnixScheme is the prefix of unix socket address, e.g. unix:///run/app.sock
+	UnixScheme = "unix://"
+	// SystemdScheme is the prefix of listeners inherited from systemd socket activation,
+	// e.g. systemd:// for the first one, or systemd://name for the one named by FileDescriptorName.
+	SystemdScheme = "systemd://"
+)
+
+// ListenOptions .
+type ListenOptions struct {
+	// SocketMode is the permission of unix socket file, it's not changed if zero.
+	SocketMode os.FileMode
+	// RemoveStale removes the existing unix socket file before listening if no one is listening on it.
+	RemoveStale bool
+	// KeepAlive is the period of TCP keep-alive, it's disabled if negative, the default value of net package is used if zero.
+	KeepAlive time.Duration
+}
+
+// ListenOption .
+type ListenOption func(*ListenOptions)
+
+// WithSocketMode .
+func WithSocketMode(mode os.FileMode) ListenOption {
+	return func(opts *ListenOptions) {
+		opts.SocketMode = mode
+	}
+}
+
+// WithRemoveStale .
+func WithRemoveStale(remove bool) ListenOption {
+	return func(opts *ListenOptions) {
+		opts.RemoveStale = remove
+	}
+}
+
+// WithKeepAlive .
+func WithKeepAlive(period time.Duration) ListenOption {
+	return func(opts *ListenOptions) {
+		opts.KeepAlive = period
+	}
+}
+
+// ParseFileMode parses octal file mode, e.g. 0660
+func ParseFileMode(mode string) (os.FileMode, error) {
+	if len(mode) <= 0 {
+		return 0, nil
+	}
+	m, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid file mode %q", mode)
+	}
+	return os.FileMode(m), nil
+}
+
+// Listen listens on addr, which is a TCP address host:port, a unix socket unix:///path,
+// or a listener inherited from systemd socket activation systemd://[name].
+func Listen(addr string, opts ...ListenOption) (net.Listener, error) {
+	options := &ListenOptions{RemoveStale: true}
+	for _, opt := range opts {
+		opt(options)
+	}
+	switch {
+	case strings.HasPrefix(addr, UnixScheme):
+		return listenUnix(strings.TrimPrefix(addr, UnixScheme), options)
+	case strings.HasPrefix(addr, SystemdScheme):
+		return SystemdListener(strings.TrimPrefix(addr, SystemdScheme))
+	}
+	lc := net.ListenConfig{KeepAlive: options.KeepAlive}
+	return lc.Listen(context.Background(), "tcp", addr)
+}
+
+func listenUnix(path string, opts *ListenOptions) (net.Listener, error) {
+	if len(path) <= 0 {
+		return nil, fmt.Errorf("empty unix socket path")
+	}
+	if opts.RemoveStale {
+		if err := removeStaleSocket(path); err != nil {
+			return nil, err
+		}
+	}
+	if opts.SocketMode == 0 {
+		return net.Listen("unix", path)
+	}
+	return listenUnixWithMode(path, opts.SocketMode)
+}
+
+// removeStaleSocket removes the socket file at path if no one is listening on it.
+func removeStaleSocket(path string) error {
+	fi, err := os.Lstat(path)
+	if err != nil {
+		return nil
+	}
+	if fi.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%q exists and is not a unix socket", path)
+	}
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err == nil {
+		conn.Close()
+		return fmt.Errorf("unix socket %q is in use", path)
+	}
+	if !errors.Is(err, syscall.ECONNREFUSED) {
+		return fmt.Errorf("fail to check unix socket %q: %w", path, err)
+	}
+	return os.Remove(path)
+}
+
+// listenUnixWithMode listens on a socket in a private directory, and links it to path after its mode is changed,
+// so that the socket can't be connected with the default permission.
+func listenUnixWithMode(path string, mode os.FileMode) (net.Listener, error) {
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".sock-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	tmp := filepath.Join(dir, filepath.Base(path))
+	l, err := net.Listen("unix", tmp)
+	if err != nil {
+		return nil, err
+	}
+	ul := l.(*net.UnixListener)
+	ul.SetUnlinkOnClose(false)
+	if err := os.Chmod(tmp, mode); err != nil {
+		l.Close()
+		return nil, err
+	}
+	// unlike rename, link fails if path exists
+	if err := os.Link(tmp, path); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return &unixListener{UnixListener: ul, path: path, unlink: true}, nil
+}
+
+// unixListener removes the socket file at path on close, the path of UnixListener is the temporary one.
+type unixListener struct {
+	*net.UnixListener
+	path   string
+	unlink bool
+	once   sync.Once
+}
+
+func (l *unixListener) Close() error {
+	err := l.UnixListener.Close()
+	l.once.Do(func() {
+		if l.unlink {
+			os.Remove(l.path)
+		}
+	})
+	return err
+}
+
+// SetUnlinkOnClose sets whether the socket file should be removed on close.
+func (l *unixListener) SetUnlinkOnClose(unlink bool) { l.unlink = unlink }
+
+var systemd struct {
+	once      sync.Once
+	err       error
+	listeners []net.Listener
+	names     []string
+	used      []bool
+	lock      sync.Mutex
+}
+
+// SystemdListener returns the listener inherited from systemd socket activation,
+// the first unused listener is returned if name is empty.
+func SystemdListener(name string) (net.Listener, error) {
+	systemd.once.Do(func() {
+		systemd.listeners, systemd.names, systemd.err = systemdListeners()
+		systemd.used = make([]bool, len(systemd.listeners))
+	})
+	if systemd.err != nil {
+		return nil, systemd.err
+	}
+	systemd.lock.Lock()
+	defer systemd.lock.Unlock()
+	for i, l := range systemd.listeners {
+		if systemd.used[i] || (len(name) > 0 && systemd.names[i] != name) {
+			continue
+		}
+		systemd.used[i] = true
+		return l, nil
+	}
+	if len(name) > 0 {
+		return nil, fmt.Errorf("no systemd listener named %q", name)
+	}
+	return nil, fmt.Errorf("no unused systemd listener")
+}
+
+// listenFdsStart is the first file descriptor passed by systemd.
+const listenFdsStart = 3
+
+// systemdListeners reads listeners passed by systemd, see sd_listen_fds(3).
+func systemdListeners() ([]net.Listener, []string, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil, fmt.Errorf("no listeners passed by systemd")
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n <= 0 {
+		return nil, nil, fmt.Errorf("no listeners passed by systemd")
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	// not to be inherited by child processes
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	listeners := make([]net.Listener, 0, n)
+	fdnames := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		fd := uintptr(listenFdsStart + i)
+		name := ""
+		if i < len(names) {
+			name = names[i]
+		}
+		f := os.NewFile(fd, name)
+		l, err := net.FileListener(f)
+		f.Close() // net.FileListener dups the fd
+		if err != nil {
+			return nil, nil, fmt.Errorf("fail to use fd %d from systemd: %w", fd, err)
+		}
+		listeners = append(listeners, l)
+		fdnames = append(fdnames, name)
+	}
+	return listeners, fdnames, nil
+}
+
+// SocketConfig is the config of unix socket, it's embedded by servers' config.
+type SocketConfig struct {
+	Mode        string `file:"mode" default:"0660" desc:"permission of unix socket file"`
+	RemoveStale bool   `file:"remove_stale" default:"true" desc:"remove the stale unix socket file before listening"`
+}
+
+// ListenOptions returns the options to listen with.
+func (c *SocketConfig) ListenOptions() ([]ListenOption, error) {
+	mode, err := ParseFileMode(c.Mode)
+	if err != nil {
+		return nil, err
+	}
+	return []ListenOption{WithSocketMode(mode), WithRemoveStale(c.RemoveStale)}, nil
+}
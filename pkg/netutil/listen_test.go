@@ -0,0 +1,112 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netutil
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseFileMode(t *testing.T) {
+	tests := []struct {
+		mode    string
+		want    os.FileMode
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"0660", 0660, false},
+		{"600", 0600, false},
+		{"0999", 0, true},
+		{"rw", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseFileMode(tt.mode)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseFileMode(%q) error = %v, wantErr %v", tt.mode, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseFileMode(%q) = %o, want %o", tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestListenUnix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.sock")
+	l, err := Listen(UnixScheme+path, WithSocketMode(0600))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0600 {
+		t.Errorf("socket mode = %o, want %o", fi.Mode().Perm(), 0600)
+	}
+
+	// leave a stale socket file, as a crashed process does
+	l.(interface{ SetUnlinkOnClose(bool) }).SetUnlinkOnClose(false)
+	l.Close()
+	if _, err := Listen(UnixScheme+path, WithRemoveStale(false)); err == nil {
+		t.Fatal("Listen() on stale socket without cleanup, want error")
+	}
+	l, err = Listen(UnixScheme + path)
+	if err != nil {
+		t.Fatalf("Listen() on stale socket error = %v", err)
+	}
+	l.Close()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("socket file is not removed on close, err = %v", err)
+	}
+}
+
+func TestListenUnixInUse(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.sock")
+	l, err := Listen(UnixScheme + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if _, err := Listen(UnixScheme+path, WithSocketMode(0600)); err == nil {
+		t.Fatal("Listen() on socket in use, want error")
+	}
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("socket in use is removed, Dial() error = %v", err)
+	}
+	conn.Close()
+}
+
+func TestListenUnixNotSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.sock")
+	if err := os.WriteFile(path, []byte("data"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Listen(UnixScheme + path); err == nil {
+		t.Fatal("Listen() on regular file, want error")
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("regular file is removed, err = %v", err)
+	}
+}
+
+func TestSystemdListenerWithoutActivation(t *testing.T) {
+	if _, err := Listen(SystemdScheme); err == nil {
+		t.Fatal("Listen() without socket activation, want error")
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package writer
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	pkgmetrics "github.com/erda-project/erda-infra/pkg/metrics"
+)
+
+// Metrics records batch sizes, flush latency, flush errors and queue depth of writers.
+type Metrics struct {
+	batchSize     *prometheus.HistogramVec
+	flushDuration *prometheus.HistogramVec
+	flushErrors   *prometheus.CounterVec
+	queueDepth    *prometheus.GaugeVec
+}
+
+// NewMetrics creates Metrics registered to r, writers are distinguished by the writer label.
+func NewMetrics(r prometheus.Registerer) *Metrics {
+	return &Metrics{
+		batchSize: pkgmetrics.Register(r, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "writer_batch_size",
+			Help:    "Number of items of each flushed batch.",
+			Buckets: prometheus.ExponentialBuckets(1, 4, 8),
+		}, []string{"writer"})).(*prometheus.HistogramVec),
+		flushDuration: pkgmetrics.Register(r, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "writer_flush_duration_seconds",
+			Help: "Latency of flushing batches.",
+		}, []string{"writer"})).(*prometheus.HistogramVec),
+		flushErrors: pkgmetrics.Register(r, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "writer_flush_errors_total",
+			Help: "Total number of failed flushes.",
+		}, []string{"writer"})).(*prometheus.CounterVec),
+		queueDepth: pkgmetrics.Register(r, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "writer_queue_depth",
+			Help: "Number of items waiting to be buffered.",
+		}, []string{"writer"})).(*prometheus.GaugeVec),
+	}
+}
+
+// ObserveFlush records a flush of n items which took d, it's safe to call on nil Metrics.
+func (m *Metrics) ObserveFlush(writer string, n int, d time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	m.batchSize.WithLabelValues(writer).Observe(float64(n))
+	m.flushDuration.WithLabelValues(writer).Observe(d.Seconds())
+	if err != nil {
+		m.flushErrors.WithLabelValues(writer).Inc()
+	}
+}
+
+// Wrap returns a Writer recording flushes of w, w is returned if m is nil.
+func (m *Metrics) Wrap(writer string, w Writer) Writer {
+	if m == nil {
+		return w
+	}
+	return &observedWriter{Writer: w, name: writer, m: m}
+}
+
+type observedWriter struct {
+	Writer
+	name string
+	m    *Metrics
+}
+
+func (w *observedWriter) Write(data interface{}) error {
+	start := time.Now()
+	err := w.Writer.Write(data)
+	w.m.ObserveFlush(w.name, 1, time.Since(start), err)
+	return err
+}
+
+func (w *observedWriter) WriteN(data ...interface{}) (int, error) {
+	start := time.Now()
+	n, err := w.Writer.WriteN(data...)
+	w.m.ObserveFlush(w.name, len(data), time.Since(start), err)
+	return n, err
+}
+
+// Option is an option of ParallelBatch.
+type Option func(*options)
+
+type options struct {
+	metrics *Metrics
+	name    string
+}
+
+// WithMetrics records metrics of the writer to m with name as the writer label.
+func WithMetrics(m *Metrics, name string) Option {
+	return func(opts *options) {
+		opts.metrics = m
+		opts.name = name
+	}
+}
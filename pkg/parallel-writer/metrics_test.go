@@ -0,0 +1,91 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package writer
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type countWriter struct {
+	lock  sync.Mutex
+	items int
+	err   error
+}
+
+func (w *countWriter) Write(data interface{}) error {
+	_, err := w.WriteN(data)
+	return err
+}
+
+func (w *countWriter) WriteN(data ...interface{}) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.err != nil {
+		return 0, w.err
+	}
+	w.items += len(data)
+	return len(data), nil
+}
+
+func (w *countWriter) Close() error { return nil }
+
+func TestParallelBatchMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := NewMetrics(reg)
+	cw := &countWriter{}
+	w := ParallelBatch(func(uint64) Writer { return cw }, 1, 4, time.Hour, IngoreError, WithMetrics(m, "test"))
+	n, err := w.WriteN(1, 2, 3, 4, 5, 6, 7, 8, 9, 10)
+	if n != 10 || err != nil {
+		t.Fatalf("WriteN got %d, %v", n, err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if cw.items != 10 {
+		t.Errorf("got %d items written, want 10", cw.items)
+	}
+	if v := testutil.ToFloat64(m.queueDepth.WithLabelValues("test")); v != 0 {
+		t.Errorf("got queue depth %v, want 0", v)
+	}
+	if n := testutil.CollectAndCount(m.batchSize); n != 1 {
+		t.Errorf("got %d batch size series, want 1", n)
+	}
+	if n := testutil.CollectAndCount(m.flushErrors); n != 0 {
+		t.Errorf("got %d flush error series, want 0", n)
+	}
+}
+
+func TestMetricsWrap(t *testing.T) {
+	var m *Metrics
+	cw := &countWriter{}
+	if m.Wrap("test", cw) != Writer(cw) {
+		t.Errorf("nil Metrics should return the writer itself")
+	}
+
+	m = NewMetrics(prometheus.NewRegistry())
+	cw.err = errors.New("failed")
+	w := m.Wrap("test", cw)
+	w.Write(1)
+	w.WriteN(1, 2)
+	if v := testutil.ToFloat64(m.flushErrors.WithLabelValues("test")); v != 2 {
+		t.Errorf("got %v flush errors, want 2", v)
+	}
+}
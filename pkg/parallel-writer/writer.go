@@ -17,6 +17,7 @@ package writer
 import (
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/recallsong/go-utils/errorx"
 
 	"github.com/erda-project/erda-infra/pkg/numutil"
@@ -45,7 +46,16 @@ func ParallelBatch(
 	size uint64, // buffer size
 	timeout time.Duration, // timeout for buffer flush
 	errorh ErrorHandler, // error handler
+	opts ...Option,
 ) Writer {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.metrics != nil {
+		create := writers
+		writers = func(i uint64) Writer { return o.metrics.Wrap(o.name, create(i)) }
+	}
 	if parallelism <= 0 {
 		if size <= 1 {
 			return writers(0)
@@ -57,6 +67,7 @@ func ParallelBatch(
 			dataCh:      make(chan interface{}, parallelism),
 			errorCh:     make(chan error, parallelism),
 			parallelism: parallelism,
+			pending:     o.pendingGauge(),
 		}
 		for i := uint64(0); i < parallelism; i++ {
 			go func(w Writer, in *channelWriter) {
@@ -69,6 +80,7 @@ func ParallelBatch(
 					writer.errorCh <- err
 				}()
 				for data := range in.dataCh {
+					in.received()
 					err = w.Write(data)
 					if err != nil {
 						if errorh != nil {
@@ -89,6 +101,7 @@ func ParallelBatch(
 		dataCh:      make(chan interface{}, parallelism*size),
 		errorCh:     make(chan error, parallelism),
 		parallelism: parallelism,
+		pending:     o.pendingGauge(),
 	}
 	for i := uint64(0); i < parallelism; i++ {
 		go func(w Writer, in *channelWriter) {
@@ -112,6 +125,7 @@ func ParallelBatch(
 					if !ok {
 						return
 					}
+					in.received()
 					err = buf.Write(data)
 					if err != nil {
 						if errorh != nil {
@@ -142,24 +156,44 @@ func ParallelBatch(
 	return writer
 }
 
+func (o *options) pendingGauge() prometheus.Gauge {
+	if o.metrics == nil {
+		return nil
+	}
+	return o.metrics.queueDepth.WithLabelValues(o.name)
+}
+
 type channelWriter struct {
 	dataCh      chan interface{}
 	errorCh     chan error
 	parallelism uint64
+	pending     prometheus.Gauge
 }
 
 func (w *channelWriter) Write(data interface{}) error {
+	if w.pending != nil {
+		w.pending.Inc()
+	}
 	w.dataCh <- data
 	return nil
 }
 
 func (w *channelWriter) WriteN(data ...interface{}) (int, error) {
+	if w.pending != nil {
+		w.pending.Add(float64(len(data)))
+	}
 	for _, item := range data {
 		w.dataCh <- item
 	}
 	return len(data), nil
 }
 
+func (w *channelWriter) received() {
+	if w.pending != nil {
+		w.pending.Dec()
+	}
+}
+
 func (w *channelWriter) Close() error {
 	close(w.dataCh)
 	var errs errorx.Errors
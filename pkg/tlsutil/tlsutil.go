@@ -0,0 +1,207 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tlsutil
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Options of server side TLS.
+type Options struct {
+	CertFile     string
+	KeyFile      string
+	CAFile       string   // CA to verify client certs
+	ClientAuth   string   // none, request, require_any, optional or require
+	MinVersion   string   // 1.0, 1.1, 1.2 or 1.3
+	CipherSuites []string // names of cipher suites, e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256
+	NextProtos   []string
+}
+
+// ParseClientAuth parses name of client auth type, require is returned for empty name if verify is true.
+// Client certs are verified by require and optional, but not by request and require_any.
+func ParseClientAuth(name string, verify bool) (tls.ClientAuthType, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "":
+		if verify {
+			return tls.RequireAndVerifyClientCert, nil
+		}
+		return tls.NoClientCert, nil
+	case "none":
+		return tls.NoClientCert, nil
+	case "request":
+		return tls.RequestClientCert, nil
+	case "require_any":
+		return tls.RequireAnyClientCert, nil
+	case "optional", "verify_if_given":
+		return tls.VerifyClientCertIfGiven, nil
+	case "require", "require_and_verify":
+		return tls.RequireAndVerifyClientCert, nil
+	}
+	return 0, fmt.Errorf("invalid client auth %q, expect none, request, require_any, optional or require", name)
+}
+
+// ParseVersion parses TLS version, e.g. 1.2
+func ParseVersion(version string) (uint16, error) {
+	switch strings.TrimPrefix(strings.ToLower(strings.TrimSpace(version)), "tls") {
+	case "":
+		return 0, nil
+	case "1.0", "10":
+		return tls.VersionTLS10, nil
+	case "1.1", "11":
+		return tls.VersionTLS11, nil
+	case "1.2", "12":
+		return tls.VersionTLS12, nil
+	case "1.3", "13":
+		return tls.VersionTLS13, nil
+	}
+	return 0, fmt.Errorf("invalid tls version %q", version)
+}
+
+// ParseCipherSuites converts names to ids of cipher suites.
+func ParseCipherSuites(names []string) ([]uint16, error) {
+	if len(names) <= 0 {
+		return nil, nil
+	}
+	suites := make(map[string]uint16)
+	for _, cs := range tls.CipherSuites() {
+		suites[cs.Name] = cs.ID
+	}
+	for _, cs := range tls.InsecureCipherSuites() {
+		suites[cs.Name] = cs.ID
+	}
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		id, ok := suites[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown cipher suite %q", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// Loader loads server TLS config from files, and reloads it when the files are changed.
+type Loader struct {
+	opts       Options
+	clientAuth tls.ClientAuthType
+	minVersion uint16
+	ciphers    []uint16
+	current    atomic.Value // *tls.Config
+	digest     atomic.Value // string
+}
+
+// NewLoader creates a Loader and loads files.
+func NewLoader(opts Options) (*Loader, error) {
+	if len(opts.CertFile) <= 0 || len(opts.KeyFile) <= 0 {
+		return nil, fmt.Errorf("cert file and key file are required")
+	}
+	l := &Loader{opts: opts}
+	var err error
+	if l.clientAuth, err = ParseClientAuth(opts.ClientAuth, len(opts.CAFile) > 0); err != nil {
+		return nil, err
+	}
+	if (l.clientAuth == tls.VerifyClientCertIfGiven || l.clientAuth == tls.RequireAndVerifyClientCert) && len(opts.CAFile) <= 0 {
+		// client certificates would be verified by the system roots
+		return nil, fmt.Errorf("ca file is required to verify client certificates")
+	}
+	if l.minVersion, err = ParseVersion(opts.MinVersion); err != nil {
+		return nil, err
+	}
+	if l.ciphers, err = ParseCipherSuites(opts.CipherSuites); err != nil {
+		return nil, err
+	}
+	if _, err := l.Reload(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// TLSConfig returns a tls.Config which always uses the latest loaded certificates.
+func (l *Loader) TLSConfig() *tls.Config {
+	cfg := l.current.Load().(*tls.Config).Clone()
+	cfg.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		return l.current.Load().(*tls.Config), nil
+	}
+	return cfg
+}
+
+// Reload loads files again if they are changed, returns true if reloaded.
+func (l *Loader) Reload() (bool, error) {
+	files := []string{l.opts.CertFile, l.opts.KeyFile}
+	if len(l.opts.CAFile) > 0 {
+		files = append(files, l.opts.CAFile)
+	}
+	data := make([][]byte, len(files))
+	h := sha256.New()
+	for i, file := range files {
+		byts, err := os.ReadFile(file)
+		if err != nil {
+			return false, err
+		}
+		data[i] = byts
+		h.Write(byts)
+	}
+	digest := string(h.Sum(nil))
+	if last, _ := l.digest.Load().(string); last == digest {
+		return false, nil
+	}
+	cert, err := tls.X509KeyPair(data[0], data[1])
+	if err != nil {
+		return false, err
+	}
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   l.clientAuth,
+		MinVersion:   l.minVersion,
+		CipherSuites: l.ciphers,
+		NextProtos:   l.opts.NextProtos,
+	}
+	if len(files) > 2 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data[2]) {
+			return false, fmt.Errorf("no valid certificate in %q", l.opts.CAFile)
+		}
+		cfg.ClientCAs = pool
+	}
+	l.current.Store(cfg)
+	l.digest.Store(digest)
+	return true, nil
+}
+
+// Watch checks files every interval and reloads them if changed, until ctx is done.
+func (l *Loader) Watch(ctx context.Context, interval time.Duration, onReload func(err error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			ok, err := l.Reload()
+			if (ok || err != nil) && onReload != nil {
+				onReload(err)
+			}
+		}
+	}
+}
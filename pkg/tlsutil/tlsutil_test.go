@@ -0,0 +1,133 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tlsutil
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeCert(t *testing.T, dir, name string) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile = filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func commonName(t *testing.T, cfg *tls.Config) string {
+	cfg, err := cfg.GetConfigForClient(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(cfg.Certificates[0].Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert.Subject.CommonName
+}
+
+func TestLoaderReload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeCert(t, dir, "first")
+	l, err := NewLoader(Options{CertFile: certFile, KeyFile: keyFile, CAFile: certFile, MinVersion: "1.2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := l.TLSConfig()
+	if cfg.MinVersion != tls.VersionTLS12 || cfg.ClientAuth != tls.RequireAndVerifyClientCert {
+		t.Errorf("TLSConfig() MinVersion = %v, ClientAuth = %v", cfg.MinVersion, cfg.ClientAuth)
+	}
+	if ok, err := l.Reload(); ok || err != nil {
+		t.Errorf("Reload() = %v, %v, want false without changes", ok, err)
+	}
+	writeCert(t, dir, "second")
+	if ok, err := l.Reload(); !ok || err != nil {
+		t.Errorf("Reload() = %v, %v, want true", ok, err)
+	}
+	if got := commonName(t, cfg); got != "second" {
+		t.Errorf("certificate = %q, want %q", got, "second")
+	}
+}
+
+func TestLoaderClientAuthWithoutCA(t *testing.T) {
+	certFile, keyFile := writeCert(t, t.TempDir(), "server")
+	for _, auth := range []string{"optional", "require"} {
+		if _, err := NewLoader(Options{CertFile: certFile, KeyFile: keyFile, ClientAuth: auth}); err == nil {
+			t.Errorf("NewLoader() with client auth %q without ca file, want error", auth)
+		}
+	}
+	for _, auth := range []string{"", "none", "request", "require_any"} {
+		if _, err := NewLoader(Options{CertFile: certFile, KeyFile: keyFile, ClientAuth: auth}); err != nil {
+			t.Errorf("NewLoader() with client auth %q error = %v", auth, err)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	if _, err := ParseClientAuth("unknown", false); err == nil {
+		t.Errorf("ParseClientAuth() should fail")
+	}
+	for name, want := range map[string]tls.ClientAuthType{
+		"require":     tls.RequireAndVerifyClientCert,
+		"optional":    tls.VerifyClientCertIfGiven,
+		"require_any": tls.RequireAnyClientCert,
+		"request":     tls.RequestClientCert,
+	} {
+		if got, err := ParseClientAuth(name, false); err != nil || got != want {
+			t.Errorf("ParseClientAuth(%q) = %v, %v, want %v", name, got, err, want)
+		}
+	}
+	if v, err := ParseVersion("TLS1.3"); err != nil || v != tls.VersionTLS13 {
+		t.Errorf("ParseVersion() = %v, %v", v, err)
+	}
+	if ids, err := ParseCipherSuites([]string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"}); err != nil || len(ids) != 1 {
+		t.Errorf("ParseCipherSuites() = %v, %v", ids, err)
+	}
+	if _, err := ParseCipherSuites([]string{"unknown"}); err == nil {
+		t.Errorf("ParseCipherSuites() should fail")
+	}
+}
@@ -27,6 +27,12 @@ import (
 
 const EnvTraceHookEnable = "TRACE_HOOK_ENABLE"
 
+// Enabled reports whether functions are hooked for tracing, providers apply the wrappers in pkg/trace/instrument explicitly otherwise.
+func Enabled() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv(EnvTraceHookEnable))
+	return enabled
+}
+
 // Hook .
 func Hook(target, replacement, trampoline interface{}) error {
 	defer func() {
@@ -34,7 +40,7 @@ func Hook(target, replacement, trampoline interface{}) error {
 			log.Printf("[ERROR] failed to hook %T : %v\n", target, err)
 		}
 	}()
-	if enabled, _ := strconv.ParseBool(os.Getenv(EnvTraceHookEnable)); !enabled {
+	if !Enabled() {
 		return nil
 	}
 	err := gohook.Hook(target, replacement, trampoline)
@@ -18,22 +18,38 @@ import (
 	"github.com/go-redis/redis"
 
 	"github.com/erda-project/erda-infra/pkg/trace/inject/hook"
+	traceredis "github.com/erda-project/erda-infra/pkg/trace/instrument/redis"
 )
 
-// Client .
-type Client interface {
-	WrapProcess(fn func(oldProcess func(redis.Cmder) error) func(redis.Cmder) error)
-	WrapProcessPipeline(fn func(oldProcess func([]redis.Cmder) error) func([]redis.Cmder) error)
-}
+type (
+	// Client .
+	Client = traceredis.Client
+	// Option .
+	Option = traceredis.Option
+	// OptionFunc .
+	OptionFunc = traceredis.OptionFunc
+	// SpanOptions .
+	SpanOptions = traceredis.SpanOptions
+	// SpanNameFormatter .
+	SpanNameFormatter = traceredis.SpanNameFormatter
+)
+
+var (
+	// WithTracerProvider .
+	WithTracerProvider = traceredis.WithTracerProvider
+	// WithAttributes .
+	WithAttributes = traceredis.WithAttributes
+	// WithSpanNameFormatter .
+	WithSpanNameFormatter = traceredis.WithSpanNameFormatter
+	// WithSpanOptions .
+	WithSpanOptions = traceredis.WithSpanOptions
+	// Version .
+	Version = traceredis.Version
+)
 
 // Wrap .
 func Wrap(client Client, opts ...Option) {
-	if client == nil {
-		return
-	}
-	cfg := newConfig("redis", opts...)
-	client.WrapProcess(newProcessWrapper(cfg))
-	client.WrapProcessPipeline(newProcessPipeline(cfg))
+	traceredis.Wrap(client, opts...)
 }
 
 //go:noinline
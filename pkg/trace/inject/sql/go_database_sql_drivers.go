@@ -16,13 +16,11 @@ package sql
 
 import (
 	"database/sql"
-	"sync"
 
-	"github.com/XSAM/otelsql"
 	_ "github.com/go-sql-driver/mysql" //nolint
-	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
 
 	"github.com/erda-project/erda-infra/pkg/trace/inject/hook"
+	tracesql "github.com/erda-project/erda-infra/pkg/trace/instrument/sql"
 )
 
 //go:noinline
@@ -30,37 +28,22 @@ func originalOpen(driverName, dataSourceName string) (*sql.DB, error) {
 	return sql.Open(driverName, dataSourceName)
 }
 
-var (
-	driversMu sync.Mutex
-	drivers   = map[string]string{}
-)
-
 //go:noinline
 func tracedOpen(driverName, dataSourceName string) (*sql.DB, error) {
-	driversMu.Lock()
-	if dname, ok := drivers[driverName]; !ok {
-		// retrieve the driver implementation we need to wrap with instrumentation
-		db, err := originalOpen(driverName, "")
-		if err != nil {
-			driversMu.Unlock()
-			return nil, err
-		}
-		d := db.Driver()
-		if err = db.Close(); err != nil {
-			driversMu.Unlock()
-			return nil, err
-		}
-		dname = "otelsql-" + driverName
-		sql.Register(dname, wrapDriver(otelsql.WrapDriver(d, otelsql.WithAttributes(
-			semconv.DBSystemKey.String(driverName),
-		))))
-		drivers[driverName] = dname
-		driverName = dname
-	} else {
-		driverName = dname
+	if name, ok := tracesql.RegisteredDriver(driverName); ok {
+		return originalOpen(name, dataSourceName)
+	}
+	// retrieve the driver implementation we need to wrap with instrumentation on the first call,
+	// by originalOpen rather than tracesql.Register, which calls the hooked sql.Open.
+	db, err := originalOpen(driverName, "")
+	if err != nil {
+		return nil, err
+	}
+	d := db.Driver()
+	if err = db.Close(); err != nil {
+		return nil, err
 	}
-	driversMu.Unlock()
-	return originalOpen(driverName, dataSourceName)
+	return originalOpen(tracesql.RegisterDriver(driverName, d), dataSourceName)
 }
 
 func init() {
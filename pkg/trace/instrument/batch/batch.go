@@ -0,0 +1,91 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tracebatch records spans of batch writes to storages.
+package tracebatch
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	injectcontext "github.com/erda-project/erda-infra/pkg/trace/inject/context"
+)
+
+const instrumentationName = "github.com/erda-project/erda-infra/pkg/trace/instrument/batch"
+
+// attribute keys of batch spans.
+var (
+	ItemsKey  = attribute.Key("db.batch.items")
+	BytesKey  = attribute.Key("db.batch.bytes")
+	FailedKey = attribute.Key("db.batch.failed_items")
+)
+
+// Span is a span of writing a batch.
+type Span struct {
+	trace.Span
+	bytes  int64
+	failed int
+}
+
+// Start starts a client span named name for writing a batch of items, attrs should contain db.system and the table or index.
+// The span in the goroutine context is used as parent if ctx has none, and valid span contexts in links are linked,
+// which are typically the contexts of items collected from different callers.
+// The global tracer provider is used if tp is nil.
+func Start(ctx context.Context, tp trace.TracerProvider, name string, items int, links []trace.SpanContext, attrs ...attribute.KeyValue) (context.Context, *Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	ctx = injectcontext.ContextWithSpan(ctx)
+	opts := []trace.SpanStartOption{
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(append(attrs, ItemsKey.Int(items))...),
+	}
+	for _, sc := range links {
+		if sc.IsValid() {
+			opts = append(opts, trace.WithLinks(trace.Link{SpanContext: sc}))
+		}
+	}
+	ctx, span := tp.Tracer(instrumentationName).Start(ctx, name, opts...)
+	return ctx, &Span{Span: span}
+}
+
+// AddBytes adds n to the size of batch in bytes.
+func (s *Span) AddBytes(n int64) { s.bytes += n }
+
+// SetFailed sets the number of items failed to write.
+func (s *Span) SetFailed(n int) { s.failed = n }
+
+// Finish records bytes, failed items and err, then ends the span.
+func (s *Span) Finish(err error) {
+	if s.bytes > 0 {
+		s.SetAttributes(BytesKey.Int64(s.bytes))
+	}
+	if s.failed > 0 {
+		s.SetAttributes(FailedKey.Int(s.failed))
+	}
+	if err != nil {
+		s.RecordError(err)
+		s.SetStatus(codes.Error, err.Error())
+	} else if s.failed > 0 {
+		s.SetStatus(codes.Error, "some items failed to write")
+	}
+	s.End()
+}
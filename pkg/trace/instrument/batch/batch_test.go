@@ -0,0 +1,88 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracebatch
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSpan(t *testing.T) {
+	tests := []struct {
+		name       string
+		bytes      int64
+		failed     int
+		err        error
+		wantStatus codes.Code
+		wantAttrs  map[attribute.Key]int64
+	}{
+		{
+			name:       "ok",
+			bytes:      100,
+			wantStatus: codes.Unset,
+			wantAttrs:  map[attribute.Key]int64{ItemsKey: 3, BytesKey: 100},
+		},
+		{
+			name:       "failed items",
+			failed:     2,
+			wantStatus: codes.Error,
+			wantAttrs:  map[attribute.Key]int64{ItemsKey: 3, FailedKey: 2},
+		},
+		{
+			name:       "error",
+			err:        errors.New("timeout"),
+			wantStatus: codes.Error,
+			wantAttrs:  map[attribute.Key]int64{ItemsKey: 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sr := tracetest.NewSpanRecorder()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+			ctx, parent := tp.Tracer("test").Start(context.Background(), "parent")
+			_, caller := tp.Tracer("test").Start(context.Background(), "caller")
+
+			_, span := Start(ctx, tp, "insert table", 3, []trace.SpanContext{caller.SpanContext(), {}})
+			span.AddBytes(tt.bytes)
+			span.SetFailed(tt.failed)
+			span.Finish(tt.err)
+
+			spans := sr.Ended()
+			if !assert.Len(t, spans, 1) {
+				return
+			}
+			got := spans[0]
+			assert.Equal(t, "insert table", got.Name())
+			assert.Equal(t, parent.SpanContext().SpanID(), got.Parent().SpanID())
+			if assert.Len(t, got.Links(), 1) {
+				assert.Equal(t, caller.SpanContext(), got.Links()[0].SpanContext)
+			}
+			assert.Equal(t, tt.wantStatus, got.Status().Code)
+			attrs := make(map[attribute.Key]int64)
+			for _, kv := range got.Attributes() {
+				attrs[kv.Key] = kv.Value.AsInt64()
+			}
+			assert.Equal(t, tt.wantAttrs, attrs)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tracehttp wraps http transports and handlers with tracing, without hooking net/http.
+package tracehttp
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+
+	injectcontext "github.com/erda-project/erda-infra/pkg/trace/inject/context"
+)
+
+// NewTransport wraps base to record client spans and inject the trace context into request headers.
+// If the request context has no span, the span in the goroutine context is used as parent.
+// http.DefaultTransport is used if base is nil.
+func NewTransport(base http.RoundTripper, opts ...otelhttp.Option) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{rt: otelhttp.NewTransport(base, opts...)}
+}
+
+type transport struct {
+	rt http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if pctx := injectcontext.ContextWithSpan(ctx); pctx != ctx {
+		req = req.WithContext(pctx)
+	}
+	return t.rt.RoundTrip(req)
+}
+
+// NewHandler wraps h to record server spans named by method and path,
+// and keeps the request context in the goroutine context during serving.
+func NewHandler(h http.Handler, opts ...otelhttp.Option) http.Handler {
+	opts = append([]otelhttp.Option{otelhttp.WithSpanNameFormatter(SpanName)}, opts...)
+	return otelhttp.NewHandler(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		injectcontext.SetContext(r.Context())
+		defer injectcontext.ClearContext()
+		h.ServeHTTP(rw, r)
+	}), "", opts...)
+}
+
+// Middleware returns a function wrapping handlers by NewHandler.
+func Middleware(opts ...otelhttp.Option) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return NewHandler(h, opts...)
+	}
+}
+
+// SpanName returns the method and path of r, without query.
+func SpanName(_ string, r *http.Request) string {
+	return r.Method + " " + r.URL.Path
+}
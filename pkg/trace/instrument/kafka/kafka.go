@@ -0,0 +1,88 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tracekafka propagates trace context through headers of kafka messages.
+package tracekafka
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Header is the message header of kafka clients, e.g. confluent-kafka-go and kafka-go.
+type Header interface {
+	~struct {
+		Key   string
+		Value []byte
+	}
+}
+
+type header = struct {
+	Key   string
+	Value []byte
+}
+
+// HeaderCarrier adapts message headers to propagation.TextMapCarrier.
+type HeaderCarrier[H Header] struct {
+	Headers *[]H
+}
+
+// Get .
+func (c HeaderCarrier[H]) Get(key string) string {
+	for _, h := range *c.Headers {
+		if h := header(h); h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+// Set .
+func (c HeaderCarrier[H]) Set(key, value string) {
+	for i, h := range *c.Headers {
+		if header(h).Key == key {
+			(*c.Headers)[i] = H(header{Key: key, Value: []byte(value)})
+			return
+		}
+	}
+	*c.Headers = append(*c.Headers, H(header{Key: key, Value: []byte(value)}))
+}
+
+// Keys .
+func (c HeaderCarrier[H]) Keys() []string {
+	keys := make([]string, 0, len(*c.Headers))
+	for _, h := range *c.Headers {
+		keys = append(keys, header(h).Key)
+	}
+	return keys
+}
+
+// StartPublish starts a producer span of publishing to topic by tracer as child of the span in ctx,
+// and injects its context into carrier, which is typically a HeaderCarrier of the message.
+func StartPublish(ctx context.Context, tracer trace.Tracer, topic string, carrier propagation.TextMapCarrier) trace.Span {
+	ctx, span := tracer.Start(ctx, topic+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingOperationPublish,
+			semconv.MessagingDestinationName(topic),
+		),
+	)
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	return span
+}
@@ -0,0 +1,37 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracekafka
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testHeader struct {
+	Key   string
+	Value []byte
+}
+
+func TestHeaderCarrier(t *testing.T) {
+	headers := []testHeader{{Key: "a", Value: []byte("1")}}
+	c := HeaderCarrier[testHeader]{Headers: &headers}
+	c.Set("b", "2")
+	c.Set("a", "3")
+	assert.Equal(t, "3", c.Get("a"))
+	assert.Equal(t, "2", c.Get("b"))
+	assert.Equal(t, "", c.Get("c"))
+	assert.Equal(t, []string{"a", "b"}, c.Keys())
+}
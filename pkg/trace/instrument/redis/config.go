@@ -12,7 +12,7 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-package redis
+package traceredis
 
 import (
 	"context"
@@ -27,7 +27,7 @@ import (
 )
 
 const (
-	instrumentationName = "github.com/erda-project/erda-infra/pkg/trace/inject/redis"
+	instrumentationName = "github.com/erda-project/erda-infra/pkg/trace/instrument/redis"
 )
 
 // SpanNameFormatter is an interface that used to format span names.
@@ -12,7 +12,7 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-package redis
+package traceredis
 
 // Version is the current release version of otel redis in use.
 func Version() string {
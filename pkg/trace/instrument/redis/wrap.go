@@ -0,0 +1,36 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package traceredis wraps go-redis clients with tracing, without hooking the constructors.
+package traceredis
+
+import (
+	"github.com/go-redis/redis"
+)
+
+// Client .
+type Client interface {
+	WrapProcess(fn func(oldProcess func(redis.Cmder) error) func(redis.Cmder) error)
+	WrapProcessPipeline(fn func(oldProcess func([]redis.Cmder) error) func([]redis.Cmder) error)
+}
+
+// Wrap wraps the processes of client to record spans, the parent span is taken from the goroutine context.
+func Wrap(client Client, opts ...Option) {
+	if client == nil {
+		return
+	}
+	cfg := newConfig("redis", opts...)
+	client.WrapProcess(newProcessWrapper(cfg))
+	client.WrapProcessPipeline(newProcessPipeline(cfg))
+}
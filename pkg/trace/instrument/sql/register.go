@@ -0,0 +1,87 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tracesql wraps database/sql drivers with tracing, without hooking sql.Open.
+package tracesql
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"sync"
+
+	"github.com/XSAM/otelsql"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+// DriverPrefix is the prefix of names of registered traced drivers.
+const DriverPrefix = "otelsql-"
+
+var (
+	driversMu sync.Mutex
+	drivers   = map[string]string{}
+)
+
+// WrapDriver wraps the driver d to record spans, the span in the goroutine context is used as parent if ctx has none.
+func WrapDriver(d driver.Driver, dbSystem string) driver.Driver {
+	return wrapDriver(otelsql.WrapDriver(d, otelsql.WithAttributes(
+		semconv.DBSystemKey.String(dbSystem),
+	)))
+}
+
+// RegisterDriver registers the traced driver of d once, and returns the name to pass to sql.Open.
+func RegisterDriver(driverName string, d driver.Driver) string {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+	if name, ok := drivers[driverName]; ok {
+		return name
+	}
+	name := DriverPrefix + driverName
+	sql.Register(name, WrapDriver(d, driverName))
+	drivers[driverName] = name
+	return name
+}
+
+// RegisteredDriver returns the name of the traced driver of driverName, false if it isn't registered.
+func RegisteredDriver(driverName string) (string, bool) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+	name, ok := drivers[driverName]
+	return name, ok
+}
+
+// Register registers the traced driver of the registered driver named driverName, and returns the name to pass to sql.Open.
+func Register(driverName string) (string, error) {
+	if name, ok := RegisteredDriver(driverName); ok {
+		return name, nil
+	}
+	// retrieve the driver implementation we need to wrap with instrumentation
+	db, err := sql.Open(driverName, "")
+	if err != nil {
+		return "", err
+	}
+	d := db.Driver()
+	if err := db.Close(); err != nil {
+		return "", err
+	}
+	return RegisterDriver(driverName, d), nil
+}
+
+// Open opens a database by the traced driver of driverName.
+func Open(driverName, dataSourceName string) (*sql.DB, error) {
+	name, err := Register(driverName)
+	if err != nil {
+		return nil, err
+	}
+	return sql.Open(name, dataSourceName)
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracesql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type testDriver struct{}
+
+func (testDriver) Open(name string) (driver.Conn, error) { return testConn{}, nil }
+
+type testConn struct{}
+
+func (testConn) Prepare(query string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (testConn) Close() error                              { return nil }
+func (testConn) Begin() (driver.Tx, error)                 { return nil, driver.ErrSkip }
+func (testConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return testRows{}, nil
+}
+
+type testRows struct{}
+
+func (testRows) Columns() []string              { return []string{"n"} }
+func (testRows) Close() error                   { return nil }
+func (testRows) Next(dest []driver.Value) error { return io.EOF }
+
+func init() {
+	sql.Register("tracesql-test", testDriver{})
+}
+
+func TestRegister(t *testing.T) {
+	name, err := Register("tracesql-test")
+	assert.NoError(t, err)
+	assert.Equal(t, DriverPrefix+"tracesql-test", name)
+
+	registered, ok := RegisteredDriver("tracesql-test")
+	assert.True(t, ok)
+	assert.Equal(t, name, registered)
+	_, ok = RegisteredDriver("tracesql-not-exist")
+	assert.False(t, ok)
+
+	again, err := Register("tracesql-test")
+	assert.NoError(t, err)
+	assert.Equal(t, name, again)
+
+	_, err = Register("tracesql-not-exist")
+	assert.Error(t, err)
+}
+
+func TestOpen(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	global := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	defer otel.SetTracerProvider(global)
+
+	db, err := Open("tracesql-test", "")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer db.Close()
+
+	ctx, span := tp.Tracer("test").Start(context.Background(), "parent")
+	rows, err := db.QueryContext(ctx, "SELECT 1")
+	if assert.NoError(t, err) {
+		rows.Close()
+	}
+	span.End()
+
+	var children int
+	for _, s := range sr.Ended() {
+		if s.Parent().SpanID() == span.SpanContext().SpanID() {
+			children++
+		}
+	}
+	assert.NotZero(t, children)
+}
@@ -0,0 +1,239 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// Error is the error model shared by HTTP and gRPC transports.
+type Error struct {
+	Code     codes.Code
+	Message  string
+	Details  []*anypb.Any
+	Metadata map[string]string
+
+	// httpStatus overrides the status mapped from Code, it is kept for errors converted from HTTPStatus() implementations.
+	httpStatus int
+}
+
+// New create Error with code and message.
+func New(code codes.Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// Newf create Error with code and formatted message.
+func Newf(code codes.Code, format string, args ...interface{}) *Error {
+	return New(code, fmt.Sprintf(format, args...))
+}
+
+// Error .
+func (e *Error) Error() string { return e.Message }
+
+// HTTPStatus returns the http status of this error.
+func (e *Error) HTTPStatus() int {
+	if e.httpStatus > 0 {
+		return e.httpStatus
+	}
+	return HTTPStatusFromCode(e.Code)
+}
+
+// WithHTTPStatus returns a copy of the error with the http status overridden.
+func (e *Error) WithHTTPStatus(status int) *Error {
+	err := e.clone()
+	err.httpStatus = status
+	return err
+}
+
+// WithMetadata returns a copy of the error with md merged into its metadata.
+func (e *Error) WithMetadata(md map[string]string) *Error {
+	err := e.clone()
+	if len(md) > 0 {
+		if err.Metadata == nil {
+			err.Metadata = make(map[string]string, len(md))
+		}
+		for k, v := range md {
+			err.Metadata[k] = v
+		}
+	}
+	return err
+}
+
+// WithDetails returns a copy of the error with details appended.
+func (e *Error) WithDetails(details ...proto.Message) (*Error, error) {
+	err := e.clone()
+	for _, detail := range details {
+		msg, perr := anypb.New(detail)
+		if perr != nil {
+			return nil, perr
+		}
+		err.Details = append(err.Details, msg)
+	}
+	return err, nil
+}
+
+// GRPCStatus returns the gRPC status of this error, metadata is carried by an errdetails.ErrorInfo.
+func (e *Error) GRPCStatus() *status.Status {
+	s := &spb.Status{
+		Code:    int32(e.Code),
+		Message: e.Message,
+		Details: e.Details,
+	}
+	if len(e.Metadata) > 0 {
+		if info, err := anypb.New(&errdetails.ErrorInfo{Metadata: e.Metadata}); err == nil {
+			s.Details = append(append([]*anypb.Any(nil), e.Details...), info)
+		}
+	}
+	return status.FromProto(s)
+}
+
+// Is matches errors with the same code and message.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+	return t.Code == e.Code && t.Message == e.Message
+}
+
+func (e *Error) clone() *Error {
+	err := *e
+	if e.Details != nil {
+		err.Details = append([]*anypb.Any(nil), e.Details...)
+	}
+	if e.Metadata != nil {
+		err.Metadata = make(map[string]string, len(e.Metadata))
+		for k, v := range e.Metadata {
+			err.Metadata[k] = v
+		}
+	}
+	return &err
+}
+
+// FromStatus convert gRPC status to Error.
+func FromStatus(s *status.Status) *Error {
+	if s == nil {
+		return nil
+	}
+	p := s.Proto()
+	err := &Error{
+		Code:    codes.Code(p.GetCode()),
+		Message: p.GetMessage(),
+	}
+	for _, detail := range p.GetDetails() {
+		if err.Metadata == nil && detail.MessageIs((*errdetails.ErrorInfo)(nil)) {
+			info := &errdetails.ErrorInfo{}
+			if detail.UnmarshalTo(info) == nil && len(info.Reason) <= 0 && len(info.Domain) <= 0 {
+				err.Metadata = info.Metadata
+				continue
+			}
+		}
+		err.Details = append(err.Details, detail)
+	}
+	return err
+}
+
+// FromError convert any error to Error, returns nil if err is nil.
+func FromError(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if stderrors.As(err, &e) {
+		return e
+	}
+	var gs interface{ GRPCStatus() *status.Status }
+	if stderrors.As(err, &gs) {
+		return FromStatus(gs.GRPCStatus())
+	}
+	var hs interface{ HTTPStatus() int }
+	if stderrors.As(err, &hs) {
+		status := hs.HTTPStatus()
+		return &Error{
+			Code:       CodeFromHTTPStatus(status),
+			Message:    err.Error(),
+			httpStatus: status,
+		}
+	}
+	switch {
+	case stderrors.Is(err, context.DeadlineExceeded):
+		return New(codes.DeadlineExceeded, err.Error())
+	case stderrors.Is(err, context.Canceled):
+		return New(codes.Canceled, err.Error())
+	}
+	return New(codes.Unknown, err.Error())
+}
+
+// Code returns the code of err, returns codes.OK if err is nil.
+func Code(err error) codes.Code {
+	if err == nil {
+		return codes.OK
+	}
+	return FromError(err).Code
+}
+
+// InvalidArgument .
+func InvalidArgument(format string, args ...interface{}) *Error {
+	return Newf(codes.InvalidArgument, format, args...)
+}
+
+// NotFound .
+func NotFound(format string, args ...interface{}) *Error {
+	return Newf(codes.NotFound, format, args...)
+}
+
+// AlreadyExists .
+func AlreadyExists(format string, args ...interface{}) *Error {
+	return Newf(codes.AlreadyExists, format, args...)
+}
+
+// PermissionDenied .
+func PermissionDenied(format string, args ...interface{}) *Error {
+	return Newf(codes.PermissionDenied, format, args...)
+}
+
+// Unauthenticated .
+func Unauthenticated(format string, args ...interface{}) *Error {
+	return Newf(codes.Unauthenticated, format, args...)
+}
+
+// ResourceExhausted .
+func ResourceExhausted(format string, args ...interface{}) *Error {
+	return Newf(codes.ResourceExhausted, format, args...)
+}
+
+// FailedPrecondition .
+func FailedPrecondition(format string, args ...interface{}) *Error {
+	return Newf(codes.FailedPrecondition, format, args...)
+}
+
+// Unavailable .
+func Unavailable(format string, args ...interface{}) *Error {
+	return Newf(codes.Unavailable, format, args...)
+}
+
+// Internal .
+func Internal(format string, args ...interface{}) *Error {
+	return Newf(codes.Internal, format, args...)
+}
@@ -0,0 +1,159 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type httpStatusError int
+
+func (e httpStatusError) HTTPStatus() int { return int(e) }
+func (e httpStatusError) Error() string   { return http.StatusText(int(e)) }
+
+func TestFromError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantCode   codes.Code
+		wantStatus int
+	}{
+		{
+			name:       "Error",
+			err:        NotFound("user %d not found", 1),
+			wantCode:   codes.NotFound,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "wrapped Error",
+			err:        fmt.Errorf("wrap: %w", PermissionDenied("denied")),
+			wantCode:   codes.PermissionDenied,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "grpc status",
+			err:        status.Error(codes.Unavailable, "unavailable"),
+			wantCode:   codes.Unavailable,
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "HTTPStatus",
+			err:        httpStatusError(http.StatusNotAcceptable),
+			wantCode:   codes.FailedPrecondition,
+			wantStatus: http.StatusNotAcceptable,
+		},
+		{
+			name:       "deadline",
+			err:        context.DeadlineExceeded,
+			wantCode:   codes.DeadlineExceeded,
+			wantStatus: http.StatusGatewayTimeout,
+		},
+		{
+			name:       "unknown",
+			err:        fmt.Errorf("unknown"),
+			wantCode:   codes.Unknown,
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := FromError(tt.err)
+			if e.Code != tt.wantCode {
+				t.Errorf("FromError().Code = %v, want %v", e.Code, tt.wantCode)
+			}
+			if e.HTTPStatus() != tt.wantStatus {
+				t.Errorf("FromError().HTTPStatus() = %v, want %v", e.HTTPStatus(), tt.wantStatus)
+			}
+		})
+	}
+	if FromError(nil) != nil {
+		t.Errorf("FromError(nil) should be nil")
+	}
+}
+
+func TestGRPCStatus(t *testing.T) {
+	err, perr := InvalidArgument("invalid name").
+		WithMetadata(map[string]string{"field": "name"}).
+		WithDetails(&errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: "name", Description: "required"}},
+		})
+	if perr != nil {
+		t.Fatal(perr)
+	}
+	got := FromError(status.ErrorProto(err.GRPCStatus().Proto()))
+	if got.Code != codes.InvalidArgument || got.Message != "invalid name" {
+		t.Errorf("FromError() = %v %q, want %v %q", got.Code, got.Message, codes.InvalidArgument, "invalid name")
+	}
+	if !reflect.DeepEqual(got.Metadata, err.Metadata) {
+		t.Errorf("FromError().Metadata = %v, want %v", got.Metadata, err.Metadata)
+	}
+	if len(got.Details) != 1 || !got.Details[0].MessageIs((*errdetails.BadRequest)(nil)) {
+		t.Errorf("FromError().Details = %v, want one BadRequest", got.Details)
+	}
+}
+
+func TestWriteHTTP(t *testing.T) {
+	err, perr := NotFound("not found").
+		WithMetadata(map[string]string{"id": "1"}).
+		WithDetails(&errdetails.ResourceInfo{ResourceType: "user", ResourceName: "1"})
+	if perr != nil {
+		t.Fatal(perr)
+	}
+	rec := httptest.NewRecorder()
+	WriteHTTP(rec, err)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("WriteHTTP() status = %v, want %v", rec.Code, http.StatusNotFound)
+	}
+
+	var got Body
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Code != http.StatusNotFound || got.Err != "not found" || got.Status != "NOT_FOUND" {
+		t.Errorf("WriteHTTP() body = %d %q %q", got.Code, got.Err, got.Status)
+	}
+	if !reflect.DeepEqual(got.Metadata, err.Metadata) {
+		t.Errorf("WriteHTTP() body metadata = %v, want %v", got.Metadata, err.Metadata)
+	}
+	if len(got.Details) != 1 {
+		t.Errorf("WriteHTTP() body details = %s, want one ResourceInfo", got.Details)
+	}
+}
+
+func TestCodeMapping(t *testing.T) {
+	for code := codes.OK; code <= codes.Unauthenticated; code++ {
+		name := CodeName(code)
+		got, ok := CodeFromName(name)
+		if !ok || got != code {
+			t.Errorf("CodeFromName(%q) = %v, want %v", name, got, code)
+		}
+	}
+	for _, code := range []codes.Code{codes.OK, codes.InvalidArgument, codes.NotFound, codes.PermissionDenied,
+		codes.Unauthenticated, codes.ResourceExhausted, codes.Unimplemented, codes.Unavailable, codes.DeadlineExceeded, codes.Canceled} {
+		if got := CodeFromHTTPStatus(HTTPStatusFromCode(code)); got != code {
+			t.Errorf("CodeFromHTTPStatus(HTTPStatusFromCode(%v)) = %v", code, got)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// Body is the JSON body of error responses.
+type Body struct {
+	Code     int               `json:"code"`
+	Err      string            `json:"err"`
+	Status   string            `json:"status,omitempty"`
+	Details  []json.RawMessage `json:"details,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Body returns the JSON body of this error, details can't be marshaled are ignored.
+func (e *Error) Body() *Body {
+	body := &Body{
+		Code:     e.HTTPStatus(),
+		Err:      e.Message,
+		Status:   CodeName(e.Code),
+		Metadata: e.Metadata,
+	}
+	for _, detail := range e.Details {
+		byts, err := protojson.Marshal(detail)
+		if err != nil {
+			continue
+		}
+		body.Details = append(body.Details, byts)
+	}
+	return body
+}
+
+// WriteHTTP writes err to w as a JSON body.
+func WriteHTTP(w http.ResponseWriter, err error) {
+	e := FromError(err)
+	if e == nil {
+		return
+	}
+	body := e.Body()
+	byts, _ := json.Marshal(body)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(body.Code)
+	w.Write(byts)
+}
@@ -0,0 +1,240 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fieldmask
+
+import (
+	"net/http"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+
+	transerrors "github.com/erda-project/erda-infra/pkg/transport/errors"
+)
+
+// QueryParam is the query parameter to select response fields, e.g. ?fields=items.id,items.name
+const QueryParam = "fields"
+
+// Parse splits comma separated paths.
+func Parse(vals ...string) []string {
+	var paths []string
+	for _, val := range vals {
+		for _, path := range strings.Split(val, ",") {
+			path = strings.TrimSpace(path)
+			if len(path) > 0 {
+				paths = append(paths, path)
+			}
+		}
+	}
+	return paths
+}
+
+// New create FieldMask with comma separated paths.
+func New(vals ...string) *fieldmaskpb.FieldMask {
+	return &fieldmaskpb.FieldMask{Paths: Parse(vals...)}
+}
+
+// FromRequest returns paths specified by QueryParam of request.
+func FromRequest(r *http.Request) []string {
+	return Parse(r.URL.Query()[QueryParam]...)
+}
+
+// Normalize checks paths against md, and converts json names in paths to proto names.
+func Normalize(md protoreflect.MessageDescriptor, paths []string) ([]string, error) {
+	list := make([]string, 0, len(paths))
+	for _, path := range paths {
+		names := strings.Split(path, ".")
+		desc := md
+		for i, name := range names {
+			if desc == nil {
+				return nil, transerrors.InvalidArgument("invalid field mask path %q", path)
+			}
+			fd := findField(desc, name)
+			if fd == nil {
+				return nil, transerrors.InvalidArgument("invalid field mask path %q", path)
+			}
+			names[i] = string(fd.Name())
+			desc = fieldMessage(fd)
+		}
+		list = append(list, strings.Join(names, "."))
+	}
+	return list, nil
+}
+
+func findField(md protoreflect.MessageDescriptor, name string) protoreflect.FieldDescriptor {
+	fields := md.Fields()
+	if fd := fields.ByName(protoreflect.Name(name)); fd != nil {
+		return fd
+	}
+	return fields.ByJSONName(name)
+}
+
+// fieldMessage returns the message type of fd, the message type of values is returned for map.
+func fieldMessage(fd protoreflect.FieldDescriptor) protoreflect.MessageDescriptor {
+	if fd.IsMap() {
+		return fd.MapValue().Message()
+	}
+	return fd.Message()
+}
+
+type tree map[protoreflect.Name]tree
+
+// newTree builds a tree of field names from paths, a nil node means the field is selected entirely.
+func newTree(paths []string) tree {
+	root := make(tree)
+	for _, path := range paths {
+		node := root
+		names := strings.Split(path, ".")
+		for i, name := range names {
+			key := protoreflect.Name(name)
+			child, ok := node[key]
+			if ok && child == nil {
+				break
+			}
+			if i == len(names)-1 {
+				node[key] = nil
+				break
+			}
+			if !ok {
+				child = make(tree)
+				node[key] = child
+			}
+			node = child
+		}
+	}
+	return root
+}
+
+// Prune clears the fields of msg not covered by paths,
+// repeated and map fields of message type are pruned element-wise.
+func Prune(msg proto.Message, paths []string) error {
+	if msg == nil || len(paths) <= 0 {
+		return nil
+	}
+	m := msg.ProtoReflect()
+	paths, err := Normalize(m.Descriptor(), paths)
+	if err != nil {
+		return err
+	}
+	prune(m, newTree(paths))
+	return nil
+}
+
+func prune(m protoreflect.Message, t tree) {
+	var clears []protoreflect.FieldDescriptor
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		child, ok := t[fd.Name()]
+		if !ok {
+			clears = append(clears, fd)
+			return true
+		}
+		if child == nil {
+			return true
+		}
+		switch {
+		case fd.IsList():
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				prune(list.Get(i).Message(), child)
+			}
+		case fd.IsMap():
+			v.Map().Range(func(_ protoreflect.MapKey, val protoreflect.Value) bool {
+				prune(val.Message(), child)
+				return true
+			})
+		default:
+			prune(v.Message(), child)
+		}
+		return true
+	})
+	for _, fd := range clears {
+		m.Clear(fd)
+	}
+}
+
+// Filter returns a pruned copy of out if it is a proto.Message, otherwise out is returned.
+func Filter(out interface{}, paths []string) (interface{}, error) {
+	msg, ok := out.(proto.Message)
+	if !ok || msg == nil || len(paths) <= 0 {
+		return out, nil
+	}
+	msg = proto.Clone(msg)
+	if err := Prune(msg, paths); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// Merge copies the fields covered by paths from src to dst, fields not set in src are cleared in dst.
+func Merge(dst, src proto.Message, paths []string) error {
+	dm, sm := dst.ProtoReflect(), src.ProtoReflect()
+	if dm.Descriptor().FullName() != sm.Descriptor().FullName() {
+		return transerrors.InvalidArgument("mismatch message type %s and %s", dm.Descriptor().FullName(), sm.Descriptor().FullName())
+	}
+	paths, err := Normalize(dm.Descriptor(), paths)
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		d, s := dm, sm
+		names := strings.Split(path, ".")
+		for i, name := range names {
+			fd := d.Descriptor().Fields().ByName(protoreflect.Name(name))
+			if i == len(names)-1 {
+				mergeField(d, s, fd)
+				break
+			}
+			if fd.IsList() || fd.IsMap() {
+				return transerrors.InvalidArgument("invalid update mask path %q, repeated field %q must be the last", path, name)
+			}
+			if !s.Has(fd) {
+				d.Clear(fd)
+				break
+			}
+			d, s = d.Mutable(fd).Message(), s.Get(fd).Message()
+		}
+	}
+	return nil
+}
+
+func mergeField(dst, src protoreflect.Message, fd protoreflect.FieldDescriptor) {
+	dst.Clear(fd)
+	if !src.Has(fd) {
+		return
+	}
+	switch {
+	case fd.IsList():
+		dl, sl := dst.Mutable(fd).List(), src.Get(fd).List()
+		for i := 0; i < sl.Len(); i++ {
+			dl.Append(cloneValue(sl.Get(i), fd.Message() != nil))
+		}
+	case fd.IsMap():
+		dm, sm := dst.Mutable(fd).Map(), src.Get(fd).Map()
+		sm.Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+			dm.Set(k, cloneValue(v, fd.MapValue().Message() != nil))
+			return true
+		})
+	default:
+		dst.Set(fd, cloneValue(src.Get(fd), fd.Message() != nil))
+	}
+}
+
+func cloneValue(v protoreflect.Value, isMessage bool) protoreflect.Value {
+	if isMessage {
+		return protoreflect.ValueOfMessage(proto.Clone(v.Message().Interface()).ProtoReflect())
+	}
+	return v
+}
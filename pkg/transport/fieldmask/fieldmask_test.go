@@ -0,0 +1,158 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fieldmask
+
+import (
+	"reflect"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func testFile() *descriptorpb.FileDescriptorProto {
+	return &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("test.proto"),
+		Package: proto.String("test"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name:  proto.String("A"),
+				Field: []*descriptorpb.FieldDescriptorProto{{Name: proto.String("a1"), Number: proto.Int32(1)}},
+			},
+			{
+				Name:  proto.String("B"),
+				Field: []*descriptorpb.FieldDescriptorProto{{Name: proto.String("b1"), Number: proto.Int32(1)}},
+			},
+		},
+		Options: &descriptorpb.FileOptions{
+			GoPackage:   proto.String("test/pb"),
+			JavaPackage: proto.String("test"),
+		},
+	}
+}
+
+func TestPrune(t *testing.T) {
+	tests := []struct {
+		name    string
+		paths   []string
+		want    *descriptorpb.FileDescriptorProto
+		wantErr bool
+	}{
+		{
+			name:  "top level",
+			paths: []string{"name", "package"},
+			want: &descriptorpb.FileDescriptorProto{
+				Name:    proto.String("test.proto"),
+				Package: proto.String("test"),
+			},
+		},
+		{
+			name:  "repeated",
+			paths: Parse("name, message_type.name"),
+			want: &descriptorpb.FileDescriptorProto{
+				Name: proto.String("test.proto"),
+				MessageType: []*descriptorpb.DescriptorProto{
+					{Name: proto.String("A")},
+					{Name: proto.String("B")},
+				},
+			},
+		},
+		{
+			name:  "json name",
+			paths: Parse("options.goPackage"),
+			want: &descriptorpb.FileDescriptorProto{
+				Options: &descriptorpb.FileOptions{GoPackage: proto.String("test/pb")},
+			},
+		},
+		{
+			name:  "parent selected",
+			paths: Parse("options.go_package,options"),
+			want: &descriptorpb.FileDescriptorProto{
+				Options: testFile().Options,
+			},
+		},
+		{
+			name:    "invalid",
+			paths:   Parse("name.x"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := testFile()
+			err := Prune(msg, tt.paths)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Prune() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !proto.Equal(msg, tt.want) {
+				t.Errorf("Prune() = %v, want %v", msg, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilter(t *testing.T) {
+	msg := testFile()
+	out, err := Filter(msg, []string{"name"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(out.(proto.Message), &descriptorpb.FileDescriptorProto{Name: proto.String("test.proto")}) {
+		t.Errorf("Filter() = %v", out)
+	}
+	if !proto.Equal(msg, testFile()) {
+		t.Errorf("Filter() should not modify the input")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	dst := testFile()
+	src := &descriptorpb.FileDescriptorProto{
+		Name: proto.String("new.proto"),
+		Options: &descriptorpb.FileOptions{
+			GoPackage: proto.String("new/pb"),
+		},
+	}
+	err := Merge(dst, src, []string{"name", "options.go_package", "package"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := testFile()
+	want.Name = proto.String("new.proto")
+	want.Package = nil
+	want.Options.GoPackage = proto.String("new/pb")
+	if !proto.Equal(dst, want) {
+		t.Errorf("Merge() = %v, want %v", dst, want)
+	}
+}
+
+func TestFromJSON(t *testing.T) {
+	mask, err := FromJSON([]byte(`{"name":"x","messageType":[],"options":{"go_package":"x","javaPackage":"x"},"unknown":1}`), (*descriptorpb.FileDescriptorProto)(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"message_type", "name", "options.go_package", "options.java_package"}
+	if !reflect.DeepEqual(mask.Paths, want) {
+		t.Errorf("FromJSON() = %v, want %v", mask.Paths, want)
+	}
+
+	mask, err = FromJSON([]byte(`{"name":"x","package":"y"}`), (*descriptorpb.FileDescriptorProto)(nil), "package")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(mask.Paths, []string{"name"}) {
+		t.Errorf("FromJSON() = %v, want %v", mask.Paths, []string{"name"})
+	}
+}
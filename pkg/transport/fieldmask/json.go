@@ -0,0 +1,110 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fieldmask
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// FromJSON returns a FieldMask of the fields present in JSON object data,
+// names are resolved by the message type of msg, msg can be a typed nil pointer.
+// Unknown fields in data are ignored, and the fields named in excludes are skipped at top level.
+func FromJSON(data []byte, msg proto.Message, excludes ...string) (*fieldmaskpb.FieldMask, error) {
+	mask := &fieldmaskpb.FieldMask{}
+	data = bytes.TrimSpace(data)
+	if len(data) <= 0 || msg == nil {
+		return mask, nil
+	}
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, err
+	}
+	md := msg.ProtoReflect().Descriptor()
+	for _, name := range excludes {
+		if fd := findField(md, name); fd != nil {
+			delete(obj, string(fd.Name()))
+			delete(obj, fd.JSONName())
+		}
+	}
+	appendJSONPaths(mask, "", obj, md)
+	sort.Strings(mask.Paths)
+	return mask, nil
+}
+
+func appendJSONPaths(mask *fieldmaskpb.FieldMask, prefix string, obj map[string]json.RawMessage, md protoreflect.MessageDescriptor) {
+	for key, raw := range obj {
+		fd := findField(md, key)
+		if fd == nil {
+			continue
+		}
+		path := prefix + string(fd.Name())
+		sub := fd.Message()
+		if sub == nil || fd.IsList() || fd.IsMap() || isWellKnownType(sub) {
+			mask.Paths = append(mask.Paths, path)
+			continue
+		}
+		var child map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &child); err != nil || len(child) <= 0 {
+			// null, empty or not an object, the field is replaced entirely
+			mask.Paths = append(mask.Paths, path)
+			continue
+		}
+		appendJSONPaths(mask, path+".", child, sub)
+	}
+}
+
+// isWellKnownType returns true if messages of md are represented as JSON values other than objects of fields.
+func isWellKnownType(md protoreflect.MessageDescriptor) bool {
+	if md.FullName().Parent() != "google.protobuf" {
+		return false
+	}
+	switch md.Name() {
+	case "Any", "Duration", "Timestamp", "FieldMask", "Empty",
+		"Struct", "Value", "ListValue",
+		"BoolValue", "BytesValue", "StringValue",
+		"Int32Value", "UInt32Value", "FloatValue",
+		"Int64Value", "UInt64Value", "DoubleValue":
+		return true
+	}
+	return false
+}
+
+// ReadJSONBody reads the body of JSON request and resets it for subsequent decoding,
+// returns nil if the request is not JSON.
+func ReadJSONBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil || r.ContentLength == 0 {
+		return nil, nil
+	}
+	mtype, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || !(mtype == "application/json" || (strings.HasPrefix(mtype, "application/vnd.") && strings.HasSuffix(mtype, "+json"))) {
+		return nil, nil
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"context"
+	"net/url"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/resolver"
+)
+
+type testClientConn struct {
+	resolver.ClientConn
+	lock   sync.Mutex
+	states []resolver.State
+}
+
+func (cc *testClientConn) UpdateState(s resolver.State) error {
+	cc.lock.Lock()
+	defer cc.lock.Unlock()
+	cc.states = append(cc.states, s)
+	return nil
+}
+
+func (cc *testClientConn) ReportError(error) {}
+
+func (cc *testClientConn) addrs() [][]string {
+	cc.lock.Lock()
+	defer cc.lock.Unlock()
+	var list [][]string
+	for _, s := range cc.states {
+		var addrs []string
+		for _, addr := range s.Addresses {
+			addrs = append(addrs, addr.Addr)
+		}
+		list = append(list, addrs)
+	}
+	return list
+}
+
+func newTarget(t *testing.T, target string) resolver.Target {
+	u, err := url.Parse(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resolver.Target{URL: *u}
+}
+
+func TestStaticResolver(t *testing.T) {
+	cc := &testClientConn{}
+	r, err := NewStaticBuilder().Build(newTarget(t, "static:///a:1, b:2"), cc, resolver.BuildOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if got, want := cc.addrs(), [][]string{{"a:1", "b:2"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("addrs = %v, want %v", got, want)
+	}
+}
+
+func TestDNSResolver(t *testing.T) {
+	var lock sync.Mutex
+	hosts := []string{"10.0.0.2", "10.0.0.1"}
+	b := &dnsBuilder{
+		interval: time.Hour,
+		lookup: func(ctx context.Context, host string) ([]string, error) {
+			lock.Lock()
+			defer lock.Unlock()
+			return append([]string(nil), hosts...), nil
+		},
+	}
+	cc := &testClientConn{}
+	r, err := b.Build(newTarget(t, "dns:///example.com:7070"), cc, resolver.BuildOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	wait := func(n int) {
+		for i := 0; i < 100 && len(cc.addrs()) < n; i++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	wait(1)
+	r.ResolveNow(resolver.ResolveNowOptions{}) // unchanged, no update
+	lock.Lock()
+	hosts = []string{"10.0.0.3"}
+	lock.Unlock()
+	r.ResolveNow(resolver.ResolveNowOptions{})
+	wait(2)
+	want := [][]string{{"10.0.0.1:7070", "10.0.0.2:7070"}, {"10.0.0.3:7070"}}
+	if got := cc.addrs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("addrs = %v, want %v", got, want)
+	}
+}
+
+type testSubConn struct {
+	balancer.SubConn
+	name string
+}
+
+func TestWeightedPicker(t *testing.T) {
+	a, b := &testSubConn{name: "a"}, &testSubConn{name: "b"}
+	picker := (&weightedPickerBuilder{}).Build(base.PickerBuildInfo{
+		ReadySCs: map[balancer.SubConn]base.SubConnInfo{
+			a: {Address: WithWeight(resolver.Address{Addr: "a"}, 3)},
+			b: {Address: resolver.Address{Addr: "b"}},
+		},
+	})
+	counts := make(map[string]int)
+	for i := 0; i < 8; i++ {
+		res, err := picker.Pick(balancer.PickInfo{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts[res.SubConn.(*testSubConn).name]++
+	}
+	if want := map[string]int{"a": 6, "b": 2}; !reflect.DeepEqual(counts, want) {
+		t.Errorf("counts = %v, want %v", counts, want)
+	}
+}
+
+func TestAdvertiseAddr(t *testing.T) {
+	if got, err := AdvertiseAddr("10.0.0.1:7070"); err != nil || got != "10.0.0.1:7070" {
+		t.Errorf("AdvertiseAddr() = %q, %v", got, err)
+	}
+	if _, err := AdvertiseAddr("7070"); err == nil {
+		t.Errorf("AdvertiseAddr() should fail without port")
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"context"
+	"net"
+	"sort"
+	"time"
+
+	"google.golang.org/grpc/resolver"
+)
+
+// DNSScheme is the scheme of dns resolver, e.g. dns:///example.com:7070
+const DNSScheme = "dns"
+
+const defaultDNSPort = "443"
+
+// NewDNSBuilder returns a dns resolver builder which refreshes addresses periodically,
+// unlike the builtin dns resolver of grpc which only re-resolves on connection errors.
+func NewDNSBuilder(interval time.Duration) resolver.Builder {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	return &dnsBuilder{interval: interval, lookup: net.DefaultResolver.LookupHost}
+}
+
+type dnsBuilder struct {
+	interval time.Duration
+	lookup   func(ctx context.Context, host string) ([]string, error)
+}
+
+func (b *dnsBuilder) Scheme() string { return DNSScheme }
+
+func (b *dnsBuilder) Build(target resolver.Target, cc resolver.ClientConn, opts resolver.BuildOptions) (resolver.Resolver, error) {
+	host, port, err := net.SplitHostPort(target.Endpoint())
+	if err != nil {
+		host, port = target.Endpoint(), defaultDNSPort
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &dnsResolver{
+		host:     host,
+		port:     port,
+		cc:       cc,
+		lookup:   b.lookup,
+		interval: b.interval,
+		ctx:      ctx,
+		cancel:   cancel,
+		now:      make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+	go r.watch()
+	return r, nil
+}
+
+type dnsResolver struct {
+	host, port string
+	cc         resolver.ClientConn
+	lookup     func(ctx context.Context, host string) ([]string, error)
+	interval   time.Duration
+	ctx        context.Context
+	cancel     context.CancelFunc
+	now        chan struct{}
+	done       chan struct{}
+}
+
+func (r *dnsResolver) watch() {
+	defer close(r.done)
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	var last []string
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-timer.C:
+		case <-r.now:
+			if !timer.Stop() {
+				<-timer.C
+			}
+		}
+		if ip := net.ParseIP(r.host); ip != nil {
+			last = r.update(last, []string{r.host})
+		} else if hosts, err := r.lookup(r.ctx, r.host); err != nil {
+			r.cc.ReportError(err)
+		} else {
+			last = r.update(last, hosts)
+		}
+		timer.Reset(r.interval)
+	}
+}
+
+func (r *dnsResolver) update(last, hosts []string) []string {
+	sort.Strings(hosts)
+	if equalStrings(last, hosts) {
+		return last
+	}
+	addrs := make([]resolver.Address, 0, len(hosts))
+	for _, host := range hosts {
+		addrs = append(addrs, resolver.Address{Addr: net.JoinHostPort(host, r.port)})
+	}
+	r.cc.UpdateState(resolver.State{Addresses: addrs})
+	return hosts
+}
+
+func (r *dnsResolver) ResolveNow(resolver.ResolveNowOptions) {
+	select {
+	case r.now <- struct{}{}:
+	default:
+	}
+}
+
+func (r *dnsResolver) Close() {
+	r.cancel()
+	<-r.done
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
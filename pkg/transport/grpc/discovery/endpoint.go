@@ -0,0 +1,104 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+
+	"google.golang.org/grpc/resolver"
+)
+
+// DefaultPrefix is the default etcd key prefix of registered endpoints.
+const DefaultPrefix = "/erda-infra/grpc/services/"
+
+// Endpoint is an address of service registered in registry.
+type Endpoint struct {
+	Addr     string            `json:"addr"`
+	Weight   int               `json:"weight,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Key returns the registry key of endpoint for service.
+func Key(prefix, service, addr string) string {
+	return ServicePrefix(prefix, service) + addr
+}
+
+// ServicePrefix returns the registry key prefix of service.
+func ServicePrefix(prefix, service string) string {
+	if len(prefix) <= 0 {
+		prefix = DefaultPrefix
+	}
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return prefix + service + "/"
+}
+
+func (e *Endpoint) marshal() (string, error) {
+	byts, err := json.Marshal(e)
+	return string(byts), err
+}
+
+func unmarshalEndpoint(data []byte) (*Endpoint, error) {
+	ep := &Endpoint{}
+	if err := json.Unmarshal(data, ep); err != nil {
+		return nil, err
+	}
+	if len(ep.Addr) <= 0 {
+		return nil, fmt.Errorf("empty endpoint address")
+	}
+	return ep, nil
+}
+
+type weightKey struct{}
+
+// WithWeight returns addr with weight set for the weighted balancer.
+func WithWeight(addr resolver.Address, weight int) resolver.Address {
+	addr.BalancerAttributes = addr.BalancerAttributes.WithValue(weightKey{}, weight)
+	return addr
+}
+
+// Weight returns the weight of addr, it's 1 if not set.
+func Weight(addr resolver.Address) int {
+	if w, ok := addr.BalancerAttributes.Value(weightKey{}).(int); ok && w > 0 {
+		return w
+	}
+	return 1
+}
+
+// AdvertiseAddr returns the address for clients to connect to listen address,
+// the host is replaced with a non-loopback IP if it is unspecified.
+func AdvertiseAddr(listen string) (string, error) {
+	host, port, err := net.SplitHostPort(listen)
+	if err != nil {
+		return "", err
+	}
+	if ip := net.ParseIP(host); len(host) > 0 && (ip == nil || !ip.IsUnspecified()) {
+		return listen, nil
+	}
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return "", err
+	}
+	for _, addr := range addrs {
+		if ipnet, ok := addr.(*net.IPNet); ok && !ipnet.IP.IsLoopback() && ipnet.IP.To4() != nil {
+			return net.JoinHostPort(ipnet.IP.String(), port), nil
+		}
+	}
+	return "", fmt.Errorf("no available ip to advertise for %q", listen)
+}
@@ -0,0 +1,145 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"google.golang.org/grpc/resolver"
+)
+
+// EtcdScheme is the scheme of etcd resolver, e.g. etcd:///erda.infra.example.UserService
+const EtcdScheme = "etcd"
+
+// NewEtcdBuilder returns a resolver builder which watches endpoints registered by Register.
+func NewEtcdBuilder(client *clientv3.Client, prefix string) resolver.Builder {
+	return &etcdBuilder{client: client, prefix: prefix}
+}
+
+type etcdBuilder struct {
+	client *clientv3.Client
+	prefix string
+}
+
+func (b *etcdBuilder) Scheme() string { return EtcdScheme }
+
+func (b *etcdBuilder) Build(target resolver.Target, cc resolver.ClientConn, opts resolver.BuildOptions) (resolver.Resolver, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &etcdResolver{
+		client:    b.client,
+		keyPrefix: ServicePrefix(b.prefix, strings.Trim(target.Endpoint(), "/")),
+		cc:        cc,
+		ctx:       ctx,
+		cancel:    cancel,
+		endpoints: make(map[string]*Endpoint),
+	}
+	rev, err := r.load()
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	r.wg.Add(1)
+	go r.watch(rev)
+	return r, nil
+}
+
+type etcdResolver struct {
+	client    *clientv3.Client
+	keyPrefix string
+	cc        resolver.ClientConn
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+	endpoints map[string]*Endpoint
+}
+
+func (r *etcdResolver) load() (int64, error) {
+	resp, err := r.client.Get(r.ctx, r.keyPrefix, clientv3.WithPrefix())
+	if err != nil {
+		return 0, err
+	}
+	r.endpoints = make(map[string]*Endpoint)
+	for _, kv := range resp.Kvs {
+		if ep, err := unmarshalEndpoint(kv.Value); err == nil {
+			r.endpoints[string(kv.Key)] = ep
+		}
+	}
+	r.update()
+	return resp.Header.Revision, nil
+}
+
+func (r *etcdResolver) watch(rev int64) {
+	defer r.wg.Done()
+	for {
+		wch := r.client.Watch(r.ctx, r.keyPrefix, clientv3.WithPrefix(), clientv3.WithRev(rev+1))
+		for resp := range wch {
+			if err := resp.Err(); err != nil {
+				r.cc.ReportError(err)
+				break
+			}
+			for _, ev := range resp.Events {
+				key := string(ev.Kv.Key)
+				switch ev.Type {
+				case clientv3.EventTypePut:
+					if ep, err := unmarshalEndpoint(ev.Kv.Value); err == nil {
+						r.endpoints[key] = ep
+					}
+				case clientv3.EventTypeDelete:
+					delete(r.endpoints, key)
+				}
+			}
+			rev = resp.Header.Revision
+			r.update()
+		}
+		if r.ctx.Err() != nil {
+			return
+		}
+		// the watch is broken, e.g. compacted, reload all endpoints
+		for {
+			n, err := r.load()
+			if err == nil {
+				rev = n
+				break
+			}
+			r.cc.ReportError(err)
+			select {
+			case <-r.ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+		}
+	}
+}
+
+func (r *etcdResolver) update() {
+	addrs := make([]resolver.Address, 0, len(r.endpoints))
+	for _, ep := range r.endpoints {
+		addrs = append(addrs, WithWeight(resolver.Address{Addr: ep.Addr}, ep.Weight))
+	}
+	sort.Slice(addrs, func(i, j int) bool { return addrs[i].Addr < addrs[j].Addr })
+	r.cc.UpdateState(resolver.State{Addresses: addrs})
+}
+
+func (r *etcdResolver) ResolveNow(resolver.ResolveNowOptions) {}
+
+func (r *etcdResolver) Close() {
+	r.cancel()
+	r.wg.Wait()
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// Registration is the registration of endpoint in etcd, the endpoint is kept alive by a lease until it's closed.
+type Registration struct {
+	client   *clientv3.Client
+	keys     []string
+	value    string
+	ttl      int64
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	lock     sync.Mutex
+	lease    clientv3.LeaseID
+	onError  func(error)
+	interval time.Duration
+}
+
+// RegisterOption .
+type RegisterOption func(*Registration)
+
+// WithErrorHandler sets the handler of errors while keeping registration alive.
+func WithErrorHandler(fn func(error)) RegisterOption {
+	return func(r *Registration) {
+		r.onError = fn
+	}
+}
+
+// Register registers endpoint for services under prefix with a lease of ttl,
+// it will be registered again if the lease is lost, e.g. after etcd is unavailable for a while.
+func Register(ctx context.Context, client *clientv3.Client, prefix string, services []string, ep *Endpoint, ttl time.Duration, opts ...RegisterOption) (*Registration, error) {
+	value, err := ep.marshal()
+	if err != nil {
+		return nil, err
+	}
+	if ttl < time.Second {
+		ttl = 10 * time.Second
+	}
+	r := &Registration{
+		client:   client,
+		value:    value,
+		ttl:      int64(ttl / time.Second),
+		onError:  func(error) {},
+		interval: time.Second,
+	}
+	for _, service := range services {
+		r.keys = append(r.keys, Key(prefix, service, ep.Addr))
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	r.ctx, r.cancel = context.WithCancel(context.Background())
+	ch, err := r.register(ctx)
+	if err != nil {
+		r.cancel()
+		return nil, err
+	}
+	r.wg.Add(1)
+	go r.keepAlive(ch)
+	return r, nil
+}
+
+func (r *Registration) register(ctx context.Context) (<-chan *clientv3.LeaseKeepAliveResponse, error) {
+	lease, err := r.client.Grant(ctx, r.ttl)
+	if err != nil {
+		return nil, err
+	}
+	ops := make([]clientv3.Op, 0, len(r.keys))
+	for _, key := range r.keys {
+		ops = append(ops, clientv3.OpPut(key, r.value, clientv3.WithLease(lease.ID)))
+	}
+	if _, err := r.client.Txn(ctx).Then(ops...).Commit(); err != nil {
+		r.client.Revoke(context.Background(), lease.ID)
+		return nil, err
+	}
+	r.lock.Lock()
+	r.lease = lease.ID
+	r.lock.Unlock()
+	return r.client.KeepAlive(r.ctx, lease.ID)
+}
+
+func (r *Registration) keepAlive(ch <-chan *clientv3.LeaseKeepAliveResponse) {
+	defer r.wg.Done()
+	for {
+		for range ch {
+		}
+		// keepalive channel is closed, the lease is lost or registration is closed
+		for {
+			select {
+			case <-r.ctx.Done():
+				return
+			case <-time.After(r.interval):
+			}
+			var err error
+			if ch, err = r.register(r.ctx); err == nil {
+				break
+			}
+			r.onError(err)
+		}
+	}
+}
+
+// Close deregisters the endpoint.
+func (r *Registration) Close() error {
+	r.cancel()
+	r.wg.Wait()
+	r.lock.Lock()
+	lease := r.lease
+	r.lock.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := r.client.Revoke(ctx, lease)
+	return err
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"strings"
+
+	"google.golang.org/grpc/resolver"
+)
+
+// StaticScheme is the scheme of static resolver, e.g. static:///host1:7070,host2:7070
+const StaticScheme = "static"
+
+// NewStaticBuilder returns a resolver builder for a static list of addresses.
+func NewStaticBuilder() resolver.Builder { return staticBuilder{} }
+
+type staticBuilder struct{}
+
+func (staticBuilder) Scheme() string { return StaticScheme }
+
+func (staticBuilder) Build(target resolver.Target, cc resolver.ClientConn, opts resolver.BuildOptions) (resolver.Resolver, error) {
+	var addrs []resolver.Address
+	for _, addr := range strings.Split(target.Endpoint(), ",") {
+		addr = strings.TrimSpace(addr)
+		if len(addr) > 0 {
+			addrs = append(addrs, resolver.Address{Addr: addr})
+		}
+	}
+	if err := cc.UpdateState(resolver.State{Addresses: addrs}); err != nil {
+		return nil, err
+	}
+	return nopResolver{}, nil
+}
+
+type nopResolver struct{}
+
+func (nopResolver) ResolveNow(resolver.ResolveNowOptions) {}
+func (nopResolver) Close()                                {}
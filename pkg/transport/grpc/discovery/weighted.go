@@ -0,0 +1,70 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"sync"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+)
+
+// WeightedBalancerName is the name of balancer which picks addresses by static weights set by WithWeight.
+const WeightedBalancerName = "static_weighted_round_robin"
+
+func init() {
+	balancer.Register(base.NewBalancerBuilder(WeightedBalancerName, &weightedPickerBuilder{}, base.Config{HealthCheck: true}))
+}
+
+type weightedPickerBuilder struct{}
+
+func (*weightedPickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	if len(info.ReadySCs) <= 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+	p := &weightedPicker{}
+	for sc, sci := range info.ReadySCs {
+		p.items = append(p.items, &weightedItem{sc: sc, weight: Weight(sci.Address)})
+	}
+	return p
+}
+
+type weightedItem struct {
+	sc      balancer.SubConn
+	weight  int
+	current int
+}
+
+// weightedPicker picks items by smooth weighted round robin.
+type weightedPicker struct {
+	lock  sync.Mutex
+	items []*weightedItem
+}
+
+func (p *weightedPicker) Pick(balancer.PickInfo) (balancer.PickResult, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	var total int
+	var best *weightedItem
+	for _, item := range p.items {
+		item.current += item.weight
+		total += item.weight
+		if best == nil || item.current > best.current {
+			best = item
+		}
+	}
+	best.current -= total
+	return balancer.PickResult{SubConn: best.sc}, nil
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resilience
+
+import (
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrCircuitOpen is returned if the circuit breaker of target is open.
+var ErrCircuitOpen = status.Error(codes.Unavailable, "circuit breaker is open")
+
+type breakerState int
+
+const (
+	stateClosed breakerState = iota
+	stateOpen
+	stateHalfOpen
+)
+
+type breaker struct {
+	cfg      *BreakerConfig
+	lock     sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+	probes   int
+	now      func() time.Time
+}
+
+func newBreaker(cfg *BreakerConfig) *breaker {
+	return &breaker{cfg: cfg, now: time.Now}
+}
+
+// allow reports whether a request can be sent.
+func (b *breaker) allow() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	switch b.state {
+	case stateOpen:
+		if b.now().Sub(b.openedAt) < b.cfg.OpenTimeout {
+			return false
+		}
+		b.state, b.probes = stateHalfOpen, 0
+		fallthrough
+	case stateHalfOpen:
+		if b.probes >= b.cfg.HalfOpenRequests {
+			return false
+		}
+		b.probes++
+	}
+	return true
+}
+
+// done records the result of an allowed request.
+func (b *breaker) done(failed bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if !failed {
+		b.state, b.failures = stateClosed, 0
+		return
+	}
+	b.failures++
+	if b.state == stateHalfOpen || b.failures >= b.cfg.FailureThreshold {
+		b.state, b.openedAt = stateOpen, b.now()
+	}
+}
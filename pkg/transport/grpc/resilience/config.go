@@ -0,0 +1,133 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resilience
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+
+	transerrors "github.com/erda-project/erda-infra/pkg/transport/errors"
+)
+
+// Config is the resilience config of client.
+type Config struct {
+	Retry   RetryPolicy              `file:"retry"`
+	Hedging HedgingPolicy            `file:"hedging"`
+	Breaker BreakerConfig            `file:"circuit_breaker"`
+	Methods map[string]*MethodPolicy `file:"methods" desc:"policies of methods, keyed by full method name (e.g. erda.infra.example.UserService/GetUser) or service name"`
+}
+
+// RetryPolicy .
+type RetryPolicy struct {
+	MaxAttempts    int           `file:"max_attempts" default:"1" desc:"max attempts including the original call, 1 means no retry"`
+	InitialBackoff time.Duration `file:"initial_backoff" default:"100ms" desc:"backoff before the first retry"`
+	MaxBackoff     time.Duration `file:"max_backoff" default:"1s" desc:"max backoff between retries"`
+	Multiplier     float64       `file:"backoff_multiplier" default:"2" desc:"backoff multiplier"`
+	Jitter         float64       `file:"jitter" default:"0.2" desc:"random jitter ratio of backoff"`
+	Codes          []string      `file:"retryable_codes" default:"UNAVAILABLE" desc:"status codes to retry"`
+	NonIdempotent  bool          `file:"non_idempotent" desc:"retry methods which are not known to be idempotent"`
+}
+
+// HedgingPolicy .
+type HedgingPolicy struct {
+	MaxAttempts int           `file:"max_attempts" default:"1" desc:"max concurrent attempts including the original call, 1 means no hedging"`
+	Delay       time.Duration `file:"delay" default:"100ms" desc:"delay before sending the next hedged request"`
+	Codes       []string      `file:"non_fatal_codes" default:"UNAVAILABLE" desc:"status codes that do not stop sending hedged requests"`
+}
+
+// BreakerConfig .
+type BreakerConfig struct {
+	Enable           bool          `file:"enable" desc:"enable circuit breaker per target"`
+	FailureThreshold int           `file:"failure_threshold" default:"5" desc:"consecutive failures to open the circuit"`
+	OpenTimeout      time.Duration `file:"open_timeout" default:"10s" desc:"duration of open state before trying again"`
+	HalfOpenRequests int           `file:"half_open_requests" default:"1" desc:"max requests allowed in half open state"`
+	Codes            []string      `file:"failure_codes" default:"UNAVAILABLE,DEADLINE_EXCEEDED,INTERNAL" desc:"status codes counted as failure"`
+}
+
+// MethodPolicy overrides the policies for methods, zero fields are inherited.
+type MethodPolicy struct {
+	Idempotent *bool          `file:"idempotent" desc:"mark methods as idempotent, by default it's detected from proto options"`
+	Retry      *RetryPolicy   `file:"retry"`
+	Hedging    *HedgingPolicy `file:"hedging"`
+}
+
+// Enabled returns true if any policy is enabled.
+func (c *Config) Enabled() bool {
+	if c.Retry.MaxAttempts > 1 || c.Hedging.MaxAttempts > 1 || c.Breaker.Enable {
+		return true
+	}
+	for _, mp := range c.Methods {
+		if mp != nil && ((mp.Retry != nil && mp.Retry.MaxAttempts > 1) || (mp.Hedging != nil && mp.Hedging.MaxAttempts > 1)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p RetryPolicy) inherit(base *RetryPolicy) RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = base.MaxAttempts
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = base.InitialBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = base.MaxBackoff
+	}
+	if p.Multiplier <= 0 {
+		p.Multiplier = base.Multiplier
+	}
+	if p.Jitter <= 0 {
+		p.Jitter = base.Jitter
+	}
+	if len(p.Codes) <= 0 {
+		p.Codes = base.Codes
+	}
+	return p
+}
+
+func (p HedgingPolicy) inherit(base *HedgingPolicy) HedgingPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = base.MaxAttempts
+	}
+	if p.Delay <= 0 {
+		p.Delay = base.Delay
+	}
+	if len(p.Codes) <= 0 {
+		p.Codes = base.Codes
+	}
+	return p
+}
+
+type codeSet map[codes.Code]bool
+
+func parseCodes(names []string) (codeSet, error) {
+	set := make(codeSet)
+	for _, name := range names {
+		name = strings.ToUpper(strings.TrimSpace(name))
+		if len(name) <= 0 {
+			continue
+		}
+		code, ok := transerrors.CodeFromName(name)
+		if !ok {
+			return nil, fmt.Errorf("invalid status code %q", name)
+		}
+		set[code] = true
+	}
+	return set, nil
+}
@@ -0,0 +1,222 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resilience
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+type methodPolicy struct {
+	idempotent   bool
+	retry        RetryPolicy
+	retryCodes   codeSet
+	hedging      HedgingPolicy
+	hedgingCodes codeSet
+}
+
+type interceptor struct {
+	cfg          *Config
+	breakerCodes codeSet
+	policies     sync.Map // method -> *methodPolicy
+	breakers     sync.Map // target -> *breaker
+}
+
+// UnaryClientInterceptor returns a client interceptor to retry, hedge and break circuits of unary calls by cfg.
+func UnaryClientInterceptor(cfg *Config) (grpc.UnaryClientInterceptor, error) {
+	it := &interceptor{cfg: cfg}
+	var err error
+	if cfg.Breaker.Enable {
+		it.breakerCodes, err = parseCodes(cfg.Breaker.Codes)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// validate policies early
+	if _, err := it.newPolicy(""); err != nil {
+		return nil, err
+	}
+	for key := range cfg.Methods {
+		if _, err := it.newPolicy(key); err != nil {
+			return nil, err
+		}
+	}
+	return it.intercept, nil
+}
+
+func (it *interceptor) intercept(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if it.cfg.Breaker.Enable {
+		invoker = it.withBreaker(cc.Target(), invoker)
+	}
+	p := it.policy(method)
+	if p.idempotent && p.hedging.MaxAttempts > 1 {
+		if msg, ok := reply.(proto.Message); ok {
+			return it.hedge(ctx, p, msg, method, req, cc, invoker, opts...)
+		}
+	}
+	if p.retry.MaxAttempts > 1 && (p.idempotent || p.retry.NonIdempotent) {
+		return it.retry(ctx, p, method, req, reply, cc, invoker, opts...)
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+func (it *interceptor) policy(method string) *methodPolicy {
+	if p, ok := it.policies.Load(method); ok {
+		return p.(*methodPolicy)
+	}
+	p, _ := it.newPolicy(method) // policies are validated in UnaryClientInterceptor
+	it.policies.Store(method, p)
+	return p
+}
+
+func (it *interceptor) newPolicy(method string) (*methodPolicy, error) {
+	method = strings.TrimPrefix(method, "/")
+	p := &methodPolicy{
+		idempotent: isIdempotent(method),
+		retry:      it.cfg.Retry,
+		hedging:    it.cfg.Hedging,
+	}
+	service := method
+	if idx := strings.LastIndex(method, "/"); idx >= 0 {
+		service = method[:idx]
+	}
+	for _, key := range []string{service, method} {
+		mp := it.cfg.Methods[key]
+		if mp == nil {
+			continue
+		}
+		if mp.Idempotent != nil {
+			p.idempotent = *mp.Idempotent
+		}
+		if mp.Retry != nil {
+			p.retry = mp.Retry.inherit(&p.retry)
+		}
+		if mp.Hedging != nil {
+			p.hedging = mp.Hedging.inherit(&p.hedging)
+		}
+	}
+	var err error
+	p.retryCodes, err = parseCodes(p.retry.Codes)
+	if err != nil {
+		return nil, err
+	}
+	p.hedgingCodes, err = parseCodes(p.hedging.Codes)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (it *interceptor) withBreaker(target string, invoker grpc.UnaryInvoker) grpc.UnaryInvoker {
+	v, ok := it.breakers.Load(target)
+	if !ok {
+		v, _ = it.breakers.LoadOrStore(target, newBreaker(&it.cfg.Breaker))
+	}
+	b := v.(*breaker)
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		if !b.allow() {
+			return ErrCircuitOpen
+		}
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		b.done(err != nil && it.breakerCodes[status.Code(err)])
+		return err
+	}
+}
+
+func (it *interceptor) retry(ctx context.Context, p *methodPolicy, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	backoff := p.retry.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if err == nil || err == ErrCircuitOpen || attempt >= p.retry.MaxAttempts || !p.retryCodes[status.Code(err)] {
+			return err
+		}
+		timer := time.NewTimer(jitter(backoff, p.retry.Jitter))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		backoff = time.Duration(float64(backoff) * p.retry.Multiplier)
+		if p.retry.MaxBackoff > 0 && backoff > p.retry.MaxBackoff {
+			backoff = p.retry.MaxBackoff
+		}
+	}
+}
+
+func (it *interceptor) hedge(ctx context.Context, p *methodPolicy, reply proto.Message, method string, req interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	type result struct {
+		reply proto.Message
+		err   error
+	}
+	results := make(chan result, p.hedging.MaxAttempts)
+	send := func() {
+		out := reply.ProtoReflect().New().Interface()
+		go func() {
+			err := invoker(ctx, method, req, out, cc, opts...)
+			results <- result{reply: out, err: err}
+		}()
+	}
+	send()
+	sent, received := 1, 0
+	timer := time.NewTimer(p.hedging.Delay)
+	defer timer.Stop()
+	var lastErr error
+	for {
+		select {
+		case <-timer.C:
+			if sent < p.hedging.MaxAttempts {
+				send()
+				sent++
+				timer.Reset(p.hedging.Delay)
+			}
+		case r := <-results:
+			received++
+			if r.err == nil {
+				proto.Reset(reply)
+				proto.Merge(reply, r.reply)
+				return nil
+			}
+			lastErr = r.err
+			if r.err == ErrCircuitOpen || !p.hedgingCodes[status.Code(r.err)] {
+				return r.err
+			}
+			if sent < p.hedging.MaxAttempts {
+				send()
+				sent++
+				timer.Reset(p.hedging.Delay)
+			} else if received >= sent {
+				return lastErr
+			}
+		}
+	}
+}
+
+func jitter(d time.Duration, ratio float64) time.Duration {
+	if ratio <= 0 || d <= 0 {
+		return d
+	}
+	delta := float64(d) * ratio
+	return time.Duration(float64(d) - delta + rand.Float64()*2*delta)
+}
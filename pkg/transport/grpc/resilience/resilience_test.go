@@ -0,0 +1,123 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resilience
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func boolPtr(b bool) *bool { return &b }
+
+func TestRetry(t *testing.T) {
+	cfg := &Config{
+		Retry: RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, Multiplier: 2, Codes: []string{"UNAVAILABLE"}},
+		Methods: map[string]*MethodPolicy{
+			"test.Service":        {Idempotent: boolPtr(true)},
+			"test.Service/Create": {Idempotent: boolPtr(false)},
+		},
+	}
+	it, err := UnaryClientInterceptor(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		method    string
+		errs      []codes.Code
+		wantCalls int
+		wantCode  codes.Code
+	}{
+		{"/test.Service/Get", []codes.Code{codes.Unavailable, codes.OK}, 2, codes.OK},
+		{"/test.Service/Get", []codes.Code{codes.Unavailable, codes.Unavailable, codes.Unavailable}, 3, codes.Unavailable},
+		{"/test.Service/Get", []codes.Code{codes.NotFound}, 1, codes.NotFound},
+		{"/test.Service/Create", []codes.Code{codes.Unavailable}, 1, codes.Unavailable},
+	}
+	for _, tt := range tests {
+		var calls int
+		invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			code := tt.errs[calls]
+			calls++
+			return status.Error(code, code.String())
+		}
+		err := it(context.Background(), tt.method, nil, &wrapperspb.StringValue{}, nil, invoker)
+		if calls != tt.wantCalls || status.Code(err) != tt.wantCode {
+			t.Errorf("%s %v: calls = %d, code = %v, want %d, %v", tt.method, tt.errs, calls, status.Code(err), tt.wantCalls, tt.wantCode)
+		}
+	}
+}
+
+func TestHedging(t *testing.T) {
+	cfg := &Config{
+		Hedging: HedgingPolicy{MaxAttempts: 2, Delay: 10 * time.Millisecond, Codes: []string{"UNAVAILABLE"}},
+		Methods: map[string]*MethodPolicy{"test.Service": {Idempotent: boolPtr(true)}},
+	}
+	it, err := UnaryClientInterceptor(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var calls int32
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			<-ctx.Done() // the first request hangs until the hedged one wins
+			return status.FromContextError(ctx.Err()).Err()
+		}
+		reply.(*wrapperspb.StringValue).Value = "hedged"
+		return nil
+	}
+	reply := &wrapperspb.StringValue{}
+	if err := it(context.Background(), "/test.Service/Get", nil, reply, nil, invoker); err != nil {
+		t.Fatal(err)
+	}
+	if reply.Value != "hedged" {
+		t.Errorf("reply = %q, want %q", reply.Value, "hedged")
+	}
+}
+
+func TestBreaker(t *testing.T) {
+	now := time.Now()
+	b := newBreaker(&BreakerConfig{FailureThreshold: 2, OpenTimeout: time.Second, HalfOpenRequests: 1})
+	b.now = func() time.Time { return now }
+
+	steps := []struct {
+		advance   time.Duration
+		wantAllow bool
+		failed    bool
+	}{
+		{0, true, true},
+		{0, true, true}, // opened
+		{0, false, false},
+		{time.Second, true, true}, // half open, probe fails
+		{0, false, false},
+		{time.Second, true, false}, // half open, probe succeeds
+		{0, true, false},
+	}
+	for i, s := range steps {
+		now = now.Add(s.advance)
+		allow := b.allow()
+		if allow != s.wantAllow {
+			t.Fatalf("step %d: allow() = %v, want %v", i, allow, s.wantAllow)
+		}
+		if allow {
+			b.done(s.failed)
+		}
+	}
+}
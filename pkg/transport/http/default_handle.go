@@ -15,9 +15,9 @@
 package http
 
 import (
-	"encoding/json"
 	"net/http"
 
+	transerrors "github.com/erda-project/erda-infra/pkg/transport/errors"
 	"github.com/erda-project/erda-infra/pkg/transport/http/encoding"
 )
 
@@ -35,19 +35,7 @@ type Error interface {
 	HTTPStatus() int
 }
 
-// EncodeError default EncodeErrorFunc implement
+// EncodeError default EncodeErrorFunc implement, err is rendered by transerrors.WriteHTTP
 func EncodeError(w http.ResponseWriter, r *http.Request, err error) {
-	var status int
-	if e, ok := err.(Error); ok {
-		status = e.HTTPStatus()
-	} else {
-		status = http.StatusInternalServerError
-	}
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	byts, _ := json.Marshal(map[string]interface{}{
-		"code": status,
-		"err":  err.Error(),
-	})
-	w.Write(byts)
+	transerrors.WriteHTTP(w, err)
 }
@@ -30,6 +30,8 @@ import (
 	protoV2 "google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protoreflect"
 	"google.golang.org/protobuf/reflect/protoregistry"
+
+	"github.com/erda-project/erda-infra/pkg/transport/fieldmask"
 )
 
 // Marshaler is a configurable object for marshaling protocol buffer messages
@@ -55,6 +57,10 @@ type Marshaler struct {
 	// AnyResolver is used to resolve the google.protobuf.Any well-known type.
 	// If unset, the global registry is used by default.
 	AnyResolver AnyResolver
+
+	// Fields specifies the field mask paths to render, e.g. items.id.
+	// If empty, all fields are rendered.
+	Fields []string
 }
 
 // IMarshaler is implemented by protobuf messages that customize the
@@ -96,6 +102,14 @@ func (jm *Marshaler) marshal(m proto.Message) ([]byte, error) {
 		return nil, errors.New("Marshal called with nil")
 	}
 
+	if len(jm.Fields) > 0 {
+		msg := protoV2.Clone(proto.MessageV2(m))
+		if err := fieldmask.Prune(msg, jm.Fields); err != nil {
+			return nil, err
+		}
+		m = proto.MessageV1(msg)
+	}
+
 	// Check for custom marshalers first since they may not properly
 	// implement protobuf reflection that the logic below relies on.
 	if jsm, ok := m.(IMarshaler); ok {
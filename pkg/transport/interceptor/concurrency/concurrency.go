@@ -0,0 +1,111 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package concurrency
+
+import (
+	"context"
+	"sync"
+
+	"github.com/erda-project/erda-infra/pkg/transport"
+	transerrors "github.com/erda-project/erda-infra/pkg/transport/errors"
+	"github.com/erda-project/erda-infra/pkg/transport/interceptor"
+)
+
+type config struct {
+	def     int
+	methods map[string]int
+	global  bool
+	block   bool
+}
+
+// Option .
+type Option func(*config)
+
+// WithMethod setup the max concurrent requests of method,
+// name is the full method name like "pkg.Service/Method" or a service name like "pkg.Service".
+func WithMethod(name string, max int) Option {
+	return func(c *config) {
+		c.methods[name] = max
+	}
+}
+
+// WithGlobal shares the default limit by all methods instead of limiting each method separately.
+func WithGlobal() Option {
+	return func(c *config) {
+		c.global = true
+	}
+}
+
+// WithBlock waits for the running requests to finish until the context is done, instead of rejecting immediately.
+func WithBlock() Option {
+	return func(c *config) {
+		c.block = true
+	}
+}
+
+// Limit returns an Interceptor to limit the concurrent requests of each method to max,
+// max <= 0 means methods without specified limit are not limited.
+// A ResourceExhausted error is returned if the limit is exceeded.
+func Limit(max int, opts ...Option) interceptor.Interceptor {
+	cfg := &config{def: max, methods: make(map[string]int)}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	var sems sync.Map
+	getSemaphore := func(info transport.ServiceInfo) chan struct{} {
+		var key string
+		limit := cfg.def
+		if info != nil {
+			if n, ok := cfg.methods[info.Service()+"/"+info.Method()]; ok {
+				key, limit = info.Service()+"/"+info.Method(), n
+			} else if n, ok := cfg.methods[info.Service()]; ok {
+				key, limit = info.Service(), n
+			} else if !cfg.global {
+				key = info.Service() + "/" + info.Method()
+			}
+		}
+		if limit <= 0 {
+			return nil
+		}
+		if sem, ok := sems.Load(key); ok {
+			return sem.(chan struct{})
+		}
+		sem, _ := sems.LoadOrStore(key, make(chan struct{}, limit))
+		return sem.(chan struct{})
+	}
+	return func(next interceptor.Handler) interceptor.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			sem := getSemaphore(transport.ContextServiceInfo(ctx))
+			if sem == nil {
+				return next(ctx, req)
+			}
+			if cfg.block {
+				select {
+				case sem <- struct{}{}:
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
+			} else {
+				select {
+				case sem <- struct{}{}:
+				default:
+					return nil, transerrors.ResourceExhausted("too many concurrent requests for %s", transport.GetFullMethodName(ctx))
+				}
+			}
+			defer func() { <-sem }()
+			return next(ctx, req)
+		}
+	}
+}
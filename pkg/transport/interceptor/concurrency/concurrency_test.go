@@ -0,0 +1,56 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package concurrency
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+
+	"github.com/erda-project/erda-infra/pkg/transport"
+	transerrors "github.com/erda-project/erda-infra/pkg/transport/errors"
+)
+
+func TestLimit(t *testing.T) {
+	entered, release := make(chan struct{}), make(chan struct{})
+	h := Limit(1)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		if req == "wait" {
+			entered <- struct{}{}
+			<-release
+		}
+		return nil, nil
+	})
+	ctxA := transport.WithServiceInfo(context.Background(), transport.NewServiceInfo("test.Service", "A", nil))
+	ctxB := transport.WithServiceInfo(context.Background(), transport.NewServiceInfo("test.Service", "B", nil))
+
+	done := make(chan struct{})
+	go func() {
+		h(ctxA, "wait")
+		close(done)
+	}()
+	<-entered
+	if _, err := h(ctxA, nil); transerrors.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Limit() error = %v, want ResourceExhausted", err)
+	}
+	if _, err := h(ctxB, nil); err != nil {
+		t.Errorf("Limit() error = %v, methods should be limited separately", err)
+	}
+	close(release)
+	<-done
+	if _, err := h(ctxA, nil); err != nil {
+		t.Errorf("Limit() error = %v, want nil after release", err)
+	}
+}
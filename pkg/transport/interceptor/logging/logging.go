@@ -0,0 +1,95 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/peer"
+
+	"github.com/erda-project/erda-infra/base/logs"
+	"github.com/erda-project/erda-infra/pkg/transport"
+	transerrors "github.com/erda-project/erda-infra/pkg/transport/errors"
+	transhttp "github.com/erda-project/erda-infra/pkg/transport/http"
+	"github.com/erda-project/erda-infra/pkg/transport/interceptor"
+)
+
+type config struct {
+	skip      func(ctx context.Context) bool
+	slowTime  time.Duration
+	logErrors bool
+}
+
+// Option .
+type Option func(*config)
+
+// WithSkipper skips logging requests when skip returns true.
+func WithSkipper(skip func(ctx context.Context) bool) Option {
+	return func(c *config) {
+		c.skip = skip
+	}
+}
+
+// WithSlowThreshold logs requests that take longer than d in warning level.
+func WithSlowThreshold(d time.Duration) Option {
+	return func(c *config) {
+		c.slowTime = d
+	}
+}
+
+// OnlyErrors logs failed requests only.
+func OnlyErrors() Option {
+	return func(c *config) {
+		c.logErrors = true
+	}
+}
+
+// Logging returns an Interceptor to log each request with the service and method of ServiceInfo.
+func Logging(log logs.Logger, opts ...Option) interceptor.Interceptor {
+	cfg := &config{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return func(next interceptor.Handler) interceptor.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if cfg.skip != nil && cfg.skip(ctx) {
+				return next(ctx, req)
+			}
+			start := time.Now()
+			resp, err := next(ctx, req)
+			cost := time.Since(start)
+
+			kind, addr := "grpc", ""
+			if r := transhttp.ContextRequest(ctx); r != nil {
+				kind, addr = "http", r.RemoteAddr
+			} else if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+				addr = p.Addr.String()
+			}
+			method := transport.GetFullMethodName(ctx)
+			if err != nil {
+				code := transerrors.Code(err)
+				log.Errorf("%s %s from %s, code: %s, cost: %s, err: %s", kind, method, addr, transerrors.CodeName(code), cost, err)
+				return resp, err
+			}
+			if cfg.slowTime > 0 && cost >= cfg.slowTime {
+				log.Warnf("%s %s from %s, slow request, cost: %s", kind, method, addr, cost)
+			} else if !cfg.logErrors {
+				log.Infof("%s %s from %s, cost: %s", kind, method, addr, cost)
+			}
+			return resp, err
+		}
+	}
+}
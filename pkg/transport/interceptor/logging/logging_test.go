@@ -0,0 +1,75 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/erda-project/erda-infra/base/logs/logrusx"
+	"github.com/erda-project/erda-infra/pkg/transport"
+	transerrors "github.com/erda-project/erda-infra/pkg/transport/errors"
+)
+
+func TestLogging(t *testing.T) {
+	ctx := transport.WithServiceInfo(context.Background(), transport.NewServiceInfo("test.Service", "Method", nil))
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		switch req {
+		case "fail":
+			return nil, transerrors.NotFound("no %s", "user")
+		case "slow":
+			time.Sleep(20 * time.Millisecond)
+		}
+		return "ok", nil
+	}
+	tests := []struct {
+		name string
+		opts []Option
+		req  string
+		want []string // empty if nothing is logged
+	}{
+		{name: "ok", req: "ok", want: []string{"INFO", "grpc test.Service/Method"}},
+		{name: "error", req: "fail", want: []string{"ERRO", "code: NOT_FOUND", "no user"}},
+		{name: "slow", opts: []Option{WithSlowThreshold(10 * time.Millisecond)}, req: "slow", want: []string{"WARN", "slow request"}},
+		{name: "only errors", opts: []Option{OnlyErrors()}, req: "ok"},
+		{name: "skipped", opts: []Option{WithSkipper(func(context.Context) bool { return true })}, req: "fail"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			log := logrusx.New()
+			log.SetOutput(&buf)
+			resp, err := Logging(log, tt.opts...)(handler)(ctx, tt.req)
+			if tt.req == "fail" {
+				if err == nil {
+					t.Errorf("Logging() error = nil, the error of handler should be returned")
+				}
+			} else if resp != "ok" || err != nil {
+				t.Errorf("Logging() = %v, %v, want ok", resp, err)
+			}
+			if len(tt.want) <= 0 && buf.Len() > 0 {
+				t.Errorf("Logging() logs %q, want nothing", buf.String())
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("Logging() logs %q, want %q", buf.String(), want)
+				}
+			}
+		})
+	}
+}
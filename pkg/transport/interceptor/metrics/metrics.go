@@ -0,0 +1,57 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
+
+	pkgmetrics "github.com/erda-project/erda-infra/pkg/metrics"
+	"github.com/erda-project/erda-infra/pkg/transport"
+	transerrors "github.com/erda-project/erda-infra/pkg/transport/errors"
+	transhttp "github.com/erda-project/erda-infra/pkg/transport/http"
+	"github.com/erda-project/erda-infra/pkg/transport/interceptor"
+)
+
+var labelNames = []string{"transport", "service", "method"}
+
+// Metrics returns an Interceptor to record the count, latency, in-flight requests and response size of each method,
+// opts configure the metrics, the subsystem of metric names is transport by default.
+// Metrics registered by another Metrics interceptor with the same options are shared.
+func Metrics(opts ...pkgmetrics.Option) interceptor.Interceptor {
+	red := pkgmetrics.NewRED(labelNames, "code", append([]pkgmetrics.Option{pkgmetrics.WithNamespace("", "transport")}, opts...)...)
+	return func(next interceptor.Handler) interceptor.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			kind, service, method := "grpc", "", ""
+			if transhttp.ContextRequest(ctx) != nil {
+				kind = "http"
+			}
+			if info := transport.ContextServiceInfo(ctx); info != nil {
+				service, method = info.Service(), info.Method()
+			}
+			o := red.Begin(kind, service, method)
+			code, size := codes.Internal, -1 // recorded if handler panics
+			defer func() { o.End(transerrors.CodeName(code), size) }()
+			resp, err := next(ctx, req)
+			code = transerrors.Code(err)
+			if msg, ok := resp.(proto.Message); ok && err == nil {
+				size = proto.Size(msg)
+			}
+			return resp, err
+		}
+	}
+}
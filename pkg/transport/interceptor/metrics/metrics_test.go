@@ -0,0 +1,61 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	pkgmetrics "github.com/erda-project/erda-infra/pkg/metrics"
+	"github.com/erda-project/erda-infra/pkg/transport"
+	transerrors "github.com/erda-project/erda-infra/pkg/transport/errors"
+)
+
+func TestMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	h := Metrics(pkgmetrics.WithRegisterer(reg))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		switch req {
+		case "fail":
+			return nil, transerrors.NotFound("not found")
+		case "panic":
+			panic("boom")
+		}
+		return nil, nil
+	})
+	ctx := transport.WithServiceInfo(context.Background(), transport.NewServiceInfo("test.Service", "Method", nil))
+	for _, req := range []string{"ok", "ok", "fail", "panic"} {
+		func() {
+			defer func() { recover() }()
+			h(ctx, req)
+		}()
+	}
+	want := `
+# HELP transport_requests_in_flight Number of requests being handled.
+# TYPE transport_requests_in_flight gauge
+transport_requests_in_flight{method="Method",service="test.Service",transport="grpc"} 0
+# HELP transport_requests_total Total number of requests handled.
+# TYPE transport_requests_total counter
+transport_requests_total{code="INTERNAL",method="Method",service="test.Service",transport="grpc"} 1
+transport_requests_total{code="NOT_FOUND",method="Method",service="test.Service",transport="grpc"} 1
+transport_requests_total{code="OK",method="Method",service="test.Service",transport="grpc"} 2
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want), "transport_requests_in_flight", "transport_requests_total"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/time/rate"
+
+	"github.com/erda-project/erda-infra/pkg/transport"
+	transerrors "github.com/erda-project/erda-infra/pkg/transport/errors"
+	"github.com/erda-project/erda-infra/pkg/transport/interceptor"
+)
+
+// Limit is the rate of a token bucket.
+type Limit struct {
+	// Rate is the number of requests allowed per second.
+	Rate float64
+	// Burst is the max number of requests allowed at once.
+	Burst int
+}
+
+type config struct {
+	def     *Limit
+	methods map[string]Limit
+}
+
+// Option .
+type Option func(*config)
+
+// WithDefault setup the limit of methods without specified limit, methods are not limited by default.
+func WithDefault(l Limit) Option {
+	return func(c *config) {
+		c.def = &l
+	}
+}
+
+// WithMethod setup the limit of method, name is the full method name like "pkg.Service/Method" or a service name like "pkg.Service",
+// the limit of service is shared by all methods of the service.
+func WithMethod(name string, l Limit) Option {
+	return func(c *config) {
+		c.methods[name] = l
+	}
+}
+
+// RateLimit returns an Interceptor to limit the requests rate of each method by a token bucket,
+// a ResourceExhausted error is returned if the limit is exceeded.
+func RateLimit(opts ...Option) interceptor.Interceptor {
+	cfg := &config{methods: make(map[string]Limit)}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	var limiters sync.Map
+	getLimiter := func(info transport.ServiceInfo) *rate.Limiter {
+		var key string
+		limit := cfg.def
+		if info != nil {
+			key = info.Service() + "/" + info.Method()
+			if l, ok := cfg.methods[key]; ok {
+				limit = &l
+			} else if l, ok := cfg.methods[info.Service()]; ok {
+				key, limit = info.Service(), &l
+			}
+		}
+		if limit == nil {
+			return nil
+		}
+		if l, ok := limiters.Load(key); ok {
+			return l.(*rate.Limiter)
+		}
+		l, _ := limiters.LoadOrStore(key, rate.NewLimiter(rate.Limit(limit.Rate), limit.Burst))
+		return l.(*rate.Limiter)
+	}
+	return func(next interceptor.Handler) interceptor.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			info := transport.ContextServiceInfo(ctx)
+			if l := getLimiter(info); l != nil && !l.Allow() {
+				return nil, transerrors.ResourceExhausted("rate limit exceeded for %s", transport.GetFullMethodName(ctx))
+			}
+			return next(ctx, req)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+
+	"github.com/erda-project/erda-infra/pkg/transport"
+	transerrors "github.com/erda-project/erda-infra/pkg/transport/errors"
+)
+
+func TestRateLimit(t *testing.T) {
+	h := RateLimit(
+		WithDefault(Limit{Rate: 0, Burst: 1}),
+		WithMethod("test.Service/B", Limit{Rate: 0, Burst: 2}),
+		WithMethod("test.Other", Limit{Rate: 0, Burst: 2}),
+	)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	call := func(method string) codes.Code {
+		service := "test.Service"
+		if idx := strings.Index(method, "/"); idx >= 0 {
+			service, method = method[:idx], method[idx+1:]
+		}
+		ctx := transport.WithServiceInfo(context.Background(), transport.NewServiceInfo(service, method, nil))
+		_, err := h(ctx, nil)
+		return transerrors.Code(err)
+	}
+	tests := []struct {
+		method string
+		want   codes.Code
+	}{
+		{"A", codes.OK},
+		{"A", codes.ResourceExhausted},
+		{"B", codes.OK},
+		{"B", codes.OK},
+		{"B", codes.ResourceExhausted},
+		{"C", codes.OK},
+		// the limit of service is shared by its methods
+		{"test.Other/A", codes.OK},
+		{"test.Other/B", codes.OK},
+		{"test.Other/C", codes.ResourceExhausted},
+	}
+	for i, tt := range tests {
+		if got := call(tt.method); got != tt.want {
+			t.Errorf("call %d %s = %v, want %v", i, tt.method, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recovery
+
+import (
+	"context"
+	"log"
+	"runtime"
+
+	"github.com/erda-project/erda-infra/base/logs"
+	"github.com/erda-project/erda-infra/pkg/transport"
+	transerrors "github.com/erda-project/erda-infra/pkg/transport/errors"
+	"github.com/erda-project/erda-infra/pkg/transport/interceptor"
+)
+
+// HandlerFunc converts the recovered value to error.
+type HandlerFunc func(ctx context.Context, req interface{}, p interface{}) error
+
+type config struct {
+	logf    func(format string, args ...interface{})
+	handler HandlerFunc
+}
+
+// Option .
+type Option func(*config)
+
+// WithLogger logs the panic value and stack by log, they are logged by the standard logger by default.
+func WithLogger(log logs.Logger) Option {
+	return func(c *config) {
+		c.logf = log.Errorf
+	}
+}
+
+// WithHandler setup the func to convert the recovered value to error.
+func WithHandler(h HandlerFunc) Option {
+	return func(c *config) {
+		c.handler = h
+	}
+}
+
+// Recovery returns an Interceptor to recover from panics of handlers,
+// an Internal error is returned by default, the panic value is logged but not returned to clients.
+func Recovery(opts ...Option) interceptor.Interceptor {
+	cfg := &config{
+		logf: log.Printf,
+		handler: func(ctx context.Context, req interface{}, p interface{}) error {
+			return transerrors.Internal("internal error")
+		},
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return func(next interceptor.Handler) interceptor.Handler {
+		return func(ctx context.Context, req interface{}) (resp interface{}, err error) {
+			defer func() {
+				if p := recover(); p != nil {
+					buf := make([]byte, 64<<10)
+					buf = buf[:runtime.Stack(buf, false)]
+					cfg.logf("panic in %s: %v\n%s", transport.GetFullMethodName(ctx), p, buf)
+					resp, err = nil, cfg.handler(ctx, req, p)
+				}
+			}()
+			return next(ctx, req)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recovery
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+
+	"github.com/erda-project/erda-infra/base/logs/logrusx"
+	"github.com/erda-project/erda-infra/pkg/transport"
+	transerrors "github.com/erda-project/erda-infra/pkg/transport/errors"
+)
+
+func TestRecovery(t *testing.T) {
+	var buf bytes.Buffer
+	log := logrusx.New()
+	log.SetOutput(&buf)
+	h := Recovery(WithLogger(log))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		panic("boom")
+	})
+	ctx := transport.WithServiceInfo(context.Background(), transport.NewServiceInfo("test.Service", "Method", nil))
+	resp, err := h(ctx, nil)
+	if resp != nil || transerrors.Code(err) != codes.Internal {
+		t.Errorf("Recovery() = %v, %v, want Internal error", resp, err)
+	}
+	if strings.Contains(err.Error(), "boom") {
+		t.Errorf("Recovery() error = %v, the panic value should not be returned", err)
+	}
+	if !strings.Contains(buf.String(), "panic in test.Service/Method: boom") {
+		t.Errorf("Recovery() logs %q, want the panic value", buf.String())
+	}
+
+	custom := errors.New("custom")
+	h = Recovery(WithHandler(func(ctx context.Context, req interface{}, p interface{}) error {
+		return custom
+	}))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		panic("boom")
+	})
+	if _, err := h(context.Background(), nil); err != custom {
+		t.Errorf("Recovery() error = %v, want %v", err, custom)
+	}
+
+	h = Recovery()(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	})
+	if resp, err := h(context.Background(), nil); resp != "ok" || err != nil {
+		t.Errorf("Recovery() = %v, %v, want ok", resp, err)
+	}
+}
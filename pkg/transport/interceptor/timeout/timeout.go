@@ -0,0 +1,65 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package timeout
+
+import (
+	"context"
+	"time"
+
+	"github.com/erda-project/erda-infra/pkg/transport"
+	"github.com/erda-project/erda-infra/pkg/transport/interceptor"
+)
+
+type config struct {
+	methods map[string]time.Duration
+}
+
+// Option .
+type Option func(*config)
+
+// WithMethod setup the timeout of method,
+// name is the full method name like "pkg.Service/Method" or a service name like "pkg.Service".
+func WithMethod(name string, timeout time.Duration) Option {
+	return func(c *config) {
+		c.methods[name] = timeout
+	}
+}
+
+// Timeout returns an Interceptor to limit the time of each request,
+// the deadline of context is kept if it's earlier. timeout <= 0 means no default timeout.
+func Timeout(timeout time.Duration, opts ...Option) interceptor.Interceptor {
+	cfg := &config{methods: make(map[string]time.Duration)}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return func(next interceptor.Handler) interceptor.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			d := timeout
+			if info := transport.ContextServiceInfo(ctx); info != nil {
+				if t, ok := cfg.methods[info.Service()+"/"+info.Method()]; ok {
+					d = t
+				} else if t, ok := cfg.methods[info.Service()]; ok {
+					d = t
+				}
+			}
+			if d <= 0 {
+				return next(ctx, req)
+			}
+			ctx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+			return next(ctx, req)
+		}
+	}
+}
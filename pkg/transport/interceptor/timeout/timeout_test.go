@@ -0,0 +1,67 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package timeout
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/erda-project/erda-infra/pkg/transport"
+)
+
+func TestTimeout(t *testing.T) {
+	h := Timeout(time.Minute,
+		WithMethod("test.Service/A", time.Second),
+		WithMethod("test.Service", time.Hour),
+		WithMethod("test.Unlimited", 0),
+	)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		deadline, ok := ctx.Deadline()
+		if !ok {
+			return time.Duration(0), nil
+		}
+		return time.Until(deadline), nil
+	})
+	deadlineCtx, cancel := context.WithTimeout(withMethod("test.Service", "A"), 10*time.Millisecond)
+	defer cancel()
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want time.Duration // 0 means no deadline
+	}{
+		{name: "default", ctx: context.Background(), want: time.Minute},
+		{name: "method", ctx: withMethod("test.Service", "A"), want: time.Second},
+		{name: "service", ctx: withMethod("test.Service", "B"), want: time.Hour},
+		{name: "no timeout", ctx: withMethod("test.Unlimited", "A")},
+		{name: "earlier deadline of context", ctx: deadlineCtx, want: 10 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := h(tt.ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := resp.(time.Duration)
+			if got > tt.want || got < tt.want/2 {
+				t.Errorf("Timeout() deadline after %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func withMethod(service, method string) context.Context {
+	return transport.WithServiceInfo(context.Background(), transport.NewServiceInfo(service, method, nil))
+}
+
@@ -0,0 +1,42 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"context"
+
+	transerrors "github.com/erda-project/erda-infra/pkg/transport/errors"
+	"github.com/erda-project/erda-infra/pkg/transport/interceptor"
+)
+
+// Validator is implemented by messages generated with validation rules, e.g. by go-proto-validators.
+type Validator interface {
+	Validate() error
+}
+
+// Validate returns an Interceptor to validate requests which implement Validator,
+// an InvalidArgument error is returned if the validation fails.
+func Validate() interceptor.Interceptor {
+	return func(next interceptor.Handler) interceptor.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if v, ok := req.(Validator); ok {
+				if err := v.Validate(); err != nil {
+					return nil, transerrors.InvalidArgument("%s", err)
+				}
+			}
+			return next(ctx, req)
+		}
+	}
+}
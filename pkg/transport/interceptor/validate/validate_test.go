@@ -0,0 +1,49 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+
+	transerrors "github.com/erda-project/erda-infra/pkg/transport/errors"
+)
+
+type request struct{ err error }
+
+func (r *request) Validate() error { return r.err }
+
+func TestValidate(t *testing.T) {
+	var called int
+	h := Validate()(func(ctx context.Context, req interface{}) (interface{}, error) {
+		called++
+		return "ok", nil
+	})
+	if _, err := h(context.Background(), &request{err: errors.New("name is required")}); transerrors.Code(err) != codes.InvalidArgument {
+		t.Errorf("Validate() error = %v, want InvalidArgument", err)
+	}
+	if called != 0 {
+		t.Errorf("handler is called with invalid request")
+	}
+	if resp, err := h(context.Background(), &request{}); resp != "ok" || err != nil {
+		t.Errorf("Validate() = %v, %v, want ok for valid request", resp, err)
+	}
+	if resp, err := h(context.Background(), "no validator"); resp != "ok" || err != nil {
+		t.Errorf("Validate() = %v, %v, want ok for request without Validate", resp, err)
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// TimeoutHeader is the header to carry the timeout of request, e.g. 1500ms, 2s or 1500 (in milliseconds).
+	TimeoutHeader = "X-Request-Timeout"
+	// GRPCTimeoutHeader is the header used by gRPC to carry the timeout of request, e.g. 1500m.
+	GRPCTimeoutHeader = "Grpc-Timeout"
+)
+
+var (
+	timeoutKey     = strings.ToLower(TimeoutHeader)
+	grpcTimeoutKey = strings.ToLower(GRPCTimeoutHeader)
+)
+
+// ParseTimeout parses the value of TimeoutHeader, it's in milliseconds or a duration string.
+func ParseTimeout(val string) (time.Duration, error) {
+	val = strings.TrimSpace(val)
+	if len(val) <= 0 {
+		return 0, fmt.Errorf("empty timeout")
+	}
+	if ms, err := strconv.ParseInt(val, 10, 64); err == nil {
+		if ms < 0 {
+			return 0, fmt.Errorf("negative timeout %q", val)
+		}
+		return time.Duration(ms) * time.Millisecond, nil
+	}
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timeout %q", val)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("negative timeout %q", val)
+	}
+	return d, nil
+}
+
+// ParseGRPCTimeout parses the value of GRPCTimeoutHeader,
+// see https://github.com/grpc/grpc/blob/master/doc/PROTOCOL-HTTP2.md
+func ParseGRPCTimeout(val string) (time.Duration, bool) {
+	val = strings.TrimSpace(val)
+	if len(val) < 2 || len(val) > 9 {
+		return 0, false
+	}
+	var unit time.Duration
+	switch val[len(val)-1] {
+	case 'H':
+		unit = time.Hour
+	case 'M':
+		unit = time.Minute
+	case 'S':
+		unit = time.Second
+	case 'm':
+		unit = time.Millisecond
+	case 'u':
+		unit = time.Microsecond
+	case 'n':
+		unit = time.Nanosecond
+	default:
+		return 0, false
+	}
+	n, err := strconv.ParseInt(val[:len(val)-1], 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return time.Duration(n) * unit, true
+}
+
+// FormatTimeout formats d as the value of TimeoutHeader in milliseconds.
+func FormatTimeout(d time.Duration) string {
+	ms := int64(d / time.Millisecond)
+	if d > 0 && ms <= 0 {
+		ms = 1
+	}
+	if ms < 0 {
+		ms = 0
+	}
+	return strconv.FormatInt(ms, 10) + "ms"
+}
+
+// HeaderTimeout returns the timeout carried by header.
+func HeaderTimeout(header Header) (time.Duration, bool) {
+	if vals := header.Get(timeoutKey); len(vals) > 0 {
+		if d, err := ParseTimeout(vals[0]); err == nil {
+			return d, true
+		}
+	}
+	if vals := header.Get(grpcTimeoutKey); len(vals) > 0 {
+		return ParseGRPCTimeout(vals[0])
+	}
+	return 0, false
+}
+
+// WithTimeout returns a context with the deadline of the timeout carried by header,
+// the timeout is limited by max if max > 0, the deadline of ctx is kept if it's earlier.
+func WithTimeout(ctx context.Context, header Header, max time.Duration) (context.Context, context.CancelFunc) {
+	timeout, ok := HeaderTimeout(header)
+	if max > 0 && (!ok || timeout > max) {
+		timeout, ok = max, true
+	}
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// WithHTTPTimeout is the same as WithTimeout, but reads timeout from http header.
+func WithHTTPTimeout(ctx context.Context, header http.Header, max time.Duration) (context.Context, context.CancelFunc) {
+	md := metadata.MD{}
+	for _, key := range []string{TimeoutHeader, GRPCTimeoutHeader} {
+		if vals := header.Values(key); len(vals) > 0 {
+			md[strings.ToLower(key)] = vals
+		}
+	}
+	return WithTimeout(ctx, md, max)
+}
+
+// WithDeadlineHeader sets TimeoutHeader of outgoing header to the remaining time of ctx.
+func WithDeadlineHeader(ctx context.Context) context.Context {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return ctx
+	}
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+	md.Set(timeoutKey, FormatTimeout(time.Until(deadline)))
+	return metadata.NewOutgoingContext(ctx, md)
+}
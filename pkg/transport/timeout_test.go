@@ -0,0 +1,113 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transport
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+)
+
+func TestHeaderTimeout(t *testing.T) {
+	tests := []struct {
+		name   string
+		header Header
+		want   time.Duration
+		wantOk bool
+	}{
+		{
+			name:   "milliseconds",
+			header: metadata.Pairs(TimeoutHeader, "1500"),
+			want:   1500 * time.Millisecond,
+			wantOk: true,
+		},
+		{
+			name:   "duration",
+			header: metadata.Pairs(TimeoutHeader, "2s"),
+			want:   2 * time.Second,
+			wantOk: true,
+		},
+		{
+			name:   "grpc",
+			header: metadata.Pairs(GRPCTimeoutHeader, "300m"),
+			want:   300 * time.Millisecond,
+			wantOk: true,
+		},
+		{
+			name:   "grpc minute",
+			header: metadata.Pairs(GRPCTimeoutHeader, "1M"),
+			want:   time.Minute,
+			wantOk: true,
+		},
+		{
+			name:   "invalid",
+			header: metadata.Pairs(TimeoutHeader, "-1s"),
+		},
+		{
+			name:   "none",
+			header: metadata.MD{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := HeaderTimeout(tt.header)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("HeaderTimeout() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	ctx, cancel := WithTimeout(context.Background(), metadata.Pairs(TimeoutHeader, "1h"), time.Second)
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	if !ok || time.Until(deadline) > time.Second {
+		t.Errorf("WithTimeout() deadline should be limited by max")
+	}
+
+	ctx, cancel = WithTimeout(context.Background(), metadata.MD{}, 0)
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Errorf("WithTimeout() should not set deadline")
+	}
+}
+
+func TestWithHeader(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	ctx = WithHeader(ctx, metadata.Pairs("a", "b", TimeoutHeader, "1h"))
+	md, _ := metadata.FromOutgoingContext(ctx)
+	if got := md.Get("a"); len(got) != 1 || got[0] != "b" {
+		t.Errorf("WithHeader() header a = %v, want [b]", got)
+	}
+	vals := md.Get(TimeoutHeader)
+	if len(vals) != 1 {
+		t.Fatalf("WithHeader() timeout header = %v", vals)
+	}
+	d, err := ParseTimeout(vals[0])
+	if err != nil || d > time.Minute || d <= 0 {
+		t.Errorf("WithHeader() timeout = %v, %v, want remaining time of ctx", d, err)
+	}
+
+	// the timeout of caller is kept if ctx has no deadline
+	ctx = WithHeader(context.Background(), metadata.Pairs(TimeoutHeader, "1h"))
+	md, _ = metadata.FromOutgoingContext(ctx)
+	if got := md.Get(TimeoutHeader); len(got) != 1 || got[0] != "1h" {
+		t.Errorf("WithHeader() without deadline, timeout header = %v, want [1h]", got)
+	}
+}
@@ -118,12 +118,17 @@ func (si *serviceInfo) Instance() interface{} { return si.instance }
 // Header .
 type Header = metadata.MD
 
-// WithHeader setup header for caller
+// WithHeader setup header for caller, timeout headers are replaced by the remaining time of ctx if it has a deadline.
 func WithHeader(ctx context.Context, header Header) context.Context {
 	if len(header) <= 0 {
-		return ctx
+		return WithDeadlineHeader(ctx)
+	}
+	if _, ok := ctx.Deadline(); ok && (len(header.Get(timeoutKey)) > 0 || len(header.Get(grpcTimeoutKey)) > 0) {
+		header = header.Copy()
+		delete(header, timeoutKey)
+		delete(header, grpcTimeoutKey)
 	}
-	return metadata.NewOutgoingContext(ctx, header)
+	return WithDeadlineHeader(metadata.NewOutgoingContext(ctx, header))
 }
 
 // ContextHeader get header in server
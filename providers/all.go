@@ -15,6 +15,7 @@
 package providers
 
 import (
+	_ "github.com/erda-project/erda-infra/providers/auth"                  //
 	_ "github.com/erda-project/erda-infra/providers/cassandra"             //
 	_ "github.com/erda-project/erda-infra/providers/clickhouse"            //
 	_ "github.com/erda-project/erda-infra/providers/elasticsearch"         //
@@ -37,6 +38,7 @@ import (
 	_ "github.com/erda-project/erda-infra/providers/remote-forward/client" //
 	_ "github.com/erda-project/erda-infra/providers/remote-forward/server" //
 	_ "github.com/erda-project/erda-infra/providers/serviceregister"       //
+	_ "github.com/erda-project/erda-infra/providers/trace"                 //
 	// _ "github.com/erda-project/erda-infra/providers/zk-master-election"   //
 	// _ "github.com/erda-project/erda-infra/providers/zookeeper"            //
 	// _ "github.com/erda-project/erda-infra/providers/legacy/httpendpoints" //
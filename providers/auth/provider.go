@@ -0,0 +1,239 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/labstack/echo"
+
+	"github.com/erda-project/erda-infra/base/logs"
+	"github.com/erda-project/erda-infra/base/servicehub"
+	pkgauth "github.com/erda-project/erda-infra/pkg/auth"
+	"github.com/erda-project/erda-infra/pkg/transport/interceptor"
+	"github.com/erda-project/erda-infra/providers/httpserver/server"
+)
+
+// Interface .
+type Interface interface {
+	pkgauth.Authenticator
+	// Route returns a route option of httpserver.Router to authenticate requests and require scopes,
+	// the principal is set to the context of request and to echo context with key PrincipalKey.
+	Route(scopes ...string) server.MiddlewareFunc
+	// Interceptor returns an Interceptor for transport.WithInterceptors to authenticate requests of services.
+	Interceptor(opts ...pkgauth.Option) interceptor.Interceptor
+}
+
+// PrincipalKey is the key of principal in echo context, e.g. used by ratelimit.ByContextValue.
+const PrincipalKey = "principal"
+
+type config struct {
+	JWT struct {
+		SecretFile          string        `file:"secret_file" env:"AUTH_JWT_SECRET_FILE" desc:"file of HMAC secret"`
+		KeyFiles            []string      `file:"key_files" desc:"PEM files of public keys or certificates"`
+		JWKSURL             string        `file:"jwks_url" env:"AUTH_JWT_JWKS_URL" desc:"url of JWKS"`
+		OIDCDiscovery       bool          `file:"oidc_discovery" desc:"discover jwks_url by the openid configuration of issuer"`
+		JWKSRefreshInterval time.Duration `file:"jwks_refresh_interval" default:"10m" desc:"interval to refresh JWKS"`
+		Issuer              string        `file:"issuer" env:"AUTH_JWT_ISSUER" desc:"expected issuer of tokens"`
+		Audience            []string      `file:"audience" desc:"accepted audience of tokens"`
+		Algorithms          []string      `file:"algorithms" desc:"accepted algorithms, e.g. RS256, ES256, HS256"`
+		Leeway              time.Duration `file:"leeway" default:"1m" desc:"allowed clock skew"`
+		SubjectClaim        string        `file:"subject_claim" default:"sub"`
+		ScopesClaim         string        `file:"scopes_claim" default:"scope"`
+		RolesClaim          string        `file:"roles_claim" default:"roles"`
+	} `file:"jwt"`
+	APIKey struct {
+		Header string `file:"header" default:"X-API-Key" desc:"header of API key"`
+		Keys   []struct {
+			Name   string   `file:"name"`
+			Key    string   `file:"key" desc:"API key in plain text"`
+			Hash   string   `file:"hash" desc:"hex encoded sha256 digest of API key"`
+			Scopes []string `file:"scopes"`
+			Roles  []string `file:"roles"`
+		} `file:"keys"`
+	} `file:"api_key"`
+	MTLS struct {
+		Enable bool                `file:"enable" env:"AUTH_MTLS_ENABLE" desc:"authenticate by client certificates verified by TLS"`
+		Scopes map[string][]string `file:"scopes" desc:"scopes granted by certificate subject, a URI SAN or common name"`
+	} `file:"mtls"`
+}
+
+type provider struct {
+	Cfg   *config
+	Log   logs.Logger
+	authn pkgauth.Authenticator
+}
+
+func (p *provider) Init(ctx servicehub.Context) error {
+	var list []pkgauth.Authenticator
+	jwt, err := p.jwtAuthenticator()
+	if err != nil {
+		return err
+	}
+	if jwt != nil {
+		list = append(list, jwt)
+	}
+	if len(p.Cfg.APIKey.Keys) > 0 {
+		keys := make([]pkgauth.APIKey, 0, len(p.Cfg.APIKey.Keys))
+		for _, k := range p.Cfg.APIKey.Keys {
+			keys = append(keys, pkgauth.APIKey{Name: k.Name, Key: k.Key, Hash: k.Hash, Scopes: k.Scopes, Roles: k.Roles})
+		}
+		a, err := pkgauth.NewAPIKeyAuthenticator(p.Cfg.APIKey.Header, keys)
+		if err != nil {
+			return err
+		}
+		list = append(list, a)
+	}
+	if p.Cfg.MTLS.Enable {
+		list = append(list, pkgauth.NewMTLSAuthenticator(p.Cfg.MTLS.Scopes))
+	}
+	if len(list) <= 0 {
+		p.Log.Warnf("no authentication method is configured, all requests with required authentication are rejected")
+	}
+	p.authn = pkgauth.Chain(list...)
+	return nil
+}
+
+func (p *provider) jwtAuthenticator() (pkgauth.Authenticator, error) {
+	cfg := &p.Cfg.JWT
+	var keys pkgauth.StaticKeys
+	if len(cfg.SecretFile) > 0 {
+		key, err := pkgauth.LoadKeyFile(cfg.SecretFile, "")
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := key.Key.([]byte); !ok {
+			return nil, fmt.Errorf("secret_file %q is a public key, use key_files instead", cfg.SecretFile)
+		}
+		keys = append(keys, key)
+	}
+	for _, file := range cfg.KeyFiles {
+		key, err := pkgauth.LoadKeyFile(file, "")
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := key.Key.([]byte); ok {
+			return nil, fmt.Errorf("key file %q is not a PEM public key", file)
+		}
+		keys = append(keys, key)
+	}
+	jwksURL := cfg.JWKSURL
+	if len(jwksURL) <= 0 && cfg.OIDCDiscovery {
+		if len(cfg.Issuer) <= 0 {
+			return nil, errors.New("issuer is required for oidc discovery")
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		url, err := pkgauth.DiscoverJWKS(ctx, &http.Client{}, cfg.Issuer)
+		if err != nil {
+			return nil, err
+		}
+		jwksURL = url
+	}
+	var source pkgauth.KeySource = keys
+	if len(jwksURL) > 0 {
+		jwks := pkgauth.NewJWKS(jwksURL, pkgauth.WithRefreshInterval(cfg.JWKSRefreshInterval))
+		if len(keys) > 0 {
+			source = multiKeySource{keys, jwks}
+		} else {
+			source = jwks
+		}
+	} else if len(keys) <= 0 {
+		return nil, nil
+	}
+	return pkgauth.NewJWTAuthenticator(source, pkgauth.JWTOptions{
+		Issuer:       cfg.Issuer,
+		Audience:     cfg.Audience,
+		Algorithms:   cfg.Algorithms,
+		Leeway:       cfg.Leeway,
+		SubjectClaim: cfg.SubjectClaim,
+		ScopesClaim:  cfg.ScopesClaim,
+		RolesClaim:   cfg.RolesClaim,
+	}), nil
+}
+
+type multiKeySource []pkgauth.KeySource
+
+func (s multiKeySource) Keys(ctx context.Context, kid string) ([]*pkgauth.Key, error) {
+	var keys []*pkgauth.Key
+	var lastErr error
+	for _, source := range s {
+		list, err := source.Keys(ctx, kid)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		keys = append(keys, list...)
+	}
+	if len(keys) <= 0 {
+		return nil, lastErr
+	}
+	return keys, nil
+}
+
+// Authenticate .
+func (p *provider) Authenticate(ctx context.Context, req *pkgauth.Request) (*pkgauth.Principal, error) {
+	return p.authn.Authenticate(ctx, req)
+}
+
+// Route .
+func (p *provider) Route(scopes ...string) server.MiddlewareFunc {
+	return func(next server.HandlerFunc) server.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			principal := pkgauth.ContextPrincipal(req.Context())
+			if principal == nil {
+				var err error
+				principal, err = p.authn.Authenticate(req.Context(), pkgauth.NewHTTPRequest(req))
+				if err != nil {
+					c.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+					if errors.Is(err, pkgauth.ErrNoCredentials) || errors.Is(err, pkgauth.ErrInvalidCredentials) {
+						return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+					}
+					p.Log.Errorf("fail to authenticate request: %s", err)
+					return echo.NewHTTPError(http.StatusServiceUnavailable, "authentication unavailable")
+				}
+				c.SetRequest(req.WithContext(pkgauth.WithPrincipal(req.Context(), principal)))
+			}
+			c.Set(PrincipalKey, principal)
+			if !principal.HasScopes(scopes...) {
+				return echo.NewHTTPError(http.StatusForbidden, "permission denied")
+			}
+			return next(c)
+		}
+	}
+}
+
+// Interceptor .
+func (p *provider) Interceptor(opts ...pkgauth.Option) interceptor.Interceptor {
+	return pkgauth.Interceptor(p.authn, opts...)
+}
+
+func init() {
+	servicehub.Register("auth", &servicehub.Spec{
+		Services:    []string{"auth"},
+		Types:       []reflect.Type{reflect.TypeOf((*Interface)(nil)).Elem()},
+		Description: "authenticate requests by JWT, API key or client certificate",
+		ConfigFunc:  func() interface{} { return &config{} },
+		Creator: func() servicehub.Provider {
+			return &provider{}
+		},
+	})
+}
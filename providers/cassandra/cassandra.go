@@ -24,6 +24,8 @@ import (
 	"github.com/erda-project/erda-infra/base/servicehub"
 	writer "github.com/erda-project/erda-infra/pkg/parallel-writer"
 	"github.com/gocql/gocql"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // WriterConfig .
@@ -74,14 +76,22 @@ type config struct {
 
 // provider .
 type provider struct {
-	Cfg   *config
-	Log   logs.Logger
-	hosts []string
+	Cfg      *config
+	Log      logs.Logger
+	Metrics  prometheus.Registerer `autowired:"metrics" optional:"true"`
+	Tracer   trace.TracerProvider  `autowired:"tracer-provider" optional:"true"`
+	hosts    []string
+	observer *queryObserver
+	metrics  *writer.Metrics
 }
 
 // Init .
 func (p *provider) Init(ctx servicehub.Context) error {
 	p.hosts = strings.Split(p.Cfg.Hosts, ",")
+	if p.Metrics != nil {
+		p.observer = newQueryObserver(p.Metrics)
+		p.metrics = writer.NewMetrics(p.Metrics)
+	}
 	return nil
 }
 
@@ -94,14 +104,19 @@ func (p *provider) newSession(keyspace, consistency string) (*gocql.Session, err
 	cluster.Keyspace = keyspace
 	cluster.Timeout = p.Cfg.Timeout
 	cluster.ConnectTimeout = p.Cfg.Timeout
+	if p.observer != nil {
+		cluster.QueryObserver = p.observer
+		cluster.BatchObserver = p.observer
+	}
 	return cluster.CreateSession()
 }
 
 // Provide .
 func (p *provider) Provide(ctx servicehub.DependencyContext, args ...interface{}) interface{} {
 	return &service{
-		p:   p,
-		log: p.Log.Sub(ctx.Caller()),
+		p:    p,
+		log:  p.Log.Sub(ctx.Caller()),
+		name: ctx.Caller(),
 	}
 }
 
@@ -182,8 +197,9 @@ func (s *service) NewBatchWriter(session *Session, c *WriterConfig, builderCreat
 			retryDuration:  3 * time.Second,
 			log:            s.log,
 			batchSizeBytes: c.Batch.SizeBytes,
+			tracer:         s.p.Tracer,
 		}
-	}, c.Parallelism, c.Batch.Size, c.Batch.Timeout, s.batchWriteError)
+	}, c.Parallelism, c.Batch.Size, c.Batch.Timeout, s.batchWriteError, writer.WithMetrics(s.p.metrics, s.name))
 }
 
 func (s *service) batchWriteError(err error) error {
@@ -0,0 +1,55 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cassandra
+
+import (
+	"context"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/prometheus/client_golang/prometheus"
+
+	pkgmetrics "github.com/erda-project/erda-infra/pkg/metrics"
+)
+
+// queryObserver records latency of queries and batches.
+type queryObserver struct {
+	duration *prometheus.HistogramVec
+}
+
+func newQueryObserver(r prometheus.Registerer) *queryObserver {
+	return &queryObserver{
+		duration: pkgmetrics.Register(r, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "query_duration_seconds",
+			Help: "Latency of queries and batches.",
+		}, []string{"keyspace", "type", "status"})).(*prometheus.HistogramVec),
+	}
+}
+
+func (o *queryObserver) ObserveQuery(ctx context.Context, q gocql.ObservedQuery) {
+	o.observe(q.Keyspace, "query", q.End.Sub(q.Start), q.Err)
+}
+
+func (o *queryObserver) ObserveBatch(ctx context.Context, b gocql.ObservedBatch) {
+	o.observe(b.Keyspace, "batch", b.End.Sub(b.Start), b.Err)
+}
+
+func (o *queryObserver) observe(keyspace, typ string, d time.Duration, err error) {
+	status := "ok"
+	if err != nil {
+		status = "error"
+	}
+	o.duration.WithLabelValues(keyspace, typ, status).Observe(d.Seconds())
+}
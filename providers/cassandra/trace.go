@@ -0,0 +1,83 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cassandra
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+
+	tracebatch "github.com/erda-project/erda-infra/pkg/trace/instrument/batch"
+)
+
+// ContextCarrier is implemented by items carrying the span of caller, the span of batch is linked to it.
+type ContextCarrier interface {
+	Context() context.Context
+}
+
+var tableRegexp = regexp.MustCompile(`(?i)^\s*(?:insert\s+into|update|delete\s+from)\s+([\w."]+)`)
+
+// tableOf returns the table of statement, or empty if it's unknown.
+func tableOf(stmt string) string {
+	m := tableRegexp.FindStringSubmatch(stmt)
+	if len(m) < 2 {
+		return ""
+	}
+	return strings.ReplaceAll(m[1], `"`, "")
+}
+
+// batchSpan collects the tables, size and caller spans of a batch.
+type batchSpan struct {
+	tables map[string]struct{}
+	bytes  int
+	links  []trace.SpanContext
+}
+
+func (b *batchSpan) add(item interface{}, stmt string, args []interface{}) {
+	if b.tables == nil {
+		b.tables = make(map[string]struct{})
+	}
+	if table := tableOf(stmt); table != "" {
+		b.tables[table] = struct{}{}
+	}
+	b.bytes += cqlSizeBytes(stmt, args)
+	if c, ok := item.(ContextCarrier); ok && c.Context() != nil {
+		b.links = append(b.links, trace.SpanContextFromContext(c.Context()))
+	}
+}
+
+func (b *batchSpan) start(ctx context.Context, tp trace.TracerProvider, items int) (context.Context, *tracebatch.Span) {
+	tables := make([]string, 0, len(b.tables))
+	for table := range b.tables {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	name := "cassandra batch"
+	if len(tables) == 1 {
+		name += " " + tables[0]
+	}
+	ctx, span := tracebatch.Start(ctx, tp, name, items, b.links,
+		semconv.DBSystemCassandra,
+		semconv.DBOperation("batch"),
+		attribute.Key("db.cassandra.tables").StringSlice(tables),
+	)
+	span.AddBytes(int64(b.bytes))
+	return ctx, span
+}
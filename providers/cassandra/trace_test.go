@@ -0,0 +1,39 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cassandra
+
+import (
+	"testing"
+)
+
+func Test_tableOf(t *testing.T) {
+	tests := []struct {
+		stmt string
+		want string
+	}{
+		{stmt: "INSERT INTO spans (id, name) VALUES (?, ?)", want: "spans"},
+		{stmt: "  insert into ks.spans(id) values (?)", want: "ks.spans"},
+		{stmt: `UPDATE "ks"."logs" SET a = ? WHERE id = ?`, want: "ks.logs"},
+		{stmt: "DELETE FROM metrics WHERE id = ?", want: "metrics"},
+		{stmt: "SELECT * FROM metrics", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.stmt, func(t *testing.T) {
+			if got := tableOf(tt.stmt); got != tt.want {
+				t.Errorf("tableOf() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -15,10 +15,12 @@
 package cassandra
 
 import (
+	"context"
 	"time"
 
 	"github.com/erda-project/erda-infra/base/logs"
 	"github.com/gocql/gocql"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // StatementBuilder .
@@ -33,6 +35,7 @@ type batchWriter struct {
 	retryDuration  time.Duration
 	log            logs.Logger
 	batchSizeBytes int
+	tracer         trace.TracerProvider
 }
 
 func (w *batchWriter) Write(data interface{}) error {
@@ -45,9 +48,11 @@ func (w *batchWriter) WriteN(data ...interface{}) (int, error) {
 		return 0, nil
 	}
 	batchs := make([]*gocql.Batch, 0, 1)
+	spans := make([]*batchSpan, 0, 1)
 	sizeBytes := 0
 
 	batch := w.session.Session().NewBatch(gocql.LoggedBatch)
+	bspan := &batchSpan{}
 	for _, item := range data {
 		stmt, args, err := w.builder.GetStatement(item)
 		if err != nil {
@@ -58,38 +63,48 @@ func (w *batchWriter) WriteN(data ...interface{}) (int, error) {
 			sizeBytes += cqlSizeBytes(stmt, args)
 			if sizeBytes >= w.batchSizeBytes {
 				batchs = append(batchs, batch)
+				spans = append(spans, bspan)
 				// reset
 				sizeBytes = 0
 				batch = w.session.Session().NewBatch(gocql.LoggedBatch)
+				bspan = &batchSpan{}
 			}
 
 		}
 		batch.Query(stmt, args...)
+		bspan.add(item, stmt, args)
 	}
 
 	if batch.Size() > 0 {
 		batchs = append(batchs, batch)
+		spans = append(spans, bspan)
 	}
 
-	for _, batch := range batchs {
-		for i := 0; ; i++ {
-			err := w.session.Session().ExecuteBatch(batch)
-			if err != nil {
-				if w.retry == -1 || i < w.retry {
-					w.log.Warnf("fail to write batch(%d) to cassandra and retry after %s: %s", batch.Size(), w.retryDuration.String(), err)
-					time.Sleep(w.retryDuration)
-					continue
-				}
-				w.log.Errorf("fail to write batch(%d) to cassandra: %s", batch.Size(), err)
-				break
-			}
-			break
-		}
+	for idx, batch := range batchs {
+		ctx, span := spans[idx].start(context.Background(), w.tracer, batch.Size())
+		err := w.executeBatch(batch.WithContext(ctx))
+		span.Finish(err)
 	}
 
 	return batch.Size(), nil
 }
 
+func (w *batchWriter) executeBatch(batch *gocql.Batch) error {
+	for i := 0; ; i++ {
+		err := w.session.Session().ExecuteBatch(batch)
+		if err != nil {
+			if w.retry == -1 || i < w.retry {
+				w.log.Warnf("fail to write batch(%d) to cassandra and retry after %s: %s", batch.Size(), w.retryDuration.String(), err)
+				time.Sleep(w.retryDuration)
+				continue
+			}
+			w.log.Errorf("fail to write batch(%d) to cassandra: %s", batch.Size(), err)
+			return err
+		}
+		return nil
+	}
+}
+
 func cqlSizeBytes(stmt string, args []interface{}) int {
 	size := len(stmt)
 	for _, item := range args {
@@ -22,9 +22,12 @@ import (
 
 	ck "github.com/ClickHouse/clickhouse-go/v2"
 	ckdriver "github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/erda-project/erda-infra/base/logs"
 	"github.com/erda-project/erda-infra/base/servicehub"
+	writer "github.com/erda-project/erda-infra/pkg/parallel-writer"
 )
 
 // Interface clickhouse client
@@ -56,10 +59,13 @@ type config struct {
 }
 
 type provider struct {
-	Cfg *config
-	Log logs.Logger
+	Cfg     *config
+	Log     logs.Logger
+	Metrics prometheus.Registerer `autowired:"metrics" optional:"true"`
+	Tracer  trace.TracerProvider  `autowired:"tracer-provider" optional:"true"`
 
-	nativeConn ckdriver.Conn
+	nativeConn    ckdriver.Conn
+	writerMetrics *writer.Metrics
 }
 
 func (p *provider) Init(ctx servicehub.Context) error {
@@ -91,6 +97,12 @@ func (p *provider) Init(ctx servicehub.Context) error {
 		return fmt.Errorf("fail to connect clickhouse: %s", err)
 	}
 	p.nativeConn = conn
+	if p.Metrics != nil {
+		if err := p.Metrics.Register(newConnCollector(conn)); err != nil {
+			return fmt.Errorf("fail to register clickhouse metrics: %s", err)
+		}
+		p.writerMetrics = writer.NewMetrics(p.Metrics)
+	}
 
 	return nil
 }
@@ -99,17 +111,29 @@ func (p *provider) Client() ckdriver.Conn {
 	return p.nativeConn
 }
 
-func (p *provider) NewWriter(opts *WriterOptions) *Writer {
-	return NewWriter(p.nativeConn, opts.Encoder)
+func (p *provider) newWriter(opts *WriterOptions, name string) *Writer {
+	w := NewWriter(p.nativeConn, opts.Encoder)
+	w.metrics, w.name, w.tracer = p.writerMetrics, name, p.Tracer
+	return w
 }
 
 func (p *provider) Provide(ctx servicehub.DependencyContext, args ...interface{}) interface{} {
 	if ctx.Service() == "clickhouse-client" || ctx.Type() == nativeConnType {
 		return p.nativeConn
 	}
-	return p
+	return &service{p: p, name: ctx.Caller()}
+}
+
+type service struct {
+	p    *provider
+	name string
 }
 
+func (s *service) Client() ckdriver.Conn { return s.p.nativeConn }
+
+// NewWriter creates a writer whose metrics are labelled by the name of caller.
+func (s *service) NewWriter(opts *WriterOptions) *Writer { return s.p.newWriter(opts, s.name) }
+
 func init() {
 	servicehub.Register("clickhouse", &servicehub.Spec{
 		Services:    []string{"clickhouse", "clickhouse-client"},
@@ -0,0 +1,56 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clickhouse
+
+import (
+	ckdriver "github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// connCollector collects connection pool stats of clickhouse.
+type connCollector struct {
+	conn         ckdriver.Conn
+	open         *prometheus.Desc
+	idle         *prometheus.Desc
+	maxOpenConns *prometheus.Desc
+	maxIdleConns *prometheus.Desc
+}
+
+func newConnCollector(conn ckdriver.Conn) *connCollector {
+	return &connCollector{
+		conn:         conn,
+		open:         prometheus.NewDesc("pool_open_connections", "Number of established connections.", nil, nil),
+		idle:         prometheus.NewDesc("pool_idle_connections", "Number of idle connections.", nil, nil),
+		maxOpenConns: prometheus.NewDesc("pool_max_open_connections", "Maximum number of open connections.", nil, nil),
+		maxIdleConns: prometheus.NewDesc("pool_max_idle_connections", "Maximum number of idle connections.", nil, nil),
+	}
+}
+
+// Describe .
+func (c *connCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.open
+	ch <- c.idle
+	ch <- c.maxOpenConns
+	ch <- c.maxIdleConns
+}
+
+// Collect .
+func (c *connCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.conn.Stats()
+	ch <- prometheus.MustNewConstMetric(c.open, prometheus.GaugeValue, float64(stats.Open))
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(stats.Idle))
+	ch <- prometheus.MustNewConstMetric(c.maxOpenConns, prometheus.GaugeValue, float64(stats.MaxOpenConns))
+	ch <- prometheus.MustNewConstMetric(c.maxIdleConns, prometheus.GaugeValue, float64(stats.MaxIdleConns))
+}
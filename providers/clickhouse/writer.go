@@ -17,8 +17,14 @@ package clickhouse
 import (
 	"context"
 	"fmt"
+	"time"
 
 	ckdriver "github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+
+	writer "github.com/erda-project/erda-infra/pkg/parallel-writer"
+	tracebatch "github.com/erda-project/erda-infra/pkg/trace/instrument/batch"
 )
 
 // EncodeFunc .
@@ -39,6 +45,9 @@ type WriterOptions struct {
 type Writer struct {
 	client  ckdriver.Conn
 	Encoder EncodeFunc
+	metrics *writer.Metrics
+	name    string
+	tracer  trace.TracerProvider
 }
 
 // NewWriter .
@@ -57,6 +66,11 @@ func (w *Writer) Close() error {
 
 // WriteN .
 func (w *Writer) WriteN(list ...interface{}) (int, error) {
+	return w.WriteNContext(context.Background(), list...)
+}
+
+// WriteNContext writes list in batches per table within ctx, the spans of batches are children of the span in ctx.
+func (w *Writer) WriteNContext(ctx context.Context, list ...interface{}) (int, error) {
 	if len(list) <= 0 {
 		return 0, nil
 	}
@@ -73,18 +87,9 @@ func (w *Writer) WriteN(list ...interface{}) (int, error) {
 
 	succ := 0
 	for table, tItems := range items {
-		batch, err := w.client.PrepareBatch(context.Background(), fmt.Sprintf("insert into %s", table))
-		if err != nil {
-			return succ, err
-		}
-		for _, item := range tItems {
-			err = batch.AppendStruct(item.Data)
-			if err != nil {
-				_ = batch.Abort()
-				return succ, err
-			}
-		}
-		err = batch.Send()
+		start := time.Now()
+		err := w.writeTable(ctx, table, tItems)
+		w.metrics.ObserveFlush(w.name, len(tItems), time.Since(start), err)
 		if err != nil {
 			return succ, err
 		}
@@ -93,3 +98,24 @@ func (w *Writer) WriteN(list ...interface{}) (int, error) {
 
 	return succ, nil
 }
+
+func (w *Writer) writeTable(ctx context.Context, table string, items []*WriteItem) (err error) {
+	ctx, span := tracebatch.Start(ctx, w.tracer, "insert "+table, len(items), nil,
+		semconv.DBSystemClickhouse,
+		semconv.DBOperation("insert"),
+		semconv.DBSQLTable(table),
+	)
+	defer func() { span.Finish(err) }()
+	batch, err := w.client.PrepareBatch(ctx, fmt.Sprintf("insert into %s", table))
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		err = batch.AppendStruct(item.Data)
+		if err != nil {
+			_ = batch.Abort()
+			return err
+		}
+	}
+	return batch.Send()
+}
@@ -6,6 +6,8 @@ package client
 import (
 	context "context"
 
+	transport "github.com/erda-project/erda-infra/pkg/transport"
+	errors "github.com/erda-project/erda-infra/pkg/transport/errors"
 	grpc "github.com/erda-project/erda-infra/pkg/transport/grpc"
 	pb "github.com/erda-project/erda-infra/providers/component-protocol/protobuf/proto-go/cp/pb"
 	grpc1 "google.golang.org/grpc"
@@ -38,5 +40,10 @@ type cpserviceWrapper struct {
 }
 
 func (s *cpserviceWrapper) Render(ctx context.Context, req *pb.RenderRequest) (*pb.RenderResponse, error) {
-	return s.client.Render(ctx, req, append(grpc.CallOptionFromContext(ctx), s.opts...)...)
+	ctx = transport.WithDeadlineHeader(ctx)
+	resp, err := s.client.Render(ctx, req, append(grpc.CallOptionFromContext(ctx), s.opts...)...)
+	if err != nil {
+		return nil, errors.FromError(err)
+	}
+	return resp, nil
 }
@@ -24,6 +24,7 @@ import (
 	"github.com/erda-project/erda-infra/base/logs"
 	"github.com/erda-project/erda-infra/base/servicehub"
 	"github.com/erda-project/erda-infra/pkg/transport"
+	transerrors "github.com/erda-project/erda-infra/pkg/transport/errors"
 	transhttp "github.com/erda-project/erda-infra/pkg/transport/http"
 	"github.com/erda-project/erda-infra/providers/component-protocol/protobuf/proto-go/cp/pb"
 	"github.com/erda-project/erda-infra/providers/i18n"
@@ -59,6 +60,8 @@ func (p *provider) Init(ctx servicehub.Context) error {
 }
 
 // Error .
+//
+// Deprecated: errors are converted by transerrors.FromError, which understands this interface.
 type Error interface {
 	HTTPStatus() int
 }
@@ -99,19 +102,16 @@ func encoder(rw http.ResponseWriter, r *http.Request, obj interface{}) error {
 }
 
 func errorEncoder(rw http.ResponseWriter, request *http.Request, err error) {
-	var status int
-	if e, ok := err.(Error); ok {
-		status = e.HTTPStatus()
-	} else {
-		status = http.StatusInternalServerError
-	}
+	e := transerrors.FromError(err)
+	status := e.HTTPStatus()
 	rw.Header().Set("Content-Type", "application/json")
 	rw.WriteHeader(status)
 	byts, _ := jsi.Marshal(map[string]interface{}{
 		"success": false,
 		"err": map[string]interface{}{
 			"code": status,
-			"msg":  err.Error(),
+			"msg":  e.Message,
+			"ctx":  e.Metadata,
 		},
 	})
 	rw.Write(byts)
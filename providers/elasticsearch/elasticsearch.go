@@ -24,6 +24,8 @@ import (
 	"github.com/erda-project/erda-infra/base/servicehub"
 	writer "github.com/erda-project/erda-infra/pkg/parallel-writer"
 	"github.com/olivere/elastic"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Interface .
@@ -77,9 +79,12 @@ type config struct {
 
 // provider .
 type provider struct {
-	Cfg    *config
-	Log    logs.Logger
-	client *elastic.Client
+	Cfg     *config
+	Log     logs.Logger
+	Metrics prometheus.Registerer `autowired:"metrics" optional:"true"`
+	Tracer  trace.TracerProvider  `autowired:"tracer-provider" optional:"true"`
+	client  *elastic.Client
+	metrics *writer.Metrics
 }
 
 // Init .
@@ -96,6 +101,9 @@ func (p *provider) Init(ctx servicehub.Context) error {
 		return fmt.Errorf("failed to create elasticsearch client: %s", err)
 	}
 	p.client = client
+	if p.Metrics != nil {
+		p.metrics = writer.NewMetrics(p.Metrics)
+	}
 	return nil
 }
 
@@ -105,14 +113,16 @@ func (p *provider) Provide(ctx servicehub.DependencyContext, args ...interface{}
 		return p.client
 	}
 	return &service{
-		p:   p,
-		log: p.Log.Sub(ctx.Caller()),
+		p:    p,
+		log:  p.Log.Sub(ctx.Caller()),
+		name: ctx.Caller(),
 	}
 }
 
 type service struct {
-	p   *provider
-	log logs.Logger
+	p    *provider
+	log  logs.Logger
+	name string
 }
 
 func (s *service) Client() *elastic.Client { return s.p.client }
@@ -134,8 +144,9 @@ func (s *service) NewBatchWriterWithOptions(c *WriterConfig, opts ...BatchWriteO
 			retry:         c.Retry,
 			retryDuration: 3 * time.Second,
 			timeout:       fmt.Sprintf("%dms", c.Batch.Timeout.Milliseconds()),
+			tracer:        s.p.Tracer,
 		}
-	}, c.Parallelism, c.Batch.Size, c.Batch.Timeout, options.ErrorHandler)
+	}, c.Parallelism, c.Batch.Size, c.Batch.Timeout, options.ErrorHandler, writer.WithMetrics(s.p.metrics, s.name))
 }
 
 func (s *service) NewBatchWriter(c *WriterConfig) writer.Writer {
@@ -165,7 +176,9 @@ func (s *service) NewWriter(opts *WriteOptions) *Writer {
 	if timeout <= 0 {
 		timeout = 30 * time.Second
 	}
-	return NewWriter(s.p.client, timeout, opts.Enc)
+	w := NewWriter(s.p.client, timeout, opts.Enc)
+	w.metrics, w.name, w.tracer = s.p.metrics, s.name, s.p.Tracer
+	return w
 }
 
 func init() {
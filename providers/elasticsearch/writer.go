@@ -20,19 +20,43 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/erda-project/erda-infra/base/logs"
+	writer "github.com/erda-project/erda-infra/pkg/parallel-writer"
+	tracebatch "github.com/erda-project/erda-infra/pkg/trace/instrument/batch"
 	"github.com/olivere/elastic"
 	"github.com/recallsong/go-utils/reflectx"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var indicesKey = attribute.Key("db.elasticsearch.indices")
+
+// startBulk starts the span of a bulk request to indices.
+func startBulk(ctx context.Context, tp trace.TracerProvider, items int, indices map[string]struct{}, links []trace.SpanContext) (context.Context, *tracebatch.Span) {
+	names := make([]string, 0, len(indices))
+	for index := range indices {
+		names = append(names, index)
+	}
+	sort.Strings(names)
+	return tracebatch.Start(ctx, tp, "elasticsearch bulk", items, links,
+		semconv.DBSystemElasticsearch,
+		semconv.DBOperation("bulk"),
+		indicesKey.StringSlice(names),
+	)
+}
+
 // Document .
 type Document struct {
 	ID    string      `json:"id"`
 	Index string      `json:"index"`
 	Data  interface{} `json:"data"`
+	// Context carries the span of caller, the span of bulk request is linked to it.
+	Context context.Context `json:"-"`
 }
 
 type batchWriter struct {
@@ -42,6 +66,7 @@ type batchWriter struct {
 	retry         int
 	retryDuration time.Duration
 	log           logs.Logger
+	tracer        trace.TracerProvider
 }
 
 func (w *batchWriter) Write(data interface{}) error {
@@ -55,6 +80,8 @@ func (w *batchWriter) WriteN(data ...interface{}) (int, error) {
 	}
 
 	requests := make([]elastic.BulkableRequest, len(data), len(data))
+	indices := make(map[string]struct{})
+	var links []trace.SpanContext
 	for i, item := range data {
 		if doc, ok := item.(*Document); ok {
 			req := elastic.NewBulkIndexRequest().Index(doc.Index).Type(w.typ).Doc(doc.Data)
@@ -62,12 +89,24 @@ func (w *batchWriter) WriteN(data ...interface{}) (int, error) {
 				req.Id(doc.ID)
 			}
 			requests[i] = req
+			indices[doc.Index] = struct{}{}
+			if doc.Context != nil {
+				links = append(links, trace.SpanContextFromContext(doc.Context))
+			}
 		} else {
 			return 0, fmt.Errorf("%s is not *elasticsearch.Document", reflect.TypeOf(item))
 		}
 	}
+	ctx, span := startBulk(context.Background(), w.tracer, len(requests), indices, links)
+	var err error
+	defer func() { span.Finish(err) }()
 	for i := 0; ; i++ {
-		res, err := w.client.Bulk().Add(requests...).Timeout(w.timeout).Do(context.Background())
+		bulk := w.client.Bulk().Add(requests...)
+		if i == 0 {
+			span.AddBytes(bulk.EstimatedSizeInBytes())
+		}
+		var res *elastic.BulkResponse
+		res, err = bulk.Timeout(w.timeout).Do(ctx)
 		if err != nil {
 			if i < w.retry {
 				w.log.Warnf("failed to write batch(%d) to elasticsearch and retry after %s: %s", len(requests), w.retryDuration.String(), err)
@@ -78,6 +117,7 @@ func (w *batchWriter) WriteN(data ...interface{}) (int, error) {
 			break
 		}
 		if res.Errors {
+			span.SetFailed(len(res.Failed()))
 			for _, item := range res.Failed() {
 				if item == nil || item.Error == nil {
 					continue
@@ -111,6 +151,9 @@ type Writer struct {
 	client  *elastic.Client
 	enc     EncodeFunc
 	timeout string
+	metrics *writer.Metrics
+	name    string
+	tracer  trace.TracerProvider
 }
 
 // Close .
@@ -118,22 +161,46 @@ func (w *Writer) Close() error { return nil }
 
 // Write .
 func (w *Writer) Write(data interface{}) error {
+	return w.WriteContext(context.Background(), data)
+}
+
+// WriteContext writes data within ctx, the span of request is a child of the span in ctx.
+func (w *Writer) WriteContext(ctx context.Context, data interface{}) (err error) {
 	index, id, typ, body, err := w.enc(data)
 	if err != nil {
 		return err
 	}
+	ctx, span := tracebatch.Start(ctx, w.tracer, "elasticsearch index", 1, nil,
+		semconv.DBSystemElasticsearch,
+		semconv.DBOperation("index"),
+		indicesKey.StringSlice([]string{index}),
+	)
+	defer func() { span.Finish(err) }()
 	_, err = w.client.Index().
 		Index(index).Id(id).Type(typ).
-		BodyJson(body).Timeout(w.timeout).Do(context.Background())
+		BodyJson(body).Timeout(w.timeout).Do(ctx)
 	return err
 }
 
 // WriteN .
 func (w *Writer) WriteN(list ...interface{}) (int, error) {
+	return w.WriteNContext(context.Background(), list...)
+}
+
+// WriteNContext writes list in a bulk request within ctx, the span of request is a child of the span in ctx.
+func (w *Writer) WriteNContext(ctx context.Context, list ...interface{}) (int, error) {
 	if len(list) <= 0 {
 		return 0, nil
 	}
+	start := time.Now()
+	n, err := w.writeN(ctx, list)
+	w.metrics.ObserveFlush(w.name, len(list), time.Since(start), err)
+	return n, err
+}
+
+func (w *Writer) writeN(ctx context.Context, list []interface{}) (n int, err error) {
 	bulk := w.client.Bulk()
+	indices := make(map[string]struct{})
 	for _, data := range list {
 		index, id, typ, body, err := w.enc(data)
 		if err != nil {
@@ -141,8 +208,17 @@ func (w *Writer) WriteN(list ...interface{}) (int, error) {
 		}
 		req := elastic.NewBulkIndexRequest().Index(index).Id(id).Type(typ).Doc(body)
 		bulk.Add(req)
+		indices[index] = struct{}{}
 	}
-	res, err := bulk.Timeout(w.timeout).Do(context.Background())
+	ctx, span := startBulk(ctx, w.tracer, len(list), indices, nil)
+	span.AddBytes(bulk.EstimatedSizeInBytes())
+	defer func() {
+		if berr, ok := err.(*BatchWriteError); ok {
+			span.SetFailed(len(berr.Errors))
+		}
+		span.Finish(err)
+	}()
+	res, err := bulk.Timeout(w.timeout).Do(ctx)
 	if err != nil {
 		berr := &BatchWriteError{
 			List:   list,
@@ -27,6 +27,8 @@ import (
 
 	"github.com/erda-project/erda-infra/base/logs"
 	"github.com/erda-project/erda-infra/base/servicehub"
+	grpccontext "github.com/erda-project/erda-infra/pkg/trace/inject/context/grpc"
+	"github.com/erda-project/erda-infra/pkg/trace/inject/hook"
 )
 
 // Interface .
@@ -54,6 +56,7 @@ type config struct {
 	SyncConnect bool   `file:"sync_connect" default:"true"`
 	Username    string `file:"username"`
 	Password    string `file:"password"`
+	Trace       bool   `file:"trace" env:"ETCD_TRACE" default:"false"`
 }
 
 var clientType = reflect.TypeOf((*clientv3.Client)(nil))
@@ -86,6 +89,13 @@ func (p *provider) Connect() (*clientv3.Client, error) {
 		Username:    p.Cfg.Username,
 		Password:    p.Cfg.Password,
 	}
+	// interceptors are added by the hooked client constructor already if hook is enabled
+	if p.Cfg.Trace && !hook.Enabled() {
+		config.DialOptions = append(config.DialOptions,
+			grpc.WithChainUnaryInterceptor(grpccontext.UnaryClientInterceptor()),
+			grpc.WithChainStreamInterceptor(grpccontext.StreamClientInterceptor()),
+		)
+	}
 	if p.Cfg.SyncConnect {
 		config.DialOptions = append(config.DialOptions, grpc.WithBlock())
 	}
@@ -19,20 +19,32 @@ import (
 	"crypto/tls"
 	"fmt"
 	"reflect"
+	"strings"
+	"sync"
+	"time"
 
+	clientv3 "go.etcd.io/etcd/client/v3"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/resolver"
 
 	"github.com/erda-project/erda-infra/base/logs"
 	"github.com/erda-project/erda-infra/base/servicehub"
 	grpccontext "github.com/erda-project/erda-infra/pkg/trace/inject/context/grpc"
 	transgrpc "github.com/erda-project/erda-infra/pkg/transport/grpc"
+	"github.com/erda-project/erda-infra/pkg/transport/grpc/discovery"
+	"github.com/erda-project/erda-infra/pkg/transport/grpc/resilience"
 )
 
 // Interface .
 type Interface interface {
 	Get() *grpc.ClientConn
 	NewConnect(opts ...grpc.DialOption) (*grpc.ClientConn, error)
+	// Conn returns the connection of target in singleton mode, the default connection is returned if name is empty.
+	Conn(name string) *grpc.ClientConn
+	// States returns the connectivity states of targets.
+	States() map[string]connectivity.State
 }
 
 var (
@@ -42,7 +54,7 @@ var (
 )
 
 type config struct {
-	Addr string `file:"addr" env:"GRPC_CLIENT_ADDR" default:":7070" desc:"the server address in the format of host:port"`
+	Addr string `file:"addr" env:"GRPC_CLIENT_ADDR" default:":7070" desc:"the server address in the format of host:port, or a target with scheme, e.g. static:///host1:7070,host2:7070, dns:///host:7070 or etcd:///service"`
 	TLS  struct {
 		ServerNameOverride string `file:"cert_file" env:"GRPC_CLIENT_CERT_FILE" desc:"the server name used to verify the hostname returned by the TLS handshake"`
 		CAFile             string `file:"ca_file" env:"GRPC_CLIENT_CA_FILE" desc:"the file containing the CA root cert file"`
@@ -51,27 +63,88 @@ type config struct {
 	Singleton   bool `file:"singleton" env:"GRPC_CLIENT_SINGLETON" default:"true" desc:"one client instance"`
 	Block       bool `file:"block" env:"GRPC_CLIENT_BLOCK" default:"true" desc:"block until the connection is up"`
 	TraceEnable bool `file:"trace_enable" env:"GRPC_CLIENT_TRACE_ENABLE" default:"true"`
+
+	Resilience resilience.Config `file:"resilience" desc:"retry, hedging and circuit breaker for unary calls"`
+
+	Discovery struct {
+		Balancer           string        `file:"balancer" env:"GRPC_CLIENT_BALANCER" desc:"load balancing policy, pick_first, round_robin or weighted"`
+		DNSRefreshInterval time.Duration `file:"dns_refresh_interval" default:"30s" desc:"interval to refresh addresses of dns target"`
+		EtcdPrefix         string        `file:"etcd_prefix" default:"/erda-infra/grpc/services/" desc:"etcd key prefix of registered endpoints"`
+	} `file:"discovery"`
+
+	Targets       map[string]map[string]interface{} `file:"targets" desc:"named targets, the config above is used as defaults and can be overridden by each target"`
+	DefaultTarget string                            `file:"default_target" desc:"name of target returned by Get, the only target is used by default, it's required for multiple targets"`
 }
 
 type provider struct {
-	Cfg  *config
-	Log  logs.Logger
-	conn *grpc.ClientConn
-	opts []grpc.DialOption
+	Cfg     *config
+	Log     logs.Logger
+	Etcd    *clientv3.Client `autowired:"etcd-client" optional:"true"`
+	conn    *grpc.ClientConn
+	opts    []grpc.DialOption
+	def     *target
+	targets map[string]*target
 }
 
 func (p *provider) Init(ctx servicehub.Context) error {
+	p.targets = make(map[string]*target)
+	if len(p.Cfg.Targets) <= 0 {
+		t, err := p.newTarget("", p.Cfg)
+		if err != nil {
+			return err
+		}
+		p.def = t
+	} else {
+		for name, raw := range p.Cfg.Targets {
+			cfg, err := p.targetConfig(raw)
+			if err != nil {
+				return fmt.Errorf("invalid config of target %q: %s", name, err)
+			}
+			t, err := p.newTarget(name, cfg)
+			if err != nil {
+				return fmt.Errorf("target %q: %s", name, err)
+			}
+			p.targets[name] = t
+		}
+		name := p.Cfg.DefaultTarget
+		if len(name) <= 0 {
+			// Get and the connection injected without target tag need a default one
+			if len(p.targets) > 1 {
+				return fmt.Errorf("default_target is required for %d targets", len(p.targets))
+			}
+			for n := range p.targets {
+				name = n
+			}
+		}
+		p.def = p.targets[name]
+		if p.def == nil {
+			return fmt.Errorf("default target %q not found", name)
+		}
+	}
+	p.opts = p.def.opts
+	if p.Cfg.Singleton {
+		for _, t := range p.allTargets() {
+			if err := t.connect(); err != nil {
+				return err
+			}
+		}
+		p.conn = p.def.conn
+	}
+	return nil
+}
+
+func (p *provider) dialOptions(cfg *config) ([]grpc.DialOption, error) {
 	var opts []grpc.DialOption
-	if len(p.Cfg.TLS.CAFile) > 0 {
-		creds, err := credentials.NewClientTLSFromFile(p.Cfg.TLS.CAFile, p.Cfg.TLS.ServerNameOverride)
+	if len(cfg.TLS.CAFile) > 0 {
+		creds, err := credentials.NewClientTLSFromFile(cfg.TLS.CAFile, cfg.TLS.ServerNameOverride)
 		if err != nil {
-			return fmt.Errorf("fail to create tls credentials %s", err)
+			return nil, fmt.Errorf("fail to create tls credentials %s", err)
 		}
 		opts = append(opts, grpc.WithTransportCredentials(creds))
 	} else {
 		// distinguish `no tls` or `tls: insecure skip verify`
 		notls := true // default no tls, compatible with old config
-		if p.Cfg.TLS.InsecureSkipVerify {
+		if cfg.TLS.InsecureSkipVerify {
 			notls = false
 		}
 		if notls {
@@ -81,40 +154,110 @@ func (p *provider) Init(ctx servicehub.Context) error {
 			opts = append(opts, grpc.WithTransportCredentials(insecureSkipVerifyTLS))
 		}
 	}
-	if p.Cfg.TraceEnable {
+	if cfg.TraceEnable {
 		opts = append(opts,
 			grpc.WithUnaryInterceptor(grpccontext.UnaryClientInterceptor()),
 			grpc.WithStreamInterceptor(grpccontext.StreamClientInterceptor()),
 		)
 	}
-	p.opts = opts
-	if p.Cfg.Singleton {
-		opts = nil
-		if p.Cfg.Block {
-			opts = append(opts, grpc.WithBlock())
-		}
-		conn, err := p.NewConnect(opts...)
+	if cfg.Resilience.Enabled() {
+		unary, err := resilience.UnaryClientInterceptor(&cfg.Resilience)
 		if err != nil {
-			return fmt.Errorf("fail to dial: %s", err)
+			return nil, fmt.Errorf("invalid resilience config: %s", err)
 		}
-		p.conn = conn
+		opts = append(opts, grpc.WithChainUnaryInterceptor(unary))
 	}
-	return nil
+	dopts, err := p.discoveryOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return append(opts, dopts...), nil
+}
+
+func (p *provider) discoveryOptions(cfg *config) ([]grpc.DialOption, error) {
+	builders := []resolver.Builder{
+		discovery.NewStaticBuilder(),
+		discovery.NewDNSBuilder(cfg.Discovery.DNSRefreshInterval),
+	}
+	if p.Etcd != nil {
+		builders = append(builders, discovery.NewEtcdBuilder(p.Etcd, cfg.Discovery.EtcdPrefix))
+	} else if strings.HasPrefix(cfg.Addr, discovery.EtcdScheme+"://") {
+		return nil, fmt.Errorf("etcd is required to resolve %q", cfg.Addr)
+	}
+	opts := []grpc.DialOption{grpc.WithResolvers(builders...)}
+	var policy string
+	switch cfg.Discovery.Balancer {
+	case "":
+	case "pick_first", "round_robin":
+		policy = cfg.Discovery.Balancer
+	case "weighted":
+		policy = discovery.WeightedBalancerName
+	default:
+		return nil, fmt.Errorf("invalid balancer %q", cfg.Discovery.Balancer)
+	}
+	if len(policy) > 0 {
+		opts = append(opts, grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"loadBalancingConfig":[{%q:{}}]}`, policy)))
+	}
+	return opts, nil
 }
 
 func (p *provider) Get() *grpc.ClientConn { return p.conn }
 func (p *provider) NewConnect(opts ...grpc.DialOption) (*grpc.ClientConn, error) {
-	return grpc.Dial(p.Cfg.Addr, append(opts, p.opts...)...)
+	return p.def.dial(opts...)
 }
 
-func (p *provider) Run(ctx context.Context) error {
-	if p.Cfg.Singleton {
-		select {
-		case <-ctx.Done():
-			p.conn.Close()
-			return nil
+func (p *provider) Conn(name string) *grpc.ClientConn {
+	if t := p.target(name); t != nil {
+		return t.conn
+	}
+	return nil
+}
+
+func (p *provider) States() map[string]connectivity.State {
+	states := make(map[string]connectivity.State)
+	for _, t := range p.allTargets() {
+		if t.conn != nil {
+			states[t.name] = t.conn.GetState()
 		}
 	}
+	return states
+}
+
+func (p *provider) target(name string) *target {
+	if len(name) <= 0 {
+		return p.def
+	}
+	return p.targets[name]
+}
+
+func (p *provider) allTargets() []*target {
+	if len(p.targets) <= 0 {
+		return []*target{p.def}
+	}
+	list := make([]*target, 0, len(p.targets))
+	for _, t := range p.targets {
+		list = append(list, t)
+	}
+	return list
+}
+
+func (p *provider) Run(ctx context.Context) error {
+	if !p.Cfg.Singleton {
+		return nil
+	}
+	targets := p.allTargets()
+	var wg sync.WaitGroup
+	for _, t := range targets {
+		wg.Add(1)
+		go func(t *target) {
+			defer wg.Done()
+			t.watchState(ctx, p.Log)
+		}(t)
+	}
+	wg.Wait()
+	for _, t := range targets {
+		t.conn.Close()
+	}
 	return nil
 }
 
@@ -122,6 +265,13 @@ func (p *provider) Provide(ctx servicehub.DependencyContext, args ...interface{}
 	if ctx.Service() == "grpc-client-connector" || ctx.Type() == interfaceType {
 		return p
 	}
+	if name := ctx.Tags().Get("target"); len(name) > 0 {
+		if conn := p.Conn(name); conn != nil {
+			return conn
+		}
+		p.Log.Errorf("grpc client target %q not found for %s", name, ctx.Caller())
+		return nil
+	}
 	return p.conn
 }
 
@@ -0,0 +1,86 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcclient
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc"
+
+	"github.com/erda-project/erda-infra/base/logs"
+	pkgconfig "github.com/erda-project/erda-infra/pkg/config"
+	"github.com/erda-project/erda-infra/pkg/transport/grpc/resilience"
+)
+
+type target struct {
+	name string
+	cfg  *config
+	opts []grpc.DialOption
+	conn *grpc.ClientConn
+}
+
+func (p *provider) newTarget(name string, cfg *config) (*target, error) {
+	opts, err := p.dialOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &target{name: name, cfg: cfg, opts: opts}, nil
+}
+
+// targetConfig returns the config of target, which overrides the provider config by raw.
+func (p *provider) targetConfig(raw map[string]interface{}) (*config, error) {
+	cfg := *p.Cfg
+	cfg.Targets, cfg.DefaultTarget = nil, ""
+	if methods := p.Cfg.Resilience.Methods; methods != nil {
+		cfg.Resilience.Methods = make(map[string]*resilience.MethodPolicy, len(methods))
+		for k, v := range methods {
+			cfg.Resilience.Methods[k] = v
+		}
+	}
+	if err := pkgconfig.ConvertData(raw, &cfg, "file"); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+func (t *target) dial(opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	return grpc.Dial(t.cfg.Addr, append(opts, t.opts...)...)
+}
+
+func (t *target) connect() error {
+	var opts []grpc.DialOption
+	if t.cfg.Block {
+		opts = append(opts, grpc.WithBlock())
+	}
+	conn, err := t.dial(opts...)
+	if err != nil {
+		if len(t.name) > 0 {
+			return fmt.Errorf("fail to dial target %q: %s", t.name, err)
+		}
+		return fmt.Errorf("fail to dial: %s", err)
+	}
+	t.conn = conn
+	return nil
+}
+
+// watchState logs the connectivity state changes until ctx is done.
+func (t *target) watchState(ctx context.Context, log logs.Logger) {
+	state := t.conn.GetState()
+	for t.conn.WaitForStateChange(ctx, state) {
+		state = t.conn.GetState()
+		log.Infof("grpc client target %q (%s) state changed to %s", t.name, t.cfg.Addr, state)
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcclient
+
+import (
+	"testing"
+	"time"
+
+	"github.com/erda-project/erda-infra/pkg/transport/grpc/resilience"
+)
+
+func TestTargetConfig(t *testing.T) {
+	p := &provider{Cfg: &config{Addr: ":7070", TraceEnable: true}}
+	p.Cfg.TLS.InsecureSkipVerify = true
+	p.Cfg.Resilience.Retry.MaxAttempts = 3
+	p.Cfg.Resilience.Methods = map[string]*resilience.MethodPolicy{"a.Service": {}}
+
+	cfg, err := p.targetConfig(map[string]interface{}{
+		"addr": "orders:7070",
+		"resilience": map[string]interface{}{
+			"retry":   map[string]interface{}{"initial_backoff": "1s"},
+			"methods": map[string]interface{}{"b.Service": map[string]interface{}{}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Addr != "orders:7070" || !cfg.TraceEnable || !cfg.TLS.InsecureSkipVerify {
+		t.Errorf("targetConfig() = %+v, want addr overridden and others inherited", cfg)
+	}
+	if cfg.Resilience.Retry.MaxAttempts != 3 || cfg.Resilience.Retry.InitialBackoff != time.Second {
+		t.Errorf("targetConfig().Resilience.Retry = %+v", cfg.Resilience.Retry)
+	}
+	if len(cfg.Resilience.Methods) != 2 || len(p.Cfg.Resilience.Methods) != 1 {
+		t.Errorf("targetConfig() methods = %v, provider methods = %v", cfg.Resilience.Methods, p.Cfg.Resilience.Methods)
+	}
+}
+
+func TestDefaultTarget(t *testing.T) {
+	targets := map[string]map[string]interface{}{
+		"orders": {"addr": "orders:7070"},
+		"users":  {"addr": "users:7070"},
+	}
+	p := &provider{Cfg: &config{Targets: targets}}
+	if err := p.Init(nil); err == nil {
+		t.Fatal("Init() with multiple targets and no default_target, want error")
+	}
+
+	p = &provider{Cfg: &config{Targets: targets, DefaultTarget: "users"}}
+	if err := p.Init(nil); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	if p.def == nil || p.def.name != "users" {
+		t.Errorf("default target = %v, want users", p.def)
+	}
+
+	p = &provider{Cfg: &config{Targets: targets, DefaultTarget: "unknown"}}
+	if err := p.Init(nil); err == nil {
+		t.Fatal("Init() with unknown default_target, want error")
+	}
+}
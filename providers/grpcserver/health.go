@@ -0,0 +1,97 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcserver
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	"github.com/erda-project/erda-infra/providers/health"
+)
+
+// healthServer implements grpc.health.v1.Health with the checkers of health provider.
+type healthServer struct {
+	healthpb.UnimplementedHealthServer
+	server   *grpc.Server
+	checkers health.Checkers
+	interval time.Duration
+	stopped  int32
+}
+
+func (s *healthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	if !s.hasService(req.Service) {
+		return nil, status.Errorf(codes.NotFound, "unknown service %q", req.Service)
+	}
+	return &healthpb.HealthCheckResponse{Status: s.status(ctx)}, nil
+}
+
+func (s *healthServer) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	ctx := stream.Context()
+	last := healthpb.HealthCheckResponse_UNKNOWN
+	if !s.hasService(req.Service) {
+		last = healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+		if err := stream.Send(&healthpb.HealthCheckResponse{Status: last}); err != nil {
+			return err
+		}
+	}
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		if s.hasService(req.Service) {
+			if st := s.status(ctx); st != last {
+				last = st
+				if err := stream.Send(&healthpb.HealthCheckResponse{Status: st}); err != nil {
+					return err
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *healthServer) hasService(name string) bool {
+	if len(name) <= 0 {
+		return true
+	}
+	_, ok := s.server.GetServiceInfo()[name]
+	return ok
+}
+
+func (s *healthServer) status(ctx context.Context) healthpb.HealthCheckResponse_ServingStatus {
+	if atomic.LoadInt32(&s.stopped) != 0 {
+		return healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	if s.checkers != nil {
+		if ok, _ := s.checkers.Check(ctx); !ok {
+			return healthpb.HealthCheckResponse_NOT_SERVING
+		}
+	}
+	return healthpb.HealthCheckResponse_SERVING
+}
+
+// shutdown marks the server as NOT_SERVING.
+func (s *healthServer) shutdown() {
+	atomic.StoreInt32(&s.stopped, 1)
+}
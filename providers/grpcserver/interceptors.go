@@ -0,0 +1,117 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcserver
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/grpc"
+)
+
+// Interceptors is used by other providers to contribute server interceptors,
+// interceptors must be added before the server starts, e.g. in Init of providers.
+type Interceptors interface {
+	AddUnaryInterceptor(interceptors ...grpc.UnaryServerInterceptor)
+	AddStreamInterceptor(interceptors ...grpc.StreamServerInterceptor)
+}
+
+// interceptorChain holds contributed interceptors, they are chained after the built-in interceptors.
+type interceptorChain struct {
+	lock    sync.Mutex
+	started bool
+	unary   []grpc.UnaryServerInterceptor
+	stream  []grpc.StreamServerInterceptor
+
+	unaryChain  grpc.UnaryServerInterceptor
+	streamChain grpc.StreamServerInterceptor
+}
+
+func (c *interceptorChain) addUnary(list ...grpc.UnaryServerInterceptor) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.started {
+		return false
+	}
+	c.unary = append(c.unary, list...)
+	return true
+}
+
+func (c *interceptorChain) addStream(list ...grpc.StreamServerInterceptor) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.started {
+		return false
+	}
+	c.stream = append(c.stream, list...)
+	return true
+}
+
+// start freezes the contributed interceptors.
+func (c *interceptorChain) start() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.started = true
+	if len(c.unary) > 0 {
+		c.unaryChain = chainUnary(c.unary)
+	}
+	if len(c.stream) > 0 {
+		c.streamChain = chainStream(c.stream)
+	}
+}
+
+func (c *interceptorChain) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if c.unaryChain == nil {
+		return handler(ctx, req)
+	}
+	return c.unaryChain(ctx, req, info, handler)
+}
+
+func (c *interceptorChain) streamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if c.streamChain == nil {
+		return handler(srv, ss)
+	}
+	return c.streamChain(srv, ss, info, handler)
+}
+
+func chainUnary(list []grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return list[0](ctx, req, info, chainUnaryHandler(list, 0, info, handler))
+	}
+}
+
+func chainUnaryHandler(list []grpc.UnaryServerInterceptor, curr int, info *grpc.UnaryServerInfo, final grpc.UnaryHandler) grpc.UnaryHandler {
+	if curr == len(list)-1 {
+		return final
+	}
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		return list[curr+1](ctx, req, info, chainUnaryHandler(list, curr+1, info, final))
+	}
+}
+
+func chainStream(list []grpc.StreamServerInterceptor) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return list[0](srv, ss, info, chainStreamHandler(list, 0, info, handler))
+	}
+}
+
+func chainStreamHandler(list []grpc.StreamServerInterceptor, curr int, info *grpc.StreamServerInfo, final grpc.StreamHandler) grpc.StreamHandler {
+	if curr == len(list)-1 {
+		return final
+	}
+	return func(srv interface{}, ss grpc.ServerStream) error {
+		return list[curr+1](srv, ss, info, chainStreamHandler(list, curr+1, info, final))
+	}
+}
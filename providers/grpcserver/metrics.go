@@ -0,0 +1,70 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcserver
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	pkgmetrics "github.com/erda-project/erda-infra/pkg/metrics"
+	transerrors "github.com/erda-project/erda-infra/pkg/transport/errors"
+)
+
+// splitMethod splits full method name /pkg.Service/Method to service and method.
+func splitMethod(fullMethod string) (string, string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	if idx := strings.LastIndex(fullMethod, "/"); idx >= 0 {
+		return fullMethod[:idx], fullMethod[idx+1:]
+	}
+	return "unknown", fullMethod
+}
+
+func unaryMetricsInterceptor(red *pkgmetrics.RED) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		service, method := splitMethod(info.FullMethod)
+		o := red.Begin(service, method, "unary")
+		code, size := codes.Internal, -1 // recorded if handler panics
+		defer func() { o.End(transerrors.CodeName(code), size) }()
+		resp, err := handler(ctx, req)
+		code = status.Code(err)
+		if msg, ok := resp.(proto.Message); ok && err == nil {
+			size = proto.Size(msg)
+		}
+		return resp, err
+	}
+}
+
+func streamMetricsInterceptor(red *pkgmetrics.RED) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		service, method := splitMethod(info.FullMethod)
+		kind := "bidi_stream"
+		if !info.IsClientStream {
+			kind = "server_stream"
+		} else if !info.IsServerStream {
+			kind = "client_stream"
+		}
+		o := red.Begin(service, method, kind)
+		code := codes.Internal // recorded if handler panics
+		defer func() { o.End(transerrors.CodeName(code), -1) }()
+		err := handler(srv, ss)
+		code = status.Code(err)
+		return err
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcserver
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+
+	pkgmetrics "github.com/erda-project/erda-infra/pkg/metrics"
+)
+
+func TestUnaryMetricsInterceptorPanic(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	red := pkgmetrics.NewRED([]string{"service", "method", "type"}, "code",
+		pkgmetrics.WithRegisterer(reg),
+		pkgmetrics.WithNamespace("", "grpc_server"),
+	)
+	interceptor := unaryMetricsInterceptor(red)
+	func() {
+		defer func() { recover() }()
+		interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/pkg.Service/Method"},
+			func(ctx context.Context, req interface{}) (interface{}, error) {
+				panic("boom")
+			},
+		)
+	}()
+	want := `
+# HELP grpc_server_requests_in_flight Number of requests being handled.
+# TYPE grpc_server_requests_in_flight gauge
+grpc_server_requests_in_flight{method="Method",service="pkg.Service",type="unary"} 0
+# HELP grpc_server_requests_total Total number of requests handled.
+# TYPE grpc_server_requests_total counter
+grpc_server_requests_total{code="INTERNAL",method="Method",service="pkg.Service",type="unary"} 1
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want), "grpc_server_requests_in_flight", "grpc_server_requests_total"); err != nil {
+		t.Error(err)
+	}
+}
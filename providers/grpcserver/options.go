@@ -0,0 +1,94 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcserver
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+
+	"github.com/erda-project/erda-infra/pkg/tlsutil"
+)
+
+func (p *provider) serverOptions() ([]grpc.ServerOption, error) {
+	var opts []grpc.ServerOption
+	if len(p.Cfg.TLS.CertFile) > 0 || len(p.Cfg.TLS.KeyFile) > 0 {
+		creds, err := p.tlsCredentials()
+		if err != nil {
+			return nil, fmt.Errorf("fail to generate credentials %v", err)
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+	ka := p.Cfg.Keepalive
+	if ka.Time > 0 || ka.Timeout > 0 || ka.MaxConnectionIdle > 0 || ka.MaxConnectionAge > 0 || ka.MaxConnectionAgeGrace > 0 {
+		opts = append(opts, grpc.KeepaliveParams(keepalive.ServerParameters{
+			MaxConnectionIdle:     ka.MaxConnectionIdle,
+			MaxConnectionAge:      ka.MaxConnectionAge,
+			MaxConnectionAgeGrace: ka.MaxConnectionAgeGrace,
+			Time:                  ka.Time,
+			Timeout:               ka.Timeout,
+		}))
+	}
+	if ka.MinTime > 0 || ka.PermitWithoutStream {
+		opts = append(opts, grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             ka.MinTime,
+			PermitWithoutStream: ka.PermitWithoutStream,
+		}))
+	}
+	if p.Cfg.MaxRecvMsgSize > 0 {
+		opts = append(opts, grpc.MaxRecvMsgSize(p.Cfg.MaxRecvMsgSize))
+	}
+	if p.Cfg.MaxSendMsgSize > 0 {
+		opts = append(opts, grpc.MaxSendMsgSize(p.Cfg.MaxSendMsgSize))
+	}
+	if p.Cfg.MaxConcurrentStreams > 0 {
+		opts = append(opts, grpc.MaxConcurrentStreams(p.Cfg.MaxConcurrentStreams))
+	}
+	return opts, nil
+}
+
+func (p *provider) tlsCredentials() (credentials.TransportCredentials, error) {
+	if len(p.Cfg.TLS.ClientCAFile) <= 0 {
+		return credentials.NewServerTLSFromFile(p.Cfg.TLS.CertFile, p.Cfg.TLS.KeyFile)
+	}
+	cert, err := tls.LoadX509KeyPair(p.Cfg.TLS.CertFile, p.Cfg.TLS.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	ca, err := os.ReadFile(p.Cfg.TLS.ClientCAFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("invalid client CA file %q", p.Cfg.TLS.ClientCAFile)
+	}
+	clientAuth, err := tlsutil.ParseClientAuth(p.Cfg.TLS.ClientAuth, true)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   clientAuth,
+		MinVersion:   tls.VersionTLS12,
+	}
+	return credentials.NewTLS(cfg), nil
+}
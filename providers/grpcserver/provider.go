@@ -15,15 +15,27 @@
 package grpcserver
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"reflect"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/erda-project/erda-infra/base/logs"
 	"github.com/erda-project/erda-infra/base/servicehub"
+	pkgmetrics "github.com/erda-project/erda-infra/pkg/metrics"
+	"github.com/erda-project/erda-infra/pkg/netutil"
 	grpccontext "github.com/erda-project/erda-infra/pkg/trace/inject/context/grpc"
+	"github.com/erda-project/erda-infra/pkg/transport/grpc/discovery"
+	"github.com/erda-project/erda-infra/providers/health"
+	"github.com/erda-project/erda-infra/providers/httpserver"
+	clientv3 "go.etcd.io/etcd/client/v3"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials"
+	channelz "google.golang.org/grpc/channelz/service"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 )
 
 // Interface .
@@ -33,66 +45,262 @@ type Interface interface {
 
 // config .
 type config struct {
-	Addr string `file:"addr" default:":7070" env:"GRPC_SERVER_ADDR" desc:"grpc address to listen"`
-	TLS  struct {
-		CertFile string `file:"cert_file" env:"GRPC_SERVER_CERT_FILE" desc:"the TLS cert file"`
-		KeyFile  string `file:"key_file" env:"GRPC_SERVER_KEY_FILE" desc:"the TLS key file"`
+	Addr   string               `file:"addr" default:":7070" env:"GRPC_SERVER_ADDR" desc:"grpc address to listen, host:port, unix:///path/to.sock or systemd://[name] for socket activation"`
+	Socket netutil.SocketConfig `file:"socket"`
+	TLS    struct {
+		CertFile     string `file:"cert_file" env:"GRPC_SERVER_CERT_FILE" desc:"the TLS cert file"`
+		KeyFile      string `file:"key_file" env:"GRPC_SERVER_KEY_FILE" desc:"the TLS key file"`
+		ClientCAFile string `file:"client_ca_file" env:"GRPC_SERVER_CLIENT_CA_FILE" desc:"the CA file to verify client certs, enables mTLS"`
+		ClientAuth   string `file:"client_auth" default:"require" desc:"client cert policy of mTLS, none, request, require_any, optional or require"`
 	} `file:"tls"`
-	TraceEnable bool `file:"trace_enable" env:"GRPC_SERVER_TRACE_ENABLE" default:"true"`
+	Keepalive struct {
+		Time                  time.Duration `file:"time" desc:"ping the client if the connection is idle for this duration, 2h by default"`
+		Timeout               time.Duration `file:"timeout" desc:"wait for the ping ack before closing the connection, 20s by default"`
+		MaxConnectionIdle     time.Duration `file:"max_connection_idle" desc:"close the connection if it's idle for this duration"`
+		MaxConnectionAge      time.Duration `file:"max_connection_age" desc:"max age of connection before sending GoAway"`
+		MaxConnectionAgeGrace time.Duration `file:"max_connection_age_grace" desc:"grace period after max_connection_age to close the connection forcibly"`
+		MinTime               time.Duration `file:"min_time" desc:"min interval of client pings, the connection is closed if clients ping more frequently, 5m by default"`
+		PermitWithoutStream   bool          `file:"permit_without_stream" desc:"allow client pings even if there are no active streams"`
+	} `file:"keepalive"`
+	MaxRecvMsgSize       int           `file:"max_recv_msg_size" env:"GRPC_SERVER_MAX_RECV_MSG_SIZE" desc:"max message size in bytes the server can receive, 4MB by default"`
+	MaxSendMsgSize       int           `file:"max_send_msg_size" env:"GRPC_SERVER_MAX_SEND_MSG_SIZE" desc:"max message size in bytes the server can send"`
+	MaxConcurrentStreams uint32        `file:"max_concurrent_streams" env:"GRPC_SERVER_MAX_CONCURRENT_STREAMS" desc:"max concurrent streams of each connection"`
+	TraceEnable          bool          `file:"trace_enable" env:"GRPC_SERVER_TRACE_ENABLE" default:"true"`
+	MaxTimeout           time.Duration `file:"max_timeout" env:"GRPC_SERVER_MAX_TIMEOUT" desc:"max timeout of request, the deadline of client is limited by it"`
+	ServeOnHTTP          bool          `file:"serve_on_http" env:"GRPC_SERVER_SERVE_ON_HTTP" desc:"serve grpc on the port of http-server instead of addr, requests are routed by content-type, h2c or tls of http-server is required"`
+	Health               struct {
+		Enable        bool          `file:"enable" env:"GRPC_SERVER_HEALTH_ENABLE" desc:"register grpc.health.v1.Health service"`
+		WatchInterval time.Duration `file:"watch_interval" default:"5s" desc:"interval to check health for Watch"`
+	} `file:"health"`
+	Reflection      bool          `file:"reflection" env:"GRPC_SERVER_REFLECTION" desc:"register server reflection service"`
+	Channelz        bool          `file:"channelz" env:"GRPC_SERVER_CHANNELZ" desc:"register channelz service"`
+	ShutdownTimeout time.Duration `file:"shutdown_timeout" default:"10s" env:"GRPC_SERVER_SHUTDOWN_TIMEOUT" desc:"max duration to wait for pending RPCs on close, then stop forcibly"`
+	Metrics         struct {
+		Enable         bool      `file:"enable" default:"true" env:"GRPC_SERVER_METRICS_ENABLE" desc:"record request metrics of methods"`
+		Buckets        []float64 `file:"buckets" desc:"buckets of latency histogram in seconds"`
+		SizeBuckets    []float64 `file:"size_buckets" desc:"buckets of response size histogram in bytes"`
+		MaxLabelValues int       `file:"max_label_values" default:"1000" desc:"max number of distinct methods, methods beyond it are recorded as other"`
+	} `file:"metrics"`
+	Registry struct {
+		Enable        bool              `file:"enable" env:"GRPC_SERVER_REGISTRY_ENABLE" desc:"register the server address to etcd for discovery"`
+		Prefix        string            `file:"prefix" default:"/erda-infra/grpc/services/" desc:"etcd key prefix of registered endpoints"`
+		Services      []string          `file:"services" desc:"service names to register, all grpc services of the server by default"`
+		AdvertiseAddr string            `file:"advertise_addr" env:"GRPC_SERVER_ADVERTISE_ADDR" desc:"address for clients to connect, detected from the tcp listener by default, required if the server does not listen on tcp"`
+		Weight        int               `file:"weight" default:"1" desc:"weight for weighted balancer"`
+		Metadata      map[string]string `file:"metadata" desc:"metadata of the endpoint"`
+		TTL           time.Duration     `file:"ttl" default:"10s" desc:"ttl of registration lease"`
+	} `file:"registry"`
 }
 
 type provider struct {
-	Cfg    *config
-	Log    logs.Logger
-	listen net.Listener
-	server *grpc.Server
+	Cfg          *config
+	Log          logs.Logger
+	Checkers     health.Checkers    `autowired:"health" optional:"true"`
+	Etcd         *clientv3.Client   `autowired:"etcd-client" optional:"true"`
+	HTTPMux      httpserver.GRPCMux `autowired:"http-server" optional:"true"`
+	listen       net.Listener
+	server       *grpc.Server
+	health       *healthServer
+	interceptors interceptorChain
+	registration *discovery.Registration
 }
 
 func (p *provider) Init(ctx servicehub.Context) error {
-	lis, err := net.Listen("tcp", p.Cfg.Addr)
+	opts, err := p.serverOptions()
 	if err != nil {
 		return err
 	}
-	p.listen = lis
-
-	var opts []grpc.ServerOption
-	if len(p.Cfg.TLS.CertFile) > 0 || len(p.Cfg.TLS.KeyFile) > 0 {
-		creds, err := credentials.NewServerTLSFromFile(p.Cfg.TLS.CertFile, p.Cfg.TLS.KeyFile)
+	if p.Cfg.ServeOnHTTP {
+		if p.HTTPMux == nil {
+			return fmt.Errorf("http-server is required to serve grpc on http")
+		}
+	} else {
+		listenOpts, err := p.Cfg.Socket.ListenOptions()
+		if err != nil {
+			return fmt.Errorf("invalid socket config: %w", err)
+		}
+		lis, err := netutil.Listen(p.Cfg.Addr, listenOpts...)
 		if err != nil {
-			return fmt.Errorf("fail to generate credentials %v", err)
+			return err
 		}
-		opts = append(opts, grpc.Creds(creds))
+		p.listen = lis
 	}
+
+	var unary []grpc.UnaryServerInterceptor
+	var stream []grpc.StreamServerInterceptor
 	if p.Cfg.TraceEnable {
-		opts = append(opts,
-			grpc.UnaryInterceptor(grpccontext.UnaryServerInterceptor()),
-			grpc.StreamInterceptor(grpccontext.StreamServerInterceptor()),
+		unary = append(unary, grpccontext.UnaryServerInterceptor())
+		stream = append(stream, grpccontext.StreamServerInterceptor())
+	}
+	if p.Cfg.Metrics.Enable {
+		red := pkgmetrics.NewRED([]string{"service", "method", "type"}, "code",
+			pkgmetrics.WithNamespace("", "grpc_server"),
+			pkgmetrics.WithBuckets(p.Cfg.Metrics.Buckets),
+			pkgmetrics.WithSizeBuckets(p.Cfg.Metrics.SizeBuckets),
+			pkgmetrics.WithMaxLabelValues(p.Cfg.Metrics.MaxLabelValues),
 		)
+		unary = append(unary, unaryMetricsInterceptor(red))
+		stream = append(stream, streamMetricsInterceptor(red))
 	}
+	unary = append(unary, unaryDeadlineInterceptor(p.Cfg.MaxTimeout))
+	stream = append(stream, streamDeadlineInterceptor(p.Cfg.MaxTimeout))
+	unary = append(unary, p.interceptors.unaryInterceptor)
+	stream = append(stream, p.interceptors.streamInterceptor)
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	)
 	p.server = grpc.NewServer(opts...)
+	if p.Cfg.Health.Enable {
+		p.health = &healthServer{
+			server:   p.server,
+			checkers: p.Checkers,
+			interval: p.Cfg.Health.WatchInterval,
+		}
+		healthpb.RegisterHealthServer(p.server, p.health)
+	}
+	if p.Cfg.Reflection {
+		reflection.Register(p.server)
+	}
+	if p.Cfg.Channelz {
+		channelz.RegisterChannelzServiceToServer(p.server)
+	}
+	if p.Cfg.ServeOnHTTP {
+		p.HTTPMux.HandleGRPC(p.server)
+	}
 	return nil
 }
 
 func (p *provider) Start() error {
+	p.interceptors.start()
+	if p.Cfg.Registry.Enable {
+		if err := p.register(); err != nil {
+			return fmt.Errorf("fail to register grpc server: %w", err)
+		}
+	}
+	if p.listen == nil {
+		p.Log.Infof("serving grpc on http server at %s", p.HTTPMux.ListenAddr())
+		return nil
+	}
 	p.Log.Infof("starting grpc server at %s", p.Cfg.Addr)
 	return p.server.Serve(p.listen)
 }
 
+func (p *provider) register() error {
+	if p.Etcd == nil {
+		return fmt.Errorf("etcd is required")
+	}
+	addr, err := p.advertiseAddr()
+	if err != nil {
+		return err
+	}
+	services := p.Cfg.Registry.Services
+	if len(services) <= 0 {
+		for name := range p.server.GetServiceInfo() {
+			if !strings.HasPrefix(name, "grpc.") { // skip health, reflection and channelz
+				services = append(services, name)
+			}
+		}
+		sort.Strings(services)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	reg, err := discovery.Register(ctx, p.Etcd, p.Cfg.Registry.Prefix, services, &discovery.Endpoint{
+		Addr:     addr,
+		Weight:   p.Cfg.Registry.Weight,
+		Metadata: p.Cfg.Registry.Metadata,
+	}, p.Cfg.Registry.TTL, discovery.WithErrorHandler(func(err error) {
+		p.Log.Warnf("fail to keep grpc server registration: %s", err)
+	}))
+	if err != nil {
+		return err
+	}
+	p.registration = reg
+	p.Log.Infof("registered grpc server %s for services %v", addr, services)
+	return nil
+}
+
+// advertiseAddr returns the address to register, it's detected from the listener if advertise_addr is not set.
+func (p *provider) advertiseAddr() (string, error) {
+	if len(p.Cfg.Registry.AdvertiseAddr) > 0 {
+		return p.Cfg.Registry.AdvertiseAddr, nil
+	}
+	var listen net.Addr
+	if p.listen != nil {
+		listen = p.listen.Addr()
+	} else {
+		listen = p.HTTPMux.ListenAddr()
+	}
+	if _, ok := listen.(*net.TCPAddr); !ok {
+		return "", fmt.Errorf("advertise_addr is required to register the server not listening on tcp, listen: %v", listen)
+	}
+	return discovery.AdvertiseAddr(listen.String())
+}
+
 func (p *provider) Close() error {
-	p.server.Stop()
+	if p.registration != nil {
+		if err := p.registration.Close(); err != nil {
+			p.Log.Warnf("fail to deregister grpc server: %s", err)
+		}
+	}
+	if p.health != nil {
+		p.health.shutdown()
+	}
+	if p.listen == nil {
+		// connections are served by http-server, which sends GOAWAY and drains them on its shutdown,
+		// and GracefulStop can't be used, it panics on draining transports of ServeHTTP.
+		return nil
+	}
+	if p.Cfg.ShutdownTimeout <= 0 {
+		p.server.GracefulStop()
+		return nil
+	}
+	done := make(chan struct{})
+	go func() {
+		p.server.GracefulStop()
+		close(done)
+	}()
+	timer := time.NewTimer(p.Cfg.ShutdownTimeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+		p.Log.Warnf("grpc server graceful stop timeout after %s, stop it forcibly", p.Cfg.ShutdownTimeout)
+		p.server.Stop()
+	}
 	return nil
 }
 
+// AddUnaryInterceptor .
+func (p *provider) AddUnaryInterceptor(interceptors ...grpc.UnaryServerInterceptor) {
+	if !p.interceptors.addUnary(interceptors...) {
+		p.Log.Errorf("grpc server has started, unary interceptors are ignored")
+	}
+}
+
+// AddStreamInterceptor .
+func (p *provider) AddStreamInterceptor(interceptors ...grpc.StreamServerInterceptor) {
+	if !p.interceptors.addStream(interceptors...) {
+		p.Log.Errorf("grpc server has started, stream interceptors are ignored")
+	}
+}
+
 func (p *provider) Provide(ctx servicehub.DependencyContext, args ...interface{}) interface{} {
+	if ctx.Service() == "grpc-server-interceptors" || ctx.Type() == interceptorsType {
+		return p
+	}
 	return p.server
 }
 
+var interceptorsType = reflect.TypeOf((*Interceptors)(nil)).Elem()
+
 func init() {
 	servicehub.Register("grpc-server", &servicehub.Spec{
-		Services: []string{"grpc-server"},
+		Services: []string{"grpc-server", "grpc-server-interceptors"},
 		Types: []reflect.Type{
 			reflect.TypeOf((*grpc.Server)(nil)),
 			reflect.TypeOf((*Interface)(nil)).Elem(),
+			interceptorsType,
 		},
 		Description: "grpc server",
 		ConfigFunc:  func() interface{} { return &config{} },
@@ -0,0 +1,142 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcserver
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/erda-project/erda-infra/base/logs/logrusx"
+	"github.com/erda-project/erda-infra/providers/httpserver/server"
+)
+
+type testGRPCMux struct {
+	handler http.Handler
+	addr    net.Addr
+}
+
+func (m *testGRPCMux) HandleGRPC(h http.Handler) { m.handler = h }
+func (m *testGRPCMux) ListenAddr() net.Addr      { return m.addr }
+
+func TestProviderCloseServeOnHTTP(t *testing.T) {
+	mux := &testGRPCMux{}
+	p := &provider{
+		Cfg:     &config{ServeOnHTTP: true},
+		Log:     logrusx.New(),
+		HTTPMux: mux,
+	}
+	p.Cfg.Health.Enable = true
+	p.Cfg.Health.WatchInterval = 10 * time.Millisecond
+	if err := p.Init(nil); err != nil {
+		t.Fatalf("Init() error: %s", err)
+	}
+	p.interceptors.start()
+
+	srv := server.New(false, &echo.DefaultBinder{}, nil, server.WithH2C())
+	srv.HandleGRPC(mux.handler)
+	addr, err := srv.Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Start(addr.String())
+	defer srv.Close()
+
+	conn, err := grpc.Dial(addr.String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Dial() error: %s", err)
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := healthpb.NewHealthClient(conn).Watch(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("Watch() error: %s", err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("Recv() error: %s", err)
+	}
+
+	// the Watch stream is in flight, Close must not drain it by GracefulStop
+	if err := p.Close(); err != nil {
+		t.Fatalf("Close() error: %s", err)
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv() after Close() error: %s", err)
+	}
+	if resp.Status != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("status after Close() = %s, want NOT_SERVING", resp.Status)
+	}
+
+	// http server drains the connection after the stream is finished
+	cancel()
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		t.Errorf("Shutdown() error: %s", err)
+	}
+}
+
+func TestProviderAdvertiseAddr(t *testing.T) {
+	unix, err := net.Listen("unix", filepath.Join(t.TempDir(), "grpc.sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unix.Close()
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tcp.Close()
+	tests := []struct {
+		name      string
+		listen    net.Listener
+		mux       *testGRPCMux
+		advertise string
+		want      string
+		wantErr   bool
+	}{
+		{name: "tcp", listen: tcp, want: tcp.Addr().String()},
+		{name: "http server on tcp", mux: &testGRPCMux{addr: tcp.Addr()}, want: tcp.Addr().String()},
+		{name: "unix", listen: unix, wantErr: true},
+		{name: "http server on unix", mux: &testGRPCMux{addr: unix.Addr()}, wantErr: true},
+		{name: "unix with advertise_addr", listen: unix, advertise: "10.0.0.1:7070", want: "10.0.0.1:7070"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &provider{Cfg: &config{}, listen: tt.listen}
+			if tt.mux != nil {
+				p.HTTPMux = tt.mux
+			}
+			p.Cfg.Registry.AdvertiseAddr = tt.advertise
+			got, err := p.advertiseAddr()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("advertiseAddr() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("advertiseAddr() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Kind is the kind of probe.
+type Kind string
+
+// kinds of probe, compatible with kubernetes probes.
+const (
+	Liveness  Kind = "liveness"
+	Readiness Kind = "readiness"
+	Startup   Kind = "startup"
+)
+
+var allKinds = []Kind{Liveness, Readiness, Startup}
+
+// CheckOption .
+type CheckOption func(c *checker)
+
+// WithName sets the name of checker, the name of registering provider is used by default.
+func WithName(name string) CheckOption {
+	return func(c *checker) {
+		c.name = name
+	}
+}
+
+// WithTimeout sets the timeout of checker, health.timeout is used by default.
+func WithTimeout(timeout time.Duration) CheckOption {
+	return func(c *checker) {
+		c.timeout = timeout
+	}
+}
+
+// WithCritical sets whether the failure of checker fails the probe, true by default.
+// The failures of non-critical checkers are reported only.
+func WithCritical(critical bool) CheckOption {
+	return func(c *checker) {
+		c.critical = critical
+	}
+}
+
+// WithKinds sets the probes that run the checker, readiness and startup by default.
+func WithKinds(kinds ...Kind) CheckOption {
+	return func(c *checker) {
+		c.kinds = kinds
+	}
+}
+
+type checker struct {
+	component string
+	name      string
+	fn        Checker
+	timeout   time.Duration
+	critical  bool
+	kinds     []Kind
+}
+
+func newChecker(component string, fn Checker, opts ...CheckOption) *checker {
+	c := &checker{
+		component: component,
+		name:      component,
+		fn:        fn,
+		critical:  true,
+		kinds:     []Kind{Readiness, Startup},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func (c *checker) hasKind(kind Kind) bool {
+	for _, k := range c.kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// check runs the checker within timeout, the checker is abandoned if it doesn't return in time.
+func (c *checker) check(ctx context.Context, timeout time.Duration) *CheckResult {
+	if c.timeout > 0 {
+		timeout = c.timeout
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if err := recover(); err != nil {
+				done <- fmt.Errorf("panic: %v", err)
+			}
+		}()
+		done <- c.fn(ctx)
+	}()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	r := &CheckResult{
+		Component: c.component,
+		Name:      c.name,
+		Critical:  c.critical,
+		Healthy:   err == nil,
+		Duration:  time.Since(start),
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	return r
+}
+
+// CheckResult is the result of a checker.
+type CheckResult struct {
+	Component string        `json:"component"`
+	Name      string        `json:"name"`
+	Critical  bool          `json:"critical"`
+	Healthy   bool          `json:"health"`
+	Error     string        `json:"error,omitempty"`
+	Duration  time.Duration `json:"-"`
+}
+
+// MarshalJSON .
+func (r *CheckResult) MarshalJSON() ([]byte, error) {
+	type result CheckResult
+	return json.Marshal(&struct {
+		*result
+		Duration string `json:"duration"`
+	}{
+		result:   (*result)(r),
+		Duration: r.Duration.String(),
+	})
+}
+
+// Report is the result of a probe.
+type Report struct {
+	Kind      Kind
+	Healthy   bool
+	Checks    []*CheckResult
+	CheckedAt time.Time
+	Duration  time.Duration
+}
+
+// Errors returns the errors of checks grouped by component, components without errors are included with nil.
+func (r *Report) Errors() map[string][]string {
+	status := make(map[string][]string)
+	for _, c := range r.Checks {
+		errors := status[c.Component]
+		if !c.Healthy {
+			errors = append(errors, c.Error)
+		}
+		status[c.Component] = errors
+	}
+	return status
+}
+
+// MarshalJSON .
+func (r *Report) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"kind":       r.Kind,
+		"health":     r.Healthy,
+		"checkers":   r.Errors(),
+		"checks":     r.Checks,
+		"checked_at": r.CheckedAt,
+		"duration":   r.Duration.String(),
+	})
+}
+
+// runChecks runs checkers in parallel and reports, the remaining checkers are canceled on the first critical failure if abort.
+func runChecks(ctx context.Context, kind Kind, checkers []*checker, timeout time.Duration, abort bool) *Report {
+	report := &Report{
+		Kind:      kind,
+		Healthy:   true,
+		Checks:    make([]*CheckResult, len(checkers)),
+		CheckedAt: time.Now(),
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var wg sync.WaitGroup
+	for i, c := range checkers {
+		wg.Add(1)
+		go func(i int, c *checker) {
+			defer wg.Done()
+			r := c.check(ctx, timeout)
+			report.Checks[i] = r
+			if abort && !r.Healthy && r.Critical {
+				cancel()
+			}
+		}(i, c)
+	}
+	wg.Wait()
+	for _, r := range report.Checks {
+		if !r.Healthy && r.Critical {
+			report.Healthy = false
+		}
+	}
+	report.Duration = time.Since(report.CheckedAt)
+	return report
+}
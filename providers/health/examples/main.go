@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/erda-project/erda-infra/base/servicehub"
 	"github.com/erda-project/erda-infra/providers/health"
@@ -30,6 +31,8 @@ type provider struct {
 func (p *provider) Init(ctx servicehub.Context) error {
 	h := ctx.Service("health").(health.Interface)
 	h.Register(p.HealthCheck)
+	h.Register(p.CacheCheck, health.WithName("cache"), health.WithCritical(false), health.WithTimeout(time.Second))
+	h.Register(func(context.Context) error { return nil }, health.WithName("alive"), health.WithKinds(health.Liveness))
 	return nil
 }
 
@@ -37,6 +40,15 @@ func (p *provider) HealthCheck(context.Context) error {
 	return fmt.Errorf("error message")
 }
 
+func (p *provider) CacheCheck(ctx context.Context) error {
+	select {
+	case <-time.After(2 * time.Second):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func init() {
 	servicehub.Register("examples", &servicehub.Spec{
 		Services:     []string{"hello"},
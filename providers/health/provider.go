@@ -16,10 +16,13 @@ package health
 
 import (
 	"context"
-	"encoding/json"
 	"net/http"
 	"reflect"
 	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
 
 	"github.com/erda-project/erda-infra/base/servicehub"
 	"github.com/erda-project/erda-infra/providers/httpserver"
@@ -30,77 +33,189 @@ type Checker func(context.Context) error
 
 // Interface .
 type Interface interface {
-	Register(Checker)
+	Register(c Checker, opts ...CheckOption)
+}
+
+// Checkers runs all registered checkers.
+type Checkers interface {
+	Check(ctx context.Context) (health bool, status map[string][]string)
+}
+
+// Reporter reports the results of probes.
+type Reporter interface {
+	Report(ctx context.Context, kind Kind) *Report
 }
 
+var (
+	checkersType = reflect.TypeOf((*Checkers)(nil)).Elem()
+	reporterType = reflect.TypeOf((*Reporter)(nil)).Elem()
+)
+
 type config struct {
-	Path           []string `file:"path" default:"/health" desc:"http path"`
-	HealthStatus   int      `file:"health_status" default:"200" desc:"http response status if health"`
-	UnhealthStatus int      `file:"unhealth_status" default:"503" desc:"http response status if unhealth"`
-	HealthBody     string   `file:"health_body" desc:"http response body if health"`
-	UnhealthBody   string   `file:"unhealth_body" desc:"http response body if unhealth"`
-	ContentType    string   `file:"content_type" default:"application/json" desc:"http response Content-Type"`
-	AbortOnError   bool     `file:"abort_on_error"`
+	Path           []string      `file:"path" default:"/health" desc:"http path of readiness probe, for compatibility"`
+	LivenessPath   []string      `file:"liveness_path" default:"/health/liveness" desc:"http path of liveness probe"`
+	ReadinessPath  []string      `file:"readiness_path" default:"/health/readiness" desc:"http path of readiness probe"`
+	StartupPath    []string      `file:"startup_path" default:"/health/startup" desc:"http path of startup probe"`
+	HealthStatus   int           `file:"health_status" default:"200" desc:"http response status if health"`
+	UnhealthStatus int           `file:"unhealth_status" default:"503" desc:"http response status if unhealth"`
+	HealthBody     string        `file:"health_body" desc:"http response body if health"`
+	UnhealthBody   string        `file:"unhealth_body" desc:"http response body if unhealth"`
+	ContentType    string        `file:"content_type" default:"application/json" desc:"http response Content-Type"`
+	AbortOnError   bool          `file:"abort_on_error" desc:"cancel the running checkers of a probe on the first critical failure"`
+	Timeout        time.Duration `file:"timeout" default:"5s" env:"HEALTH_CHECK_TIMEOUT" desc:"default timeout of each checker"`
+	Interval       time.Duration `file:"interval" default:"10s" env:"HEALTH_CHECK_INTERVAL" desc:"interval to refresh results in background, results are cached for it, 0 to run checkers on every request"`
 }
 
 type provider struct {
 	Cfg          *config
 	Router       httpserver.Router `autowired:"http-server"`
-	names        []string
-	checkers     map[string][]Checker
+	HTTPStatus   httpserver.Status `autowired:"http-server"`
+	lock         sync.RWMutex
+	checkers     []*checker
+	caches       map[Kind]*reportCache
 	healthBody   []byte
 	unhealthBody []byte
 }
 
+// reportCache keeps the last report of a probe, concurrent refreshes share one run,
+// and the last report is served to probes while it's running.
+type reportCache struct {
+	lock    sync.Mutex
+	report  *Report
+	running bool
+	group   singleflight.Group
+}
+
 func (p *provider) Init(ctx servicehub.Context) error {
-	for _, path := range p.Cfg.Path {
-		p.Router.GET(path, p.handler)
+	paths := map[Kind][]string{
+		Liveness:  p.Cfg.LivenessPath,
+		Readiness: append(append([]string{}, p.Cfg.Path...), p.Cfg.ReadinessPath...),
+		Startup:   p.Cfg.StartupPath,
+	}
+	for _, kind := range allKinds {
+		for _, path := range paths[kind] {
+			p.Router.GET(path, p.handler(kind))
+		}
 	}
 	p.healthBody = []byte(p.Cfg.HealthBody)
 	p.unhealthBody = []byte(p.Cfg.UnhealthBody)
 	return nil
 }
 
-func (p *provider) handler(resp http.ResponseWriter, req *http.Request) error {
-	status := make(map[string]interface{})
-	health := true
-	for _, key := range p.names {
-		var errors []interface{}
-		for _, checker := range p.checkers[key] {
-			err := checker(context.Background())
-			if err != nil {
-				errors = append(errors, err.Error())
-				health = false
-				if p.Cfg.AbortOnError {
-					break
-				}
-			}
+// Run refreshes the results of probes in background.
+func (p *provider) Run(ctx context.Context) error {
+	if p.Cfg.Interval <= 0 {
+		return nil
+	}
+	ticker := time.NewTicker(p.Cfg.Interval)
+	defer ticker.Stop()
+	for {
+		for _, kind := range allKinds {
+			p.refresh(ctx, kind, true)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
 		}
-		status[key] = errors
 	}
-	resp.Header().Set("Content-Type", p.Cfg.ContentType)
-	var body []byte
-	if health {
-		resp.WriteHeader(p.Cfg.HealthStatus)
-		body = p.healthBody
+}
+
+// Report returns the result of probe, which is cached for health.interval, and the last result is returned while it's refreshing.
+// The startup probe isn't run again once it succeeds, and readiness fails while http server is draining.
+func (p *provider) Report(ctx context.Context, kind Kind) *Report {
+	var report *Report
+	if p.Cfg.Interval <= 0 {
+		report = p.refresh(ctx, kind, true)
 	} else {
-		resp.WriteHeader(p.Cfg.UnhealthStatus)
-		body = p.unhealthBody
+		// the result is shared with other requests, so it's not bound to ctx of request
+		report = p.refresh(context.Background(), kind, false)
 	}
-	if len(body) > 0 {
-		resp.Write(body)
-	} else {
-		byts, _ := json.Marshal(map[string]interface{}{
-			"health":   health,
-			"checkers": status,
-		})
-		resp.Write(byts)
+	if kind == Readiness && p.HTTPStatus != nil && p.HTTPStatus.Draining() {
+		draining := *report
+		draining.Healthy = false
+		draining.Checks = append([]*CheckResult{{
+			Component: "http-server",
+			Name:      "http-server",
+			Critical:  true,
+			Error:     "draining",
+		}}, report.Checks...)
+		return &draining
+	}
+	return report
+}
+
+func (p *provider) refresh(ctx context.Context, kind Kind, force bool) *Report {
+	c := p.caches[kind]
+	c.lock.Lock()
+	report, running := c.report, c.running
+	c.lock.Unlock()
+	if report != nil {
+		if kind == Startup && report.Healthy {
+			return report
+		}
+		if !force && (running || time.Since(report.CheckedAt) < p.Cfg.Interval) {
+			return report
+		}
+	}
+	v, _, _ := c.group.Do(string(kind), func() (interface{}, error) {
+		c.lock.Lock()
+		c.running = true
+		c.lock.Unlock()
+		report := runChecks(ctx, kind, p.checkersOf(kind), p.Cfg.Timeout, p.Cfg.AbortOnError)
+		c.lock.Lock()
+		c.report, c.running = report, false
+		c.lock.Unlock()
+		return report, nil
+	})
+	return v.(*Report)
+}
+
+func (p *provider) checkersOf(kind Kind) []*checker {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	var list []*checker
+	for _, c := range p.checkers {
+		if c.hasKind(kind) {
+			list = append(list, c)
+		}
+	}
+	return list
+}
+
+// Check runs the readiness probe.
+func (p *provider) Check(ctx context.Context) (bool, map[string][]string) {
+	report := p.Report(ctx, Readiness)
+	return report.Healthy, report.Errors()
+}
+
+func (p *provider) handler(kind Kind) func(resp http.ResponseWriter, req *http.Request) error {
+	return func(resp http.ResponseWriter, req *http.Request) error {
+		report := p.Report(req.Context(), kind)
+		resp.Header().Set("Content-Type", p.Cfg.ContentType)
+		var body []byte
+		if report.Healthy {
+			resp.WriteHeader(p.Cfg.HealthStatus)
+			body = p.healthBody
+		} else {
+			resp.WriteHeader(p.Cfg.UnhealthStatus)
+			body = p.unhealthBody
+		}
+		if len(body) > 0 {
+			resp.Write(body)
+		} else {
+			byts, _ := report.MarshalJSON()
+			resp.Write(byts)
+		}
+		return nil
 	}
-	return nil
 }
 
 // Provide .
 func (p *provider) Provide(ctx servicehub.DependencyContext, args ...interface{}) interface{} {
+	if ctx.Type() == checkersType || ctx.Type() == reporterType {
+		return p
+	}
 	return &service{
 		name: ctx.Caller(),
 		p:    p,
@@ -112,26 +227,28 @@ type service struct {
 	p    *provider
 }
 
-func (s *service) Register(c Checker) {
-	list, ok := s.p.checkers[s.name]
-	if !ok {
-		s.p.names = append(s.p.names, s.name)
-		sort.Strings(s.p.names)
-	}
-	s.p.checkers[s.name] = append(list, c)
+func (s *service) Register(c Checker, opts ...CheckOption) {
+	s.p.lock.Lock()
+	defer s.p.lock.Unlock()
+	s.p.checkers = append(s.p.checkers, newChecker(s.name, c, opts...))
+	sort.SliceStable(s.p.checkers, func(i, j int) bool {
+		return s.p.checkers[i].component < s.p.checkers[j].component
+	})
 }
 
 func init() {
 	servicehub.Register("health", &servicehub.Spec{
 		Services:     []string{"health", "health-checker"},
-		Types:        []reflect.Type{reflect.TypeOf((*Interface)(nil)).Elem()},
+		Types:        []reflect.Type{reflect.TypeOf((*Interface)(nil)).Elem(), checkersType, reporterType},
 		Dependencies: []string{"http-server"},
-		Description:  "http health check",
+		Description:  "http health check with liveness, readiness and startup probes",
 		ConfigFunc:   func() interface{} { return &config{} },
 		Creator: func() servicehub.Provider {
-			return &provider{
-				checkers: make(map[string][]Checker),
+			p := &provider{caches: make(map[Kind]*reportCache)}
+			for _, kind := range allKinds {
+				p.caches[kind] = &reportCache{}
 			}
+			return p
 		},
 	})
 }
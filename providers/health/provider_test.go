@@ -0,0 +1,271 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type draining bool
+
+func (d draining) Draining() bool { return bool(d) }
+
+func newTestProvider(cfg *config) *provider {
+	p := &provider{Cfg: cfg, caches: make(map[Kind]*reportCache)}
+	for _, kind := range allKinds {
+		p.caches[kind] = &reportCache{}
+	}
+	return p
+}
+
+func TestReport(t *testing.T) {
+	failed := func(context.Context) error { return errors.New("down") }
+	ok := func(context.Context) error { return nil }
+	slow := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	tests := []struct {
+		name    string
+		kind    Kind
+		setup   func(p *provider)
+		healthy bool
+		errors  map[string][]string
+	}{
+		{
+			name: "all ok",
+			kind: Readiness,
+			setup: func(p *provider) {
+				(&service{name: "a", p: p}).Register(ok)
+				(&service{name: "b", p: p}).Register(ok)
+			},
+			healthy: true,
+			errors:  map[string][]string{"a": nil, "b": nil},
+		},
+		{
+			name: "critical failure",
+			kind: Readiness,
+			setup: func(p *provider) {
+				(&service{name: "a", p: p}).Register(failed)
+				(&service{name: "b", p: p}).Register(ok)
+			},
+			healthy: false,
+			errors:  map[string][]string{"a": {"down"}, "b": nil},
+		},
+		{
+			name: "non-critical failure",
+			kind: Readiness,
+			setup: func(p *provider) {
+				(&service{name: "a", p: p}).Register(failed, WithCritical(false))
+			},
+			healthy: true,
+			errors:  map[string][]string{"a": {"down"}},
+		},
+		{
+			name: "timeout",
+			kind: Readiness,
+			setup: func(p *provider) {
+				(&service{name: "a", p: p}).Register(slow, WithTimeout(10*time.Millisecond))
+			},
+			healthy: false,
+			errors:  map[string][]string{"a": {context.DeadlineExceeded.Error()}},
+		},
+		{
+			name: "panic",
+			kind: Readiness,
+			setup: func(p *provider) {
+				(&service{name: "a", p: p}).Register(func(context.Context) error { panic("boom") })
+			},
+			healthy: false,
+			errors:  map[string][]string{"a": {"panic: boom"}},
+		},
+		{
+			name: "liveness runs liveness checkers only",
+			kind: Liveness,
+			setup: func(p *provider) {
+				(&service{name: "a", p: p}).Register(failed)
+				(&service{name: "b", p: p}).Register(ok, WithKinds(Liveness))
+			},
+			healthy: true,
+			errors:  map[string][]string{"b": nil},
+		},
+		{
+			name: "draining",
+			kind: Readiness,
+			setup: func(p *provider) {
+				p.HTTPStatus = draining(true)
+				(&service{name: "a", p: p}).Register(ok)
+			},
+			healthy: false,
+			errors:  map[string][]string{"a": nil, "http-server": {"draining"}},
+		},
+		{
+			name: "liveness ignores draining",
+			kind: Liveness,
+			setup: func(p *provider) {
+				p.HTTPStatus = draining(true)
+			},
+			healthy: true,
+			errors:  map[string][]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestProvider(&config{Timeout: time.Second})
+			tt.setup(p)
+			report := p.Report(context.Background(), tt.kind)
+			assert.Equal(t, tt.healthy, report.Healthy)
+			assert.Equal(t, tt.errors, report.Errors())
+		})
+	}
+}
+
+func TestParallel(t *testing.T) {
+	p := newTestProvider(&config{Timeout: time.Second})
+	for i := 0; i < 5; i++ {
+		(&service{name: "a", p: p}).Register(func(context.Context) error {
+			time.Sleep(50 * time.Millisecond)
+			return nil
+		})
+	}
+	start := time.Now()
+	report := p.Report(context.Background(), Readiness)
+	assert.True(t, report.Healthy)
+	assert.Less(t, int64(time.Since(start)), int64(200*time.Millisecond))
+}
+
+func TestAbortOnError(t *testing.T) {
+	p := newTestProvider(&config{Timeout: time.Second, AbortOnError: true})
+	(&service{name: "a", p: p}).Register(func(context.Context) error { return errors.New("down") })
+	(&service{name: "b", p: p}).Register(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	start := time.Now()
+	report := p.Report(context.Background(), Readiness)
+	assert.False(t, report.Healthy)
+	assert.Equal(t, []string{context.Canceled.Error()}, report.Errors()["b"])
+	assert.Less(t, int64(time.Since(start)), int64(500*time.Millisecond))
+}
+
+func TestCache(t *testing.T) {
+	var calls, healthy int32
+	check := func(context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		if atomic.LoadInt32(&healthy) == 0 {
+			return errors.New("starting")
+		}
+		return nil
+	}
+
+	p := newTestProvider(&config{Timeout: time.Second, Interval: time.Hour})
+	(&service{name: "a", p: p}).Register(check)
+	for i := 0; i < 3; i++ {
+		p.Report(context.Background(), Readiness)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls), "cached for interval")
+
+	// startup is run until it succeeds, and never again
+	p = newTestProvider(&config{Timeout: time.Second})
+	(&service{name: "a", p: p}).Register(check)
+	atomic.StoreInt32(&calls, 0)
+	assert.False(t, p.Report(context.Background(), Startup).Healthy)
+	atomic.StoreInt32(&healthy, 1)
+	assert.True(t, p.Report(context.Background(), Startup).Healthy)
+	atomic.StoreInt32(&healthy, 0)
+	assert.True(t, p.Report(context.Background(), Startup).Healthy)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestCacheWhileRefreshing(t *testing.T) {
+	var slow int32
+	started, release := make(chan struct{}), make(chan struct{})
+	p := newTestProvider(&config{Timeout: 5 * time.Second, Interval: time.Millisecond})
+	(&service{name: "a", p: p}).Register(func(context.Context) error {
+		if atomic.LoadInt32(&slow) == 0 {
+			return nil
+		}
+		close(started)
+		<-release
+		return errors.New("down")
+	})
+	last := p.Report(context.Background(), Readiness)
+	assert.True(t, last.Healthy)
+
+	atomic.StoreInt32(&slow, 1)
+	done := make(chan *Report, 1)
+	go func() { done <- p.refresh(context.Background(), Readiness, true) }()
+	<-started
+
+	start := time.Now()
+	report := p.Report(context.Background(), Readiness)
+	assert.Same(t, last, report, "the last report is served while refreshing")
+	assert.Less(t, int64(time.Since(start)), int64(100*time.Millisecond))
+
+	close(release)
+	assert.False(t, (<-done).Healthy)
+}
+
+func TestHandler(t *testing.T) {
+	p := newTestProvider(&config{
+		Timeout:        time.Second,
+		HealthStatus:   http.StatusOK,
+		UnhealthStatus: http.StatusServiceUnavailable,
+		ContentType:    "application/json",
+	})
+	(&service{name: "a", p: p}).Register(func(context.Context) error { return errors.New("down") }, WithName("db"))
+
+	rec := httptest.NewRecorder()
+	err := p.handler(Readiness)(rec, httptest.NewRequest(http.MethodGet, "/health/readiness", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	var body struct {
+		Kind     Kind                `json:"kind"`
+		Health   bool                `json:"health"`
+		Checkers map[string][]string `json:"checkers"`
+		Checks   []struct {
+			Component string `json:"component"`
+			Name      string `json:"name"`
+			Critical  bool   `json:"critical"`
+			Health    bool   `json:"health"`
+			Error     string `json:"error"`
+			Duration  string `json:"duration"`
+		} `json:"checks"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, Readiness, body.Kind)
+	assert.False(t, body.Health)
+	assert.Equal(t, map[string][]string{"a": {"down"}}, body.Checkers)
+	if assert.Len(t, body.Checks, 1) {
+		assert.Equal(t, "db", body.Checks[0].Name)
+		assert.Equal(t, "down", body.Checks[0].Error)
+		_, err := time.ParseDuration(body.Checks[0].Duration)
+		assert.NoError(t, err)
+	}
+
+	rec = httptest.NewRecorder()
+	assert.NoError(t, p.handler(Liveness)(rec, httptest.NewRequest(http.MethodGet, "/health/liveness", nil)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
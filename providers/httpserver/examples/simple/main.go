@@ -20,10 +20,12 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/erda-project/erda-infra/base/logs"
 	"github.com/erda-project/erda-infra/base/servicehub"
 	"github.com/erda-project/erda-infra/providers/httpserver"
+	"github.com/erda-project/erda-infra/providers/httpserver/ratelimit"
 )
 
 type config struct {
@@ -62,6 +64,14 @@ func (p *provider) Init(ctx servicehub.Context) error {
 		),
 	)
 
+	// limit requests of each client ip to 10 per second, use ratelimit.NewRedisStore to share limits between instances
+	routes.GET("/hello/limited",
+		func(resp http.ResponseWriter, req *http.Request) {
+			resp.Write([]byte(p.Cfg.Message))
+		},
+		ratelimit.Route(ratelimit.NewMemoryStore(), ratelimit.Every(10, time.Second), ratelimit.WithKeyFunc(ratelimit.ByIP())),
+	)
+
 	// request parameter is struct pointer, response is: status int, data interface{}, err error
 	routes.POST("/hello/simple", func(body *struct {
 		Name string `json:"name"`
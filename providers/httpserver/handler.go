@@ -130,10 +130,42 @@ func (r *router) add(method, path string, handler interface{}, inters []server.M
 		}
 		echoHandler = handler
 	}
+	if r.metrics != nil {
+		echoHandler = r.observe(method, path, echoHandler)
+	}
 	r.tx.Add(method, path, echoHandler)
 	return echoHandler
 }
 
+// observe records metrics of route labelled by the route template instead of the request path.
+func (r *router) observe(method, path string, handler server.HandlerFunc) server.HandlerFunc {
+	return func(c server.Context) error {
+		o := r.metrics.Begin(r.group, method, path)
+		status, size := http.StatusInternalServerError, -1 // recorded if handler panics
+		defer func() { o.End(strconv.Itoa(status), size) }()
+		err := handler(c)
+		status = c.Response().Status
+		if err != nil && !c.Response().Committed {
+			status = errorStatus(err)
+		}
+		if c.Response().Committed {
+			size = int(c.Response().Size)
+		}
+		return err
+	}
+}
+
+// errorStatus returns the status of response for err handled by echo.
+func errorStatus(err error) int {
+	if he, ok := err.(*echo.HTTPError); ok {
+		return he.Code
+	}
+	if se, ok := err.(interface{ HTTPStatus() int }); ok {
+		return se.HTTPStatus()
+	}
+	return http.StatusInternalServerError
+}
+
 var (
 	readerType      = reflect.TypeOf((*io.Reader)(nil)).Elem()
 	readCloserType  = reflect.TypeOf((*io.ReadCloser)(nil)).Elem()
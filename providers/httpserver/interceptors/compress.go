@@ -0,0 +1,156 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptors
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/labstack/echo"
+)
+
+// CompressConfig .
+type CompressConfig struct {
+	// Encodings are the content encodings in order of preference, gzip and br are supported.
+	Encodings []string
+	// Level is the compression level, the default level of encoding is used if it's zero.
+	Level int
+}
+
+// Compress compresses response bodies by the encoding negotiated with Accept-Encoding header.
+func Compress(cfg CompressConfig) echo.MiddlewareFunc {
+	encodings := cfg.Encodings
+	if len(encodings) <= 0 {
+		encodings = []string{"br", "gzip"}
+	}
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req, res := c.Request(), c.Response()
+			res.Header().Add(echo.HeaderVary, echo.HeaderAcceptEncoding)
+			if len(req.Header.Get("Upgrade")) > 0 {
+				return next(c)
+			}
+			encoding := negotiateEncoding(req.Header.Get(echo.HeaderAcceptEncoding), encodings)
+			if len(encoding) <= 0 {
+				return next(c)
+			}
+			cw, err := newCompressWriter(encoding, cfg.Level, res.Writer)
+			if err != nil {
+				return err
+			}
+			res.Header().Set(echo.HeaderContentEncoding, encoding)
+			writer := res.Writer
+			res.Writer = &compressResponseWriter{Writer: cw, ResponseWriter: writer}
+			defer func() {
+				if res.Size == 0 {
+					// nothing is written, e.g. 204 or 304
+					if res.Header().Get(echo.HeaderContentEncoding) == encoding {
+						res.Header().Del(echo.HeaderContentEncoding)
+					}
+					res.Writer = writer
+					return
+				}
+				cw.Close()
+				res.Writer = writer
+			}()
+			return next(c)
+		}
+	}
+}
+
+func negotiateEncoding(accept string, supported []string) string {
+	if len(accept) <= 0 {
+		return ""
+	}
+	accepted := make(map[string]bool)
+	for _, item := range strings.Split(accept, ",") {
+		parts := strings.Split(strings.TrimSpace(item), ";")
+		name := strings.ToLower(strings.TrimSpace(parts[0]))
+		q := 1.0
+		for _, param := range parts[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		accepted[name] = q > 0
+	}
+	for _, enc := range supported {
+		if ok, found := accepted[enc]; found {
+			if ok {
+				return enc
+			}
+			continue
+		}
+		if accepted["*"] {
+			return enc
+		}
+	}
+	return ""
+}
+
+func newCompressWriter(encoding string, level int, w io.Writer) (io.WriteCloser, error) {
+	switch encoding {
+	case "gzip":
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		return gzip.NewWriterLevel(w, level)
+	case "br":
+		if level == 0 {
+			level = brotli.DefaultCompression
+		}
+		return brotli.NewWriterLevel(w, level), nil
+	}
+	return nil, echo.NewHTTPError(http.StatusInternalServerError, "unsupported content encoding "+encoding)
+}
+
+type compressResponseWriter struct {
+	io.Writer
+	http.ResponseWriter
+}
+
+func (w *compressResponseWriter) WriteHeader(code int) {
+	w.Header().Del(echo.HeaderContentLength)
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *compressResponseWriter) Write(b []byte) (int, error) {
+	if len(w.Header().Get(echo.HeaderContentType)) <= 0 {
+		w.Header().Set(echo.HeaderContentType, http.DetectContentType(b))
+	}
+	return w.Writer.Write(b)
+}
+
+func (w *compressResponseWriter) Flush() {
+	if f, ok := w.Writer.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *compressResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
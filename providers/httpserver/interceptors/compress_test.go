@@ -0,0 +1,86 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptors
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/labstack/echo"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	supported := []string{"br", "gzip"}
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", ""},
+		{"identity", ""},
+		{"gzip", "gzip"},
+		{"gzip, br", "br"},
+		{"br;q=0, gzip", "gzip"},
+		{"br;q=0.0, gzip;q=0.5", "gzip"},
+		{"*", "br"},
+		{"br;q=0, *", "gzip"},
+	}
+	for _, tt := range tests {
+		if got := negotiateEncoding(tt.accept, supported); got != tt.want {
+			t.Errorf("negotiateEncoding(%q) = %q, want %q", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestCompress(t *testing.T) {
+	const body = "hello hello hello hello hello"
+	handler := Compress(CompressConfig{})(func(c echo.Context) error {
+		return c.String(http.StatusOK, body)
+	})
+	tests := []struct {
+		accept string
+		decode func(r io.Reader) (io.Reader, error)
+	}{
+		{"", func(r io.Reader) (io.Reader, error) { return r, nil }},
+		{"gzip", func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }},
+		{"br", func(r io.Reader) (io.Reader, error) { return brotli.NewReader(r), nil }},
+	}
+	e := echo.New()
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(echo.HeaderAcceptEncoding, tt.accept)
+		rec := httptest.NewRecorder()
+		if err := handler(e.NewContext(req, rec)); err != nil {
+			t.Fatal(err)
+		}
+		if got := rec.Header().Get(echo.HeaderContentEncoding); got != tt.accept {
+			t.Errorf("Content-Encoding = %q, want %q", got, tt.accept)
+		}
+		r, err := tt.decode(rec.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != body {
+			t.Errorf("body with %q = %q, want %q", tt.accept, data, body)
+		}
+	}
+}
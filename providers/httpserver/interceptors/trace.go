@@ -0,0 +1,82 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptors
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+
+	injectcontext "github.com/erda-project/erda-infra/pkg/trace/inject/context"
+)
+
+const tracerName = "github.com/erda-project/erda-infra/providers/httpserver"
+
+// Trace records a server span named by method and route for http request,
+// and keeps the request context in the goroutine context for hook-free client wrappers.
+// The global tracer provider and propagator are used if tp or propagator is nil.
+func Trace(tp trace.TracerProvider, propagator propagation.TextMapPropagator) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			tracer, prop := tp, propagator
+			if tracer == nil {
+				tracer = otel.GetTracerProvider()
+			}
+			if prop == nil {
+				prop = otel.GetTextMapPropagator()
+			}
+			req := c.Request()
+			route := c.Path()
+			if route == "" {
+				route = req.URL.Path
+			}
+			ctx := prop.Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+			ctx, span := tracer.Tracer(tracerName).Start(ctx, req.Method+" "+route,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					semconv.HTTPRequestMethodKey.String(req.Method),
+					semconv.HTTPRoute(route),
+					semconv.URLPath(req.URL.Path),
+				),
+			)
+			defer span.End()
+			c.SetRequest(req.WithContext(ctx))
+
+			injectcontext.SetContext(ctx)
+			defer injectcontext.ClearContext()
+			err := next(c)
+
+			status := c.Response().Status
+			if err != nil {
+				// the error isn't written to response yet
+				status = http.StatusInternalServerError
+				if he, ok := err.(*echo.HTTPError); ok {
+					status = he.Code
+				}
+				span.RecordError(err)
+			}
+			span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+			if status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(status))
+			}
+			return err
+		}
+	}
+}
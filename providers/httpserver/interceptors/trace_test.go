@@ -0,0 +1,87 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptors
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTrace(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    echo.HandlerFunc
+		wantStatus codes.Code
+	}{
+		{
+			name:       "ok",
+			handler:    func(c echo.Context) error { return c.NoContent(http.StatusOK) },
+			wantStatus: codes.Unset,
+		},
+		{
+			name:       "http error",
+			handler:    func(c echo.Context) error { return echo.NewHTTPError(http.StatusBadGateway) },
+			wantStatus: codes.Error,
+		},
+		{
+			name:       "client error",
+			handler:    func(c echo.Context) error { return echo.NewHTTPError(http.StatusNotFound) },
+			wantStatus: codes.Unset,
+		},
+		{
+			name:       "error",
+			handler:    func(c echo.Context) error { return errors.New("boom") },
+			wantStatus: codes.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sr := tracetest.NewSpanRecorder()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+			e := echo.New()
+			var got trace.SpanContext
+			e.Use(Trace(tp, propagation.TraceContext{}))
+			e.GET("/users/:id", func(c echo.Context) error {
+				got = trace.SpanContextFromContext(c.Request().Context())
+				return tt.handler(c)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+			req.Header.Set("traceparent", "00-0102030405060708090a0b0c0d0e0f10-0102030405060708-01")
+			e.ServeHTTP(httptest.NewRecorder(), req)
+
+			spans := sr.Ended()
+			if !assert.Len(t, spans, 1) {
+				return
+			}
+			span := spans[0]
+			assert.Equal(t, "GET /users/:id", span.Name())
+			assert.Equal(t, trace.SpanKindServer, span.SpanKind())
+			assert.Equal(t, "0102030405060708090a0b0c0d0e0f10", span.Parent().TraceID().String())
+			assert.Equal(t, span.SpanContext().SpanID(), got.SpanID())
+			assert.Equal(t, tt.wantStatus, span.Status().Code)
+		})
+	}
+}
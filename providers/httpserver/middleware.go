@@ -0,0 +1,270 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpserver
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/middleware"
+
+	pkgconfig "github.com/erda-project/erda-infra/pkg/config"
+	"github.com/erda-project/erda-infra/pkg/trace/inject/hook"
+	"github.com/erda-project/erda-infra/providers/httpserver/interceptors"
+	"github.com/erda-project/erda-infra/providers/httpserver/server"
+)
+
+// MiddlewareConfig configures a named middleware of the pipeline.
+type MiddlewareConfig struct {
+	Name    string                 `file:"name" desc:"name of built-in or registered middleware"`
+	Disable bool                   `file:"disable" desc:"disable the middleware"`
+	Options map[string]interface{} `file:"options" desc:"options of the middleware"`
+}
+
+// MiddlewareFactory creates a middleware with the options configured in http-server.middlewares.
+type MiddlewareFactory func(options map[string]interface{}) (server.MiddlewareFunc, error)
+
+// Middlewares is used by other providers to contribute named middlewares,
+// middlewares must be registered before the server starts, e.g. in Init of providers.
+// A registered middleware is placed where it's named in http-server.middlewares, or appended to the pipeline if it's not named.
+type Middlewares interface {
+	RegisterMiddleware(name string, factory MiddlewareFactory)
+}
+
+type namedFactory struct {
+	name    string
+	factory MiddlewareFactory
+}
+
+// middlewareRegistry holds the registered middlewares.
+type middlewareRegistry struct {
+	lock    sync.Mutex
+	started bool
+	list    []namedFactory
+}
+
+func (r *middlewareRegistry) register(name string, factory MiddlewareFactory) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.started {
+		return fmt.Errorf("http server has started")
+	}
+	for _, item := range r.list {
+		if item.name == name {
+			return fmt.Errorf("middleware %q already registered", name)
+		}
+	}
+	r.list = append(r.list, namedFactory{name: name, factory: factory})
+	return nil
+}
+
+// freeze stops registering and returns the registered middlewares.
+func (r *middlewareRegistry) freeze() []namedFactory {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.started = true
+	return r.list
+}
+
+// RegisterMiddleware .
+func (p *provider) RegisterMiddleware(name string, factory MiddlewareFactory) {
+	if _, ok := p.builtinMiddlewares()[name]; ok {
+		p.Log.Errorf("middleware %q is built-in, it can't be registered", name)
+		return
+	}
+	if err := p.middlewares.register(name, factory); err != nil {
+		p.Log.Errorf("fail to register middleware %q: %s", name, err)
+	}
+}
+
+// defaultMiddlewares is the pipeline used if http-server.middlewares is not configured.
+func (p *provider) defaultMiddlewares() []MiddlewareConfig {
+	return []MiddlewareConfig{
+		{Name: "trace", Disable: !p.Cfg.Trace || hook.Enabled()},
+		{Name: "record"},
+		{Name: "cors", Disable: !p.Cfg.AllowCORS},
+		{Name: "request_id"},
+		{Name: "timeout"},
+		{Name: "detail_log"},
+		{Name: "body_dump"},
+		{Name: "debug_flag"},
+	}
+}
+
+func (p *provider) builtinMiddlewares() map[string]MiddlewareFactory {
+	return map[string]MiddlewareFactory{
+		"recover": func(options map[string]interface{}) (server.MiddlewareFunc, error) {
+			return interceptors.Recover(p.Log).(func(server.HandlerFunc) server.HandlerFunc), nil
+		},
+		"trace": func(options map[string]interface{}) (server.MiddlewareFunc, error) {
+			if hook.Enabled() {
+				// requests are traced by the hooked http server already
+				p.Log.Warnf("trace hook is enabled, middleware %q is skipped", "trace")
+				return func(next server.HandlerFunc) server.HandlerFunc { return next }, nil
+			}
+			return interceptors.Trace(p.Tracer, nil), nil
+		},
+		"record": func(options map[string]interface{}) (server.MiddlewareFunc, error) {
+			return interceptors.SimpleRecord(p.getInterceptorOption()), nil
+		},
+		"detail_log": func(options map[string]interface{}) (server.MiddlewareFunc, error) {
+			return interceptors.DetailLog(p.getInterceptorOption()), nil
+		},
+		"body_dump": func(options map[string]interface{}) (server.MiddlewareFunc, error) {
+			cfg := struct {
+				MaxBodySizeBytes int `file:"max_body_size_bytes"`
+			}{MaxBodySizeBytes: p.Cfg.Log.MaxBodySizeBytes}
+			if err := pkgconfig.ConvertData(options, &cfg, "file"); err != nil {
+				return nil, err
+			}
+			return interceptors.BodyDump(p.getInterceptorOption(), cfg.MaxBodySizeBytes), nil
+		},
+		"request_id": func(options map[string]interface{}) (server.MiddlewareFunc, error) {
+			return interceptors.InjectRequestID(), nil
+		},
+		"debug_flag": func(options map[string]interface{}) (server.MiddlewareFunc, error) {
+			return interceptors.PassThroughDebugFlag(), nil
+		},
+		"timeout": func(options map[string]interface{}) (server.MiddlewareFunc, error) {
+			cfg := struct {
+				Max time.Duration `file:"max"`
+			}{Max: p.Cfg.MaxRequestTimeout}
+			if err := pkgconfig.ConvertData(options, &cfg, "file"); err != nil {
+				return nil, err
+			}
+			return interceptors.Deadline(cfg.Max), nil
+		},
+		"cors": func(options map[string]interface{}) (server.MiddlewareFunc, error) {
+			cfg := struct {
+				AllowOrigins     []string `file:"allow_origins"`
+				AllowMethods     []string `file:"allow_methods"`
+				AllowHeaders     []string `file:"allow_headers"`
+				AllowCredentials bool     `file:"allow_credentials"`
+				ExposeHeaders    []string `file:"expose_headers"`
+				MaxAge           int      `file:"max_age"`
+			}{}
+			if err := pkgconfig.ConvertData(options, &cfg, "file"); err != nil {
+				return nil, err
+			}
+			cors := middleware.DefaultCORSConfig
+			if len(cfg.AllowOrigins) > 0 {
+				cors.AllowOrigins = cfg.AllowOrigins
+			}
+			if len(cfg.AllowMethods) > 0 {
+				cors.AllowMethods = cfg.AllowMethods
+			}
+			cors.AllowHeaders = cfg.AllowHeaders
+			cors.AllowCredentials = cfg.AllowCredentials
+			cors.ExposeHeaders = cfg.ExposeHeaders
+			cors.MaxAge = cfg.MaxAge
+			return middleware.CORSWithConfig(cors), nil
+		},
+		"compress": func(options map[string]interface{}) (server.MiddlewareFunc, error) {
+			cfg := struct {
+				Encodings []string `file:"encodings"`
+				Level     int      `file:"level"`
+			}{}
+			if err := pkgconfig.ConvertData(options, &cfg, "file"); err != nil {
+				return nil, err
+			}
+			for _, enc := range cfg.Encodings {
+				if enc != "gzip" && enc != "br" {
+					return nil, fmt.Errorf("unsupported encoding %q", enc)
+				}
+			}
+			return interceptors.Compress(interceptors.CompressConfig{Encodings: cfg.Encodings, Level: cfg.Level}), nil
+		},
+		"body_limit": func(options map[string]interface{}) (server.MiddlewareFunc, error) {
+			cfg := struct {
+				Limit string `file:"limit"`
+			}{}
+			if err := pkgconfig.ConvertData(options, &cfg, "file"); err != nil {
+				return nil, err
+			}
+			if len(cfg.Limit) <= 0 {
+				return nil, fmt.Errorf("limit is required")
+			}
+			return middleware.BodyLimit(cfg.Limit), nil
+		},
+		"secure": func(options map[string]interface{}) (server.MiddlewareFunc, error) {
+			cfg := struct {
+				XSSProtection         string `file:"xss_protection"`
+				ContentTypeNosniff    string `file:"content_type_nosniff"`
+				XFrameOptions         string `file:"x_frame_options"`
+				HSTSMaxAge            int    `file:"hsts_max_age"`
+				HSTSExcludeSubdomains bool   `file:"hsts_exclude_subdomains"`
+				ContentSecurityPolicy string `file:"content_security_policy"`
+			}{
+				XSSProtection:      middleware.DefaultSecureConfig.XSSProtection,
+				ContentTypeNosniff: middleware.DefaultSecureConfig.ContentTypeNosniff,
+				XFrameOptions:      middleware.DefaultSecureConfig.XFrameOptions,
+			}
+			if err := pkgconfig.ConvertData(options, &cfg, "file"); err != nil {
+				return nil, err
+			}
+			secure := middleware.DefaultSecureConfig
+			secure.XSSProtection = cfg.XSSProtection
+			secure.ContentTypeNosniff = cfg.ContentTypeNosniff
+			secure.XFrameOptions = cfg.XFrameOptions
+			secure.HSTSMaxAge = cfg.HSTSMaxAge
+			secure.HSTSExcludeSubdomains = cfg.HSTSExcludeSubdomains
+			secure.ContentSecurityPolicy = cfg.ContentSecurityPolicy
+			return middleware.SecureWithConfig(secure), nil
+		},
+	}
+}
+
+// buildMiddlewares builds the middleware pipeline by configs and registered middlewares.
+func (p *provider) buildMiddlewares(configs []MiddlewareConfig, registered []namedFactory) ([]server.MiddlewareFunc, error) {
+	if len(configs) <= 0 {
+		configs = p.defaultMiddlewares()
+	}
+	factories := p.builtinMiddlewares()
+	for _, item := range registered {
+		factories[item.name] = item.factory
+	}
+	named := make(map[string]bool)
+	var list []server.MiddlewareFunc
+	for _, cfg := range configs {
+		if named[cfg.Name] {
+			return nil, fmt.Errorf("duplicate middleware %q", cfg.Name)
+		}
+		named[cfg.Name] = true
+		factory, ok := factories[cfg.Name]
+		if !ok {
+			return nil, fmt.Errorf("middleware %q not found", cfg.Name)
+		}
+		if cfg.Disable {
+			continue
+		}
+		m, err := factory(cfg.Options)
+		if err != nil {
+			return nil, fmt.Errorf("invalid options of middleware %q: %w", cfg.Name, err)
+		}
+		list = append(list, m)
+	}
+	for _, item := range registered {
+		if named[item.name] {
+			continue
+		}
+		m, err := item.factory(nil)
+		if err != nil {
+			return nil, fmt.Errorf("fail to create middleware %q: %w", item.name, err)
+		}
+		list = append(list, m)
+	}
+	return list, nil
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/labstack/echo"
+
+	"github.com/erda-project/erda-infra/base/logs/logrusx"
+	"github.com/erda-project/erda-infra/providers/httpserver/server"
+)
+
+func TestBuildMiddlewares(t *testing.T) {
+	var calls []string
+	tracer := func(name string) MiddlewareFactory {
+		return func(options map[string]interface{}) (server.MiddlewareFunc, error) {
+			return func(next server.HandlerFunc) server.HandlerFunc {
+				return func(c echo.Context) error {
+					calls = append(calls, name+"="+toString(options["value"]))
+					return next(c)
+				}
+			}, nil
+		}
+	}
+	registered := []namedFactory{
+		{name: "a", factory: tracer("a")},
+		{name: "b", factory: tracer("b")},
+		{name: "c", factory: tracer("c")},
+	}
+	tests := []struct {
+		name    string
+		configs []MiddlewareConfig
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:    "ordered by config and unnamed appended",
+			configs: []MiddlewareConfig{{Name: "b", Options: map[string]interface{}{"value": "1"}}, {Name: "recover"}, {Name: "a"}},
+			want:    []string{"b=1", "a=", "c="},
+		},
+		{
+			name:    "disabled",
+			configs: []MiddlewareConfig{{Name: "a", Disable: true}, {Name: "cors"}},
+			want:    []string{"b=", "c="},
+		},
+		{
+			name:    "not found",
+			configs: []MiddlewareConfig{{Name: "x"}},
+			wantErr: true,
+		},
+		{
+			name:    "duplicate",
+			configs: []MiddlewareConfig{{Name: "a"}, {Name: "a"}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid options",
+			configs: []MiddlewareConfig{{Name: "compress", Options: map[string]interface{}{"encodings": []string{"zstd"}}}},
+			wantErr: true,
+		},
+	}
+	p := &provider{Cfg: &config{}, Log: logrusx.New()}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := p.buildMiddlewares(tt.configs, registered)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("buildMiddlewares() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			calls = nil
+			h := func(c echo.Context) error { return nil }
+			for i := len(list) - 1; i >= 0; i-- {
+				h = list[i](h)
+			}
+			e := echo.New()
+			h(e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder()))
+			if !reflect.DeepEqual(calls, tt.want) {
+				t.Errorf("middlewares called = %v, want %v", calls, tt.want)
+			}
+		})
+	}
+}
+
+func toString(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
@@ -15,60 +15,148 @@
 package httpserver
 
 import (
+	libcontext "context"
+	"fmt"
+	"net"
+	"net/http"
 	"reflect"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/go-playground/validator"
 	"github.com/labstack/echo"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/erda-project/erda-infra/base/logs"
 	"github.com/erda-project/erda-infra/base/servicehub"
+	pkgmetrics "github.com/erda-project/erda-infra/pkg/metrics"
+	"github.com/erda-project/erda-infra/pkg/netutil"
+	"github.com/erda-project/erda-infra/pkg/tlsutil"
 	"github.com/erda-project/erda-infra/providers/httpserver/interceptors"
 	"github.com/erda-project/erda-infra/providers/httpserver/server"
 )
 
 // config .
 type config struct {
-	Addr        string `file:"addr" default:":8080" desc:"http address to listen"`
+	Addr        string `file:"addr" default:":8080" desc:"http address to listen, host:port, unix:///path/to.sock or systemd://[name] for socket activation"`
 	PrintRoutes bool   `file:"print_routes" default:"true" desc:"print http routes"`
-	AllowCORS   bool   `file:"allow_cors" default:"false" desc:"allow cors"`
+	AllowCORS   bool   `file:"allow_cors" default:"false" desc:"allow cors, it's ignored if middlewares is configured"`
 	Reloadable  bool   `file:"reloadable" default:"false" desc:"routes reloadable"`
+	Trace       bool   `file:"trace" default:"false" env:"HTTP_TRACE" desc:"record server spans of requests, it's ignored if middlewares is configured or trace hook is enabled"`
+
+	MaxRequestTimeout time.Duration `file:"max_request_timeout" env:"HTTP_MAX_REQUEST_TIMEOUT" desc:"max timeout of request, the timeout specified by X-Request-Timeout or Grpc-Timeout header is limited by it"`
+
+	ReadTimeout       time.Duration `file:"read_timeout" env:"HTTP_READ_TIMEOUT" desc:"max duration for reading the entire request, including the body"`
+	ReadHeaderTimeout time.Duration `file:"read_header_timeout" env:"HTTP_READ_HEADER_TIMEOUT" desc:"max duration for reading request headers"`
+	WriteTimeout      time.Duration `file:"write_timeout" env:"HTTP_WRITE_TIMEOUT" desc:"max duration before timing out writes of the response"`
+	IdleTimeout       time.Duration `file:"idle_timeout" env:"HTTP_IDLE_TIMEOUT" desc:"max duration to wait for the next request when keep-alives are enabled"`
+	MaxHeaderBytes    int           `file:"max_header_bytes" env:"HTTP_MAX_HEADER_BYTES" desc:"max bytes of request headers, 1MB by default"`
+	ShutdownTimeout   time.Duration `file:"shutdown_timeout" default:"20s" env:"HTTP_SHUTDOWN_TIMEOUT" desc:"max duration to wait for in-flight requests on close, it's also limited by the exit deadline of hub"`
+	DrainPeriod       time.Duration `file:"drain_period" env:"HTTP_DRAIN_PERIOD" desc:"duration to keep serving on close while health check reports not ready, before shutdown"`
+
+	Socket netutil.SocketConfig `file:"socket"`
+	TLS    TLSConfig            `file:"tls"`
+	H2C    bool                 `file:"h2c" env:"HTTP_H2C" desc:"serve HTTP/2 over cleartext TCP, it's required to serve gRPC on the http port without TLS"`
+
+	Middlewares []MiddlewareConfig `file:"middlewares" desc:"ordered middleware pipeline, trace, record, cors, request_id, timeout, detail_log, body_dump and debug_flag by default"`
+
+	Metrics MetricsConfig `file:"metrics"`
 
 	Debug bool      `file:"debug" default:"false"`
 	Log   LogConfig `file:"log"`
 }
 
+// TLSConfig .
+type TLSConfig struct {
+	CertFile       string        `file:"cert_file" env:"HTTP_TLS_CERT_FILE" desc:"the TLS cert file, enables https"`
+	KeyFile        string        `file:"key_file" env:"HTTP_TLS_KEY_FILE" desc:"the TLS key file"`
+	CAFile         string        `file:"ca_file" env:"HTTP_TLS_CA_FILE" desc:"the CA file to verify client certs"`
+	ClientAuth     string        `file:"client_auth" env:"HTTP_TLS_CLIENT_AUTH" desc:"none, request, require_any, optional or require, client certs are verified by optional and require, require by default if ca_file is set"`
+	MinVersion     string        `file:"min_version" default:"1.2" env:"HTTP_TLS_MIN_VERSION" desc:"min TLS version, 1.0, 1.1, 1.2 or 1.3"`
+	CipherSuites   []string      `file:"cipher_suites" desc:"names of cipher suites, e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"`
+	ReloadInterval time.Duration `file:"reload_interval" default:"1m" desc:"interval to check cert files for reloading, 0 to disable"`
+}
+
+// MetricsConfig .
+type MetricsConfig struct {
+	Enable         bool      `file:"enable" default:"true" env:"HTTP_METRICS_ENABLE" desc:"record request metrics of routes, labelled by group, method, route and status code"`
+	Buckets        []float64 `file:"buckets" desc:"buckets of latency histogram in seconds"`
+	SizeBuckets    []float64 `file:"size_buckets" desc:"buckets of response size histogram in bytes"`
+	MaxLabelValues int       `file:"max_label_values" default:"1000" desc:"max number of distinct routes, routes beyond it are recorded as other"`
+}
+
 // LogConfig .
 type LogConfig struct {
 	MaxBodySizeBytes int `file:"max_body_size_bytes" default:"1024" desc:"max body size in bytes"`
 }
 
 type provider struct {
-	Cfg *config
-	Log logs.Logger
+	Cfg    *config
+	Log    logs.Logger
+	Tracer trace.TracerProvider `autowired:"tracer-provider" optional:"true"`
 
-	server server.Server
-	lock   sync.Mutex
-	routes map[routeKey]*route
-	err    error
+	hub         *servicehub.Hub
+	server      server.Server
+	addr        net.Addr
+	certs       *tlsutil.Loader
+	middlewares middlewareRegistry
+	metrics     *pkgmetrics.RED
+	cancel      libcontext.CancelFunc
+	draining    int32
+	lock        sync.Mutex
+	routes      map[routeKey]*route
+	err         error
 
 	startedChan chan struct{}
 }
 
 // Init .
 func (p *provider) Init(ctx servicehub.Context) error {
-	p.server = server.New(p.Cfg.Reloadable, &dataBinder{}, &structValidator{validator: validator.New()})
+	p.hub = ctx.Hub()
+	listenOpts, err := p.Cfg.Socket.ListenOptions()
+	if err != nil {
+		return fmt.Errorf("invalid socket config: %w", err)
+	}
+	opts := []server.Option{
+		server.WithTimeouts(p.Cfg.ReadTimeout, p.Cfg.ReadHeaderTimeout, p.Cfg.WriteTimeout, p.Cfg.IdleTimeout),
+		server.WithMaxHeaderBytes(p.Cfg.MaxHeaderBytes),
+		server.WithListenOptions(listenOpts...),
+	}
+	if len(p.Cfg.TLS.CertFile) > 0 || len(p.Cfg.TLS.KeyFile) > 0 {
+		certs, err := tlsutil.NewLoader(tlsutil.Options{
+			CertFile:     p.Cfg.TLS.CertFile,
+			KeyFile:      p.Cfg.TLS.KeyFile,
+			CAFile:       p.Cfg.TLS.CAFile,
+			ClientAuth:   p.Cfg.TLS.ClientAuth,
+			MinVersion:   p.Cfg.TLS.MinVersion,
+			CipherSuites: p.Cfg.TLS.CipherSuites,
+			NextProtos:   []string{"h2", "http/1.1"},
+		})
+		if err != nil {
+			return fmt.Errorf("invalid tls config: %w", err)
+		}
+		p.certs = certs
+		opts = append(opts, server.WithTLSConfig(certs.TLSConfig()))
+	}
+	if p.Cfg.H2C {
+		opts = append(opts, server.WithH2C())
+	}
+	if p.Cfg.Metrics.Enable {
+		p.metrics = pkgmetrics.NewRED([]string{"group", "method", "route"}, "code",
+			pkgmetrics.WithNamespace("", "http_server"),
+			pkgmetrics.WithBuckets(p.Cfg.Metrics.Buckets),
+			pkgmetrics.WithSizeBuckets(p.Cfg.Metrics.SizeBuckets),
+			pkgmetrics.WithMaxLabelValues(p.Cfg.Metrics.MaxLabelValues),
+		)
+	}
+	p.server = server.New(p.Cfg.Reloadable, &dataBinder{}, &structValidator{validator: validator.New()}, opts...)
+	// listen in advance, so that the actual address is known by others before start
+	p.addr, err = p.server.Listen(p.Cfg.Addr)
+	if err != nil {
+		return err
+	}
 	p.startedChan = make(chan struct{})
-
-	//p.server.Use(interceptors.Recover(p.Log).(func(echo.HandlerFunc) echo.HandlerFunc))
-	p.server.Use(interceptors.SimpleRecord(p.getInterceptorOption()))
-	p.server.Use(interceptors.CORS(p.Cfg.AllowCORS))
-	p.server.Use(interceptors.InjectRequestID())
-	p.server.Use(interceptors.DetailLog(p.getInterceptorOption()))
-	p.server.Use(interceptors.BodyDump(p.getInterceptorOption(), p.Cfg.Log.MaxBodySizeBytes))
-	p.server.Use(interceptors.PassThroughDebugFlag())
-	p.server.Use(p.wrapContext())
-
 	return nil
 }
 
@@ -104,6 +192,12 @@ func (p *provider) Start() error {
 	if p.err != nil {
 		return p.err
 	}
+	middlewares, err := p.buildMiddlewares(p.Cfg.Middlewares, p.middlewares.freeze())
+	if err != nil {
+		return err
+	}
+	p.server.Use(middlewares...)
+	p.server.Use(p.wrapContext())
 	if p.Cfg.PrintRoutes {
 		if p.Cfg.Reloadable {
 			p.lock.Lock()
@@ -113,21 +207,74 @@ func (p *provider) Start() error {
 			p.lock.Unlock()
 		}
 	}
-	p.Log.Infof("starting http server at %s", p.Cfg.Addr)
+	if p.certs != nil && p.Cfg.TLS.ReloadInterval > 0 {
+		var ctx libcontext.Context
+		ctx, p.cancel = libcontext.WithCancel(libcontext.Background())
+		go p.certs.Watch(ctx, p.Cfg.TLS.ReloadInterval, func(err error) {
+			if err != nil {
+				p.Log.Errorf("fail to reload tls certificates: %s", err)
+				return
+			}
+			p.Log.Infof("tls certificates reloaded")
+		})
+	}
+	p.Log.Infof("starting http server at %s", p.addr)
 	close(p.startedChan)
 	return p.server.Start(p.Cfg.Addr)
 }
 
-// Close .
+// Close drains and shuts down the server gracefully.
 func (p *provider) Close() error {
 	if p.server == nil {
 		return nil
 	}
-	return p.server.Close()
+	if p.cancel != nil {
+		p.cancel()
+	}
+	ctx, cancel := libcontext.WithCancel(libcontext.Background())
+	if p.hub != nil {
+		ctx, cancel = p.hub.ShutdownContext()
+	}
+	defer cancel()
+	atomic.StoreInt32(&p.draining, 1)
+	if p.Cfg.DrainPeriod > 0 {
+		p.Log.Infof("draining http server for %s", p.Cfg.DrainPeriod)
+		timer := time.NewTimer(p.Cfg.DrainPeriod)
+		select {
+		case <-ctx.Done():
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+	if p.Cfg.ShutdownTimeout > 0 {
+		var timeoutCancel libcontext.CancelFunc
+		ctx, timeoutCancel = libcontext.WithTimeout(ctx, p.Cfg.ShutdownTimeout)
+		defer timeoutCancel()
+	}
+	return p.server.Shutdown(ctx)
+}
+
+// HandleGRPC .
+func (p *provider) HandleGRPC(h http.Handler) {
+	if !p.Cfg.H2C && p.certs == nil {
+		p.Log.Warnf("neither h2c nor tls is enabled, gRPC requests can't be served on http server")
+	}
+	p.server.HandleGRPC(h)
+}
+
+// ListenAddr .
+func (p *provider) ListenAddr() net.Addr { return p.addr }
+
+// Draining .
+func (p *provider) Draining() bool {
+	return atomic.LoadInt32(&p.draining) != 0
 }
 
 // Provide .
 func (p *provider) Provide(ctx servicehub.DependencyContext, args ...interface{}) interface{} {
+	if ctx.Type() == statusType || ctx.Type() == grpcMuxType || ctx.Service() == "http-server-middlewares" || ctx.Type() == middlewaresType {
+		return p
+	}
 	if ctx.Service() == "http-router-manager" || ctx.Type() == routerManagerType {
 		return p.newRouterManager(true, ctx.Caller(), args...)
 	} else if p.Cfg.Reloadable && (ctx.Service() != "http-router-tx" || ctx.Type() == routerType) {
@@ -153,6 +300,7 @@ func (p *provider) newRouterTx(reset bool, group string, opts ...interface{}) Ro
 		tx:           p.server.NewRouter(),
 		group:        group,
 		interceptors: interceptors,
+		metrics:      p.metrics,
 	}
 	r.pathFormater = r.getPathFormater(opts)
 	if p.Cfg.Reloadable {
@@ -193,15 +341,35 @@ var (
 	routerType        = reflect.TypeOf((*Router)(nil)).Elem()
 	routerTxType      = reflect.TypeOf((*RouterTx)(nil)).Elem()
 	routerManagerType = reflect.TypeOf((*RouterManager)(nil)).Elem()
+	statusType        = reflect.TypeOf((*Status)(nil)).Elem()
+	grpcMuxType       = reflect.TypeOf((*GRPCMux)(nil)).Elem()
+	middlewaresType   = reflect.TypeOf((*Middlewares)(nil)).Elem()
 )
 
+// GRPCMux is used to serve gRPC on the port of http server.
+type GRPCMux interface {
+	// HandleGRPC serves HTTP/2 requests with content-type application/grpc by h.
+	HandleGRPC(h http.Handler)
+	// ListenAddr returns the actual address of listener, e.g. *net.TCPAddr or *net.UnixAddr.
+	ListenAddr() net.Addr
+}
+
+// Status provides the serving status of http server.
+type Status interface {
+	// Draining returns true if the server is closing and draining connections.
+	Draining() bool
+}
+
 func init() {
 	servicehub.Register("http-server", &servicehub.Spec{
-		Services: []string{"http-server", "http-router", "http-router-manager", "http-router-tx"},
+		Services: []string{"http-server", "http-router", "http-router-manager", "http-router-tx", "http-server-middlewares"},
 		Types: []reflect.Type{
 			routerType,
 			routerTxType,
 			routerManagerType,
+			statusType,
+			grpcMuxType,
+			middlewaresType,
 		},
 		Description: "http server",
 		ConfigFunc:  func() interface{} { return &config{} },
@@ -0,0 +1,200 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/prometheus/client_golang/prometheus"
+
+	pkgmetrics "github.com/erda-project/erda-infra/pkg/metrics"
+	"github.com/erda-project/erda-infra/providers/httpserver/server"
+)
+
+// Limit is the rate of a token bucket.
+type Limit struct {
+	// Rate is the number of requests allowed per second.
+	Rate float64
+	// Burst is the max number of requests allowed at once.
+	Burst int
+}
+
+// Every returns the Limit allows n requests per period, n requests are allowed at once.
+func Every(n int, period time.Duration) Limit {
+	return Limit{Rate: float64(n) / period.Seconds(), Burst: n}
+}
+
+// Result is the result of taking a token.
+type Result struct {
+	Allowed bool
+	// Remaining is the number of tokens left in the bucket.
+	Remaining int
+	// RetryAfter is the duration to wait for the next token if it's not allowed.
+	RetryAfter time.Duration
+	// Reset is the duration for the bucket to be full.
+	Reset time.Duration
+}
+
+// Store keeps the token buckets.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// KeyFunc returns the key of bucket for a request, the request is not limited if key is empty.
+type KeyFunc func(c echo.Context) string
+
+// ByIP keys requests by client IP.
+func ByIP() KeyFunc {
+	return func(c echo.Context) string { return c.RealIP() }
+}
+
+// ByHeader keys requests by the value of header.
+func ByHeader(name string) KeyFunc {
+	return func(c echo.Context) string { return c.Request().Header.Get(name) }
+}
+
+// ByContextValue keys requests by the value of echo context set by previous middlewares, e.g. the authenticated principal.
+func ByContextValue(key string) KeyFunc {
+	return func(c echo.Context) string {
+		switch val := c.Get(key).(type) {
+		case string:
+			return val
+		case interface{ String() string }:
+			return val.String()
+		}
+		return ""
+	}
+}
+
+type config struct {
+	keyFunc    KeyFunc
+	name       string
+	registerer prometheus.Registerer
+	onError    func(c echo.Context, err error)
+}
+
+// Option .
+type Option func(*config)
+
+// WithKeyFunc setup the key of requests, ByIP is used by default.
+func WithKeyFunc(fn KeyFunc) Option {
+	return func(c *config) {
+		c.keyFunc = fn
+	}
+}
+
+// WithName setup the name of the limit, it's used to separate buckets of routes sharing the same store,
+// the method and path of route is used by default.
+func WithName(name string) Option {
+	return func(c *config) {
+		c.name = name
+	}
+}
+
+// WithRegisterer setup the registerer of metrics, prometheus.DefaultRegisterer is used by default.
+func WithRegisterer(r prometheus.Registerer) Option {
+	return func(c *config) {
+		c.registerer = r
+	}
+}
+
+// WithErrorHandler setup the handler of store errors, requests are allowed if the store fails.
+func WithErrorHandler(fn func(c echo.Context, err error)) Option {
+	return func(c *config) {
+		c.onError = fn
+	}
+}
+
+// Header names of rate limit.
+const (
+	HeaderRateLimitLimit     = "RateLimit-Limit"
+	HeaderRateLimitRemaining = "RateLimit-Remaining"
+	HeaderRateLimitReset     = "RateLimit-Reset"
+	HeaderRetryAfter         = "Retry-After"
+)
+
+// Route returns a route option of httpserver.Router to limit requests by a token bucket per key,
+// requests exceeding the limit are rejected with 429 Too Many Requests.
+func Route(store Store, limit Limit, opts ...Option) server.MiddlewareFunc {
+	cfg := &config{
+		keyFunc:    ByIP(),
+		registerer: prometheus.DefaultRegisterer,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	rejected := pkgmetrics.Register(cfg.registerer, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Subsystem: "http_server",
+		Name:      "rate_limited_requests_total",
+		Help:      "Total number of requests rejected by rate limit.",
+	}, []string{"name"})).(*prometheus.CounterVec)
+	return func(next server.HandlerFunc) server.HandlerFunc {
+		return func(c echo.Context) error {
+			key := cfg.keyFunc(c)
+			if len(key) <= 0 {
+				return next(c)
+			}
+			name := cfg.name
+			if len(name) <= 0 {
+				name = c.Request().Method + " " + c.Path()
+			}
+			result, err := store.Take(c.Request().Context(), name+":"+key, limit)
+			if err != nil {
+				if cfg.onError != nil {
+					cfg.onError(c, err)
+				}
+				return next(c)
+			}
+			header := c.Response().Header()
+			header.Set(HeaderRateLimitLimit, strconv.Itoa(limit.Burst))
+			header.Set(HeaderRateLimitRemaining, strconv.Itoa(result.Remaining))
+			header.Set(HeaderRateLimitReset, seconds(result.Reset))
+			if !result.Allowed {
+				rejected.WithLabelValues(name).Inc()
+				header.Set(HeaderRetryAfter, seconds(result.RetryAfter))
+				return echo.NewHTTPError(http.StatusTooManyRequests, "rate limit exceeded")
+			}
+			return next(c)
+		}
+	}
+}
+
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
+
+// take takes a token from bucket with tokens at last, and returns the tokens left.
+func take(tokens float64, last, now time.Time, limit Limit) (float64, Result) {
+	if elapsed := now.Sub(last).Seconds(); elapsed > 0 {
+		tokens = math.Min(float64(limit.Burst), tokens+elapsed*limit.Rate)
+	}
+	var result Result
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else if limit.Rate > 0 {
+		result.RetryAfter = time.Duration((1 - tokens) / limit.Rate * float64(time.Second))
+	}
+	result.Remaining = int(tokens)
+	if limit.Rate > 0 {
+		result.Reset = time.Duration((float64(limit.Burst) - tokens) / limit.Rate * float64(time.Second))
+	}
+	return tokens, result
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMemoryStore(t *testing.T) {
+	now := time.Unix(1000, 0)
+	s := NewMemoryStore().(*memoryStore)
+	s.now = func() time.Time { return now }
+	limit := Limit{Rate: 1, Burst: 2}
+	tests := []struct {
+		advance    time.Duration
+		allowed    bool
+		remaining  int
+		retryAfter time.Duration
+	}{
+		{0, true, 1, 0},
+		{0, true, 0, 0},
+		{0, false, 0, time.Second},
+		{500 * time.Millisecond, false, 0, 500 * time.Millisecond},
+		{500 * time.Millisecond, true, 0, 0},
+		{10 * time.Second, true, 1, 0},
+	}
+	for i, tt := range tests {
+		now = now.Add(tt.advance)
+		got, err := s.Take(context.Background(), "key", limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Allowed != tt.allowed || got.Remaining != tt.remaining || got.RetryAfter != tt.retryAfter {
+			t.Errorf("Take() #%d = %+v, want allowed %v, remaining %d, retry after %s", i, got, tt.allowed, tt.remaining, tt.retryAfter)
+		}
+	}
+	if got, _ := s.Take(context.Background(), "other", limit); !got.Allowed {
+		t.Errorf("Take() of other key is not allowed")
+	}
+
+	now = now.Add(time.Hour)
+	s.Take(context.Background(), "key", limit)
+	if _, ok := s.buckets["other"]; ok {
+		t.Errorf("full bucket is not swept")
+	}
+}
+
+func TestRoute(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	handler := Route(NewMemoryStore(), Limit{Rate: 0.5, Burst: 1}, WithKeyFunc(ByHeader("X-Api-Key")), WithName("test"), WithRegisterer(reg))(
+		func(c echo.Context) error { return c.NoContent(http.StatusOK) },
+	)
+	e := echo.New()
+	tests := []struct {
+		key        string
+		wantErr    bool
+		remaining  string
+		retryAfter string
+	}{
+		{"a", false, "0", ""},
+		{"a", true, "0", "2"},
+		{"b", false, "0", ""},
+		{"", false, "", ""},
+		{"", false, "", ""},
+	}
+	for i, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if len(tt.key) > 0 {
+			req.Header.Set("X-Api-Key", tt.key)
+		}
+		rec := httptest.NewRecorder()
+		err := handler(e.NewContext(req, rec))
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("#%d error = %v, wantErr %v", i, err, tt.wantErr)
+		}
+		if he, ok := err.(*echo.HTTPError); ok && he.Code != http.StatusTooManyRequests {
+			t.Errorf("#%d status = %d, want %d", i, he.Code, http.StatusTooManyRequests)
+		}
+		if got := rec.Header().Get(HeaderRateLimitRemaining); got != tt.remaining {
+			t.Errorf("#%d %s = %q, want %q", i, HeaderRateLimitRemaining, got, tt.remaining)
+		}
+		if got := rec.Header().Get(HeaderRetryAfter); got != tt.retryAfter {
+			t.Errorf("#%d %s = %q, want %q", i, HeaderRetryAfter, got, tt.retryAfter)
+		}
+	}
+	expected := `
+# HELP http_server_rate_limited_requests_total Total number of requests rejected by rate limit.
+# TYPE http_server_rate_limited_requests_total counter
+http_server_rate_limited_requests_total{name="test"} 1
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// tokenBucketScript takes a token from the bucket stored in a hash,
+// it returns whether it's allowed and the tokens left.
+var tokenBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+	tokens = burst
+	ts = now
+end
+if now > ts then
+	tokens = math.min(burst, tokens + (now - ts) / 1000 * rate)
+end
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HMSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+local ttl = 1000
+if rate > 0 then
+	ttl = ttl + math.ceil((burst - tokens) / rate * 1000)
+end
+redis.call('PEXPIRE', KEYS[1], ttl)
+return {allowed, tostring(tokens)}
+`)
+
+type redisStore struct {
+	client redis.Cmdable
+	prefix string
+}
+
+// DefaultRedisPrefix is the default prefix of keys in redis.
+const DefaultRedisPrefix = "erda-infra:ratelimit:"
+
+// NewRedisStore returns a Store keeping buckets in redis, buckets are shared by processes using the same redis.
+// The client can be got from the redis provider, DefaultRedisPrefix is used if prefix is empty.
+func NewRedisStore(client redis.Cmdable, prefix string) Store {
+	if len(prefix) <= 0 {
+		prefix = DefaultRedisPrefix
+	}
+	return &redisStore{client: client, prefix: prefix}
+}
+
+func (s *redisStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	now := time.Now()
+	val, err := tokenBucketScript.Run(s.client, []string{s.prefix + key}, limit.Rate, limit.Burst, now.UnixNano()/int64(time.Millisecond)).Result()
+	if err != nil {
+		return Result{}, err
+	}
+	list, ok := val.([]interface{})
+	if !ok || len(list) != 2 {
+		return Result{}, fmt.Errorf("unexpected result of rate limit script: %v", val)
+	}
+	allowed, _ := list[0].(int64)
+	str, _ := list[1].(string)
+	tokens, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		return Result{}, fmt.Errorf("unexpected tokens of rate limit script: %v", list[1])
+	}
+	// the tokens are consumed, compute the result from the state before taking
+	before := tokens
+	if allowed == 1 {
+		before++
+	}
+	_, result := take(before, now, now, limit)
+	return result, nil
+}
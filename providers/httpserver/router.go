@@ -28,6 +28,7 @@ import (
 	"github.com/labstack/echo"
 	"github.com/recallsong/go-utils/net/httpx/filesystem"
 
+	pkgmetrics "github.com/erda-project/erda-infra/pkg/metrics"
 	"github.com/erda-project/erda-infra/providers/httpserver/server"
 )
 
@@ -90,6 +91,7 @@ type (
 		routes       map[routeKey]*route
 		group        string
 		interceptors []server.MiddlewareFunc
+		metrics      *pkgmetrics.RED
 	}
 )
 
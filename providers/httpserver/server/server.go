@@ -15,12 +15,18 @@
 package server
 
 import (
+	"context"
 	"crypto/tls"
 	"net"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/erda-project/erda-infra/pkg/netutil"
 	"github.com/labstack/echo"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 type (
@@ -29,9 +35,16 @@ type (
 		Use(middleware ...MiddlewareFunc)
 		NewRouter() RouterTx
 		Router() Router
+		// Listen listens on addr before Start, it returns the actual address of listener.
+		Listen(addr string) (net.Addr, error)
 		Start(addr string) error
 		Close() error
+		Shutdown(ctx context.Context) error
+		// HandleGRPC serves HTTP/2 requests with content-type application/grpc by h.
+		HandleGRPC(h http.Handler)
 	}
+	// Option configures the server.
+	Option func(s *server)
 	// RouterTx .
 	RouterTx interface {
 		Router
@@ -54,11 +67,61 @@ type (
 		reloadable bool
 		router     routerManager
 		middleware []MiddlewareFunc
+		grpc       http.Handler
+		listenOpts []netutil.ListenOption
+		h2c        bool
+		h2cLock    sync.Mutex
+		h2cConns   map[net.Conn]struct{}
 	}
 )
 
+// WithTimeouts sets the timeouts of http server, zero means no timeout.
+func WithTimeouts(read, readHeader, write, idle time.Duration) Option {
+	return withServer(func(svr *http.Server) {
+		svr.ReadTimeout = read
+		svr.ReadHeaderTimeout = readHeader
+		svr.WriteTimeout = write
+		svr.IdleTimeout = idle
+	})
+}
+
+// WithMaxHeaderBytes sets the max bytes of request header, http.DefaultMaxHeaderBytes is used if it is zero.
+func WithMaxHeaderBytes(n int) Option {
+	return withServer(func(svr *http.Server) {
+		svr.MaxHeaderBytes = n
+	})
+}
+
+// WithTLSConfig serves HTTPS with cfg.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return withServer(func(svr *http.Server) {
+		svr.TLSConfig = cfg
+	})
+}
+
+// WithH2C serves HTTP/2 over cleartext TCP, it's ignored for TLS where HTTP/2 is negotiated by ALPN.
+func WithH2C() Option {
+	return func(s *server) {
+		s.h2c = true
+	}
+}
+
+// WithListenOptions sets the options used to listen on the address, such as the permission of unix socket.
+func WithListenOptions(opts ...netutil.ListenOption) Option {
+	return func(s *server) {
+		s.listenOpts = append(s.listenOpts, opts...)
+	}
+}
+
+func withServer(fn func(svr *http.Server)) Option {
+	return func(s *server) {
+		fn(s.e.Server)
+		fn(s.e.TLSServer)
+	}
+}
+
 // New .
-func New(reloadable bool, binder echo.Binder, validator echo.Validator) Server {
+func New(reloadable bool, binder echo.Binder, validator echo.Validator, opts ...Option) Server {
 	s := &server{
 		e:          echo.New(),
 		reloadable: reloadable,
@@ -66,6 +129,12 @@ func New(reloadable bool, binder echo.Binder, validator echo.Validator) Server {
 	s.e.HideBanner, s.e.HidePort = true, true
 	s.e.Binder, s.e.Validator = binder, validator
 	s.e.Server.Handler, s.e.TLSServer.Handler = s, s
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.h2c && s.e.Server.TLSConfig == nil {
+		s.enableH2C(s.e.Server)
+	}
 	if reloadable {
 		s.router = newReloadableRouterManager(s.e)
 	} else {
@@ -74,6 +143,62 @@ func New(reloadable bool, binder echo.Binder, validator echo.Validator) Server {
 	return s
 }
 
+type connContextKey struct{}
+
+// enableH2C serves h2c by an http2 server configured on svr, so that GOAWAY is sent to h2c connections on shutdown.
+// h2c connections are hijacked from svr, they are tracked here to be waited on shutdown.
+func (s *server) enableH2C(svr *http.Server) {
+	h2s := &http2.Server{}
+	http2.ConfigureServer(svr, h2s)
+	svr.TLSConfig, svr.TLSNextProto = nil, nil // prepared by ConfigureServer for TLS, which is not used by h2c
+	s.h2cConns = make(map[net.Conn]struct{})
+	svr.ConnContext = func(ctx context.Context, c net.Conn) context.Context {
+		return context.WithValue(ctx, connContextKey{}, c)
+	}
+	handler := h2c.NewHandler(svr.Handler, h2s)
+	svr.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c, ok := r.Context().Value(connContextKey{}).(net.Conn); ok {
+			s.h2cLock.Lock()
+			s.h2cConns[c] = struct{}{}
+			s.h2cLock.Unlock()
+			defer func() {
+				s.h2cLock.Lock()
+				delete(s.h2cConns, c)
+				s.h2cLock.Unlock()
+			}()
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// waitH2CConns waits for the h2c connections to be closed until ctx is done.
+func (s *server) waitH2CConns(ctx context.Context) error {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		s.h2cLock.Lock()
+		n := len(s.h2cConns)
+		s.h2cLock.Unlock()
+		if n <= 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// closeH2CConns closes the h2c connections forcibly.
+func (s *server) closeH2CConns() {
+	s.h2cLock.Lock()
+	defer s.h2cLock.Unlock()
+	for c := range s.h2cConns {
+		c.Close()
+	}
+}
+
 // Start .
 func (s *server) Start(addr string) error {
 	err := s.startHTTP(addr)
@@ -83,17 +208,53 @@ func (s *server) Start(addr string) error {
 	return nil
 }
 
+// Listen .
+func (s *server) Listen(addr string) (net.Addr, error) {
+	l, err := newListener(addr, s.listenOpts...)
+	if err != nil {
+		return nil, err
+	}
+	if s.e.Server.TLSConfig == nil {
+		s.e.Listener = l
+	} else {
+		s.e.TLSListener = tls.NewListener(l, s.e.Server.TLSConfig)
+	}
+	return l.Addr(), nil
+}
+
 // Close .
 func (s *server) Close() error {
 	err := s.e.Server.Close()
+	s.closeH2CConns()
 	if err != nil && err != http.ErrServerClosed {
 		return err
 	}
 	return nil
 }
 
+// Shutdown stops accepting connections and waits for in-flight requests until ctx is done,
+// the server is closed forcibly if ctx is done.
+func (s *server) Shutdown(ctx context.Context) error {
+	// GOAWAY is sent to h2c connections by the shutdown hook of http2 server
+	err := s.e.Server.Shutdown(ctx)
+	if err == nil || err == http.ErrServerClosed {
+		if err = s.waitH2CConns(ctx); err == nil {
+			return nil
+		}
+	}
+	s.Close()
+	return err
+}
+
+// HandleGRPC .
+func (s *server) HandleGRPC(h http.Handler) { s.grpc = h }
+
 // ServeHTTP .
 func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.grpc != nil && r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+		s.grpc.ServeHTTP(w, r)
+		return
+	}
 	router := s.router.GetRouter()
 
 	// Acquire context
@@ -147,7 +308,7 @@ func (s *server) startHTTP(address string) error {
 func (s *server) startServer(svr *http.Server) (err error) {
 	if svr.TLSConfig == nil {
 		if s.e.Listener == nil {
-			s.e.Listener, err = newListener(svr.Addr)
+			s.e.Listener, err = newListener(svr.Addr, s.listenOpts...)
 			if err != nil {
 				return err
 			}
@@ -155,7 +316,7 @@ func (s *server) startServer(svr *http.Server) (err error) {
 		return svr.Serve(s.e.Listener)
 	}
 	if s.e.TLSListener == nil {
-		l, err := newListener(svr.Addr)
+		l, err := newListener(svr.Addr, s.listenOpts...)
 		if err != nil {
 			return err
 		}
@@ -164,28 +325,8 @@ func (s *server) startServer(svr *http.Server) (err error) {
 	return svr.Serve(s.e.TLSListener)
 }
 
-// tcpKeepAliveListener sets TCP keep-alive timeouts on accepted
-// connections. It's used by ListenAndServe and ListenAndServeTLS so
-// dead TCP connections (e.g. closing laptop mid-download) eventually
-// go away.
-type tcpKeepAliveListener struct {
-	*net.TCPListener
-}
-
-func (ln tcpKeepAliveListener) Accept() (c net.Conn, err error) {
-	tc, err := ln.AcceptTCP()
-	if err != nil {
-		return
-	}
-	tc.SetKeepAlive(true)
-	tc.SetKeepAlivePeriod(3 * time.Minute)
-	return tc, nil
-}
-
-func newListener(address string) (*tcpKeepAliveListener, error) {
-	l, err := net.Listen("tcp", address)
-	if err != nil {
-		return nil, err
-	}
-	return &tcpKeepAliveListener{l.(*net.TCPListener)}, nil
+// newListener listens on address, TCP keep-alive is enabled for TCP connections
+// so dead connections (e.g. closing laptop mid-download) eventually go away.
+func newListener(address string, opts ...netutil.ListenOption) (net.Listener, error) {
+	return netutil.Listen(address, append([]netutil.ListenOption{netutil.WithKeepAlive(3 * time.Minute)}, opts...)...)
 }
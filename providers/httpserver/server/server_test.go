@@ -0,0 +1,173 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"golang.org/x/net/http2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func TestShutdown(t *testing.T) {
+	s := New(false, &echo.DefaultBinder{}, nil, WithTimeouts(time.Second, time.Second, time.Second, time.Second))
+	started := make(chan struct{})
+	router := s.NewRouter()
+	router.Add(http.MethodGet, "/slow", func(c echo.Context) error {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		return c.String(http.StatusOK, "done")
+	})
+	router.Commit()
+
+	svr := s.(*server)
+	listener, err := newListener("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	svr.e.Listener = listener
+	go s.Start("127.0.0.1:0")
+
+	result := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + listener.Addr().String() + "/slow")
+		if err != nil {
+			result <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		result <- string(body)
+	}()
+	<-started
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if got := <-result; got != "done" {
+		t.Errorf("in-flight request = %q, want %q", got, "done")
+	}
+}
+
+func TestHandleGRPC(t *testing.T) {
+	s := New(false, &echo.DefaultBinder{}, nil, WithH2C())
+	router := s.NewRouter()
+	router.Add(http.MethodGet, "/hello", func(c echo.Context) error {
+		return c.String(http.StatusOK, "http")
+	})
+	router.Commit()
+	s.HandleGRPC(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "grpc")
+	}))
+	listener, err := newListener("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.(*server).e.Listener = listener
+	go s.Start("127.0.0.1:0")
+	defer s.Close()
+
+	client := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLS: func(network, addr string, cfg *tls.Config) (net.Conn, error) {
+			return net.Dial(network, addr)
+		},
+	}}
+	tests := []struct {
+		contentType string
+		want        string
+	}{
+		{"application/grpc", "grpc"},
+		{"application/grpc+proto", "grpc"},
+		{"application/json", "http"},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodGet, "http://"+listener.Addr().String()+"/hello", nil)
+		req.Header.Set("Content-Type", tt.contentType)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != tt.want {
+			t.Errorf("%s: body = %q, want %q", tt.contentType, body, tt.want)
+		}
+	}
+}
+
+func TestShutdownH2C(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	gs := grpc.NewServer()
+	gs.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "test.Slow",
+		HandlerType: (*interface{})(nil),
+		Methods: []grpc.MethodDesc{{
+			MethodName: "Call",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+				in := &emptypb.Empty{}
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+				close(started)
+				<-release
+				return in, nil
+			},
+		}},
+	}, struct{}{})
+	s := New(false, &echo.DefaultBinder{}, nil, WithH2C())
+	s.HandleGRPC(gs)
+	addr, err := s.Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.Start(addr.String())
+
+	conn, err := grpc.Dial(addr.String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	result := make(chan error, 1)
+	go func() {
+		result <- conn.Invoke(context.Background(), "/test.Slow/Call", &emptypb.Empty{}, &emptypb.Empty{})
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- s.Shutdown(ctx) }()
+	select {
+	case err := <-shutdown:
+		t.Fatalf("Shutdown() = %v before the in-flight call finished", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+	if err := <-result; err != nil {
+		t.Errorf("in-flight call error = %v", err)
+	}
+	if err := <-shutdown; err != nil {
+		t.Errorf("Shutdown() error = %v", err)
+	}
+}
@@ -15,9 +15,11 @@
 package kafka
 
 import (
+	"context"
 	"time"
 
 	"github.com/confluentinc/confluent-kafka-go/kafka"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
 )
 
 // BatchReader .
@@ -42,27 +44,39 @@ func WithReaderDecoder(dec Decoder) BatchReaderOption {
 	return BatchReaderOption(dec)
 }
 
+// WithReaderMessageDecoder .
+func WithReaderMessageDecoder(dec MessageDecoder) BatchReaderOption {
+	return BatchReaderOption(dec)
+}
+
 // Decoder .
 type Decoder func(key, value []byte, topic *string, timestamp time.Time) (interface{}, error)
 
+// MessageDecoder decodes the message with headers, ctx carries the consumer span continuing the trace of producer.
+type MessageDecoder func(ctx context.Context, msg *kafka.Message) (interface{}, error)
+
 func (s *service) NewBatchReader(cfg *BatchReaderConfig, options ...BatchReaderOption) (BatchReader, error) {
-	var dec Decoder
+	var dec MessageDecoder
 	for _, opt := range options {
 		switch v := opt.(type) {
 		case Decoder:
+			dec = func(ctx context.Context, msg *kafka.Message) (interface{}, error) {
+				return v(msg.Key, msg.Value, msg.TopicPartition.Topic, msg.Timestamp)
+			}
+		case MessageDecoder:
 			dec = v
 		}
 	}
 	if dec == nil {
-		dec = func(key, value []byte, topic *string, timestamp time.Time) (interface{}, error) {
-			return value, nil
+		dec = func(ctx context.Context, msg *kafka.Message) (interface{}, error) {
+			return msg.Value, nil
 		}
 	}
 	kc := convertToConfigMap(mergeMap(s.p.Cfg.Comsumer.Options, cfg.Options))
-	return newKafkaReader(s.p.Cfg.Servers, cfg.Group, cfg.Topics, kc, dec)
+	return newKafkaReader(s.p.Cfg.Servers, cfg.Group, cfg.Topics, kc, dec, s.p.metrics, s.p.tracer)
 }
 
-func newKafkaReader(servers, group string, topics []string, kc kafka.ConfigMap, dec Decoder) (BatchReader, error) {
+func newKafkaReader(servers, group string, topics []string, kc kafka.ConfigMap, dec MessageDecoder, m *kafkaMetrics, t tracer) (BatchReader, error) {
 	kc["bootstrap.servers"] = servers
 	kc["group.id"] = group
 	kc["enable.auto.offset.store"] = false
@@ -70,17 +84,23 @@ func newKafkaReader(servers, group string, topics []string, kc kafka.ConfigMap,
 	delete(kc, "auto.offset.reset")
 	delete(kc, "auto.commit.interval.ms")
 	return &kafkaBatchReader{
-		kc:     kc,
-		topics: topics,
-		decode: dec,
+		kc:      kc,
+		group:   group,
+		topics:  topics,
+		decode:  dec,
+		metrics: m,
+		tracer:  t,
 	}, nil
 }
 
 type kafkaBatchReader struct {
 	kc       kafka.ConfigMap
+	group    string
 	topics   []string
 	consumer *kafka.Consumer
-	decode   Decoder
+	decode   MessageDecoder
+	metrics  *kafkaMetrics
+	tracer   tracer
 }
 
 func (r *kafkaBatchReader) ReadN(buf []interface{}, timeout time.Duration) (int, error) {
@@ -120,8 +140,11 @@ loop:
 			r.Close()
 			return offset, err
 		}
+		r.metrics.observeLag(r.consumer, r.group, msg)
 
-		data, err := r.decode(msg.Key, msg.Value, msg.TopicPartition.Topic, msg.Timestamp)
+		ctx, span := r.tracer.startConsume(r.group, msg, semconv.MessagingOperationReceive)
+		data, err := r.decode(ctx, msg)
+		endSpan(span, err)
 		if err != nil {
 			// ingore decode error
 			continue
@@ -15,11 +15,14 @@
 package kafka
 
 import (
+	"context"
 	"time"
 
 	"github.com/confluentinc/confluent-kafka-go/kafka"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
 
 	"github.com/erda-project/erda-infra/pkg/numutil"
+	injectcontext "github.com/erda-project/erda-infra/pkg/trace/inject/context"
 )
 
 // ConsumerConfig .
@@ -33,6 +36,9 @@ type ConsumerConfig struct {
 // ConsumerFunc .
 type ConsumerFunc func(key []byte, value []byte, topic *string, timestamp time.Time) error
 
+// MessageHandler handles the message with headers, ctx carries the consumer span continuing the trace of producer.
+type MessageHandler func(ctx context.Context, msg *kafka.Message) error
+
 func mergeMap(a, b map[string]interface{}) map[string]interface{} {
 	if a == nil || len(a) == 0 {
 		return b
@@ -55,6 +61,22 @@ func (s *service) NewConsumer(cfg *ConsumerConfig, handler ConsumerFunc, options
 }
 
 func (s *service) NewConsumerWitchCreator(cfg *ConsumerConfig, creator func(i int) (ConsumerFunc, error), opts ...ConsumerOption) error {
+	return s.NewMessageConsumerWithCreator(cfg, func(i int) (MessageHandler, error) {
+		handler, err := creator(i)
+		if err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context, msg *kafka.Message) error {
+			return handler(msg.Key, msg.Value, msg.TopicPartition.Topic, msg.Timestamp)
+		}, nil
+	}, opts...)
+}
+
+func (s *service) NewMessageConsumer(cfg *ConsumerConfig, handler MessageHandler, options ...ConsumerOption) error {
+	return s.NewMessageConsumerWithCreator(cfg, func(int) (MessageHandler, error) { return handler, nil }, options...)
+}
+
+func (s *service) NewMessageConsumerWithCreator(cfg *ConsumerConfig, creator func(i int) (MessageHandler, error), opts ...ConsumerOption) error {
 	options := mergeMap(s.p.Cfg.Comsumer.Options, cfg.Options)
 	parallelism := numutil.MustInt(cfg.Parallelism)
 	var consumerListener func(i int, c *kafka.Consumer)
@@ -78,7 +100,7 @@ func (s *service) NewConsumerWitchCreator(cfg *ConsumerConfig, creator func(i in
 		if err != nil {
 			return err
 		}
-		go func(i int, handler ConsumerFunc) {
+		go func(i int, handler MessageHandler) {
 		loop:
 			for {
 				consumer, err := kafka.NewConsumer(&kc)
@@ -114,7 +136,10 @@ func (s *service) NewConsumerWitchCreator(cfg *ConsumerConfig, creator func(i in
 						}
 						continue
 					}
-					err = handler(message.Key, message.Value, message.TopicPartition.Topic, message.Timestamp)
+					s.p.metrics.observeLag(consumer, cfg.Group, message)
+					start := time.Now()
+					err = s.handle(handler, cfg.Group, message)
+					s.p.metrics.observeProcess(cfg.Group, message, time.Since(start), err)
 					if err != nil {
 						s.log.Errorf("fail to process message: %v", err)
 					}
@@ -126,6 +151,15 @@ func (s *service) NewConsumerWitchCreator(cfg *ConsumerConfig, creator func(i in
 	return nil
 }
 
+// handle calls handler in the consumer span of message, the span is kept in goroutine context for hook-free wrappers.
+func (s *service) handle(handler MessageHandler, group string, message *kafka.Message) (err error) {
+	ctx, span := s.p.tracer.startConsume(group, message, semconv.MessagingOperationDeliver)
+	defer func() { endSpan(span, err) }()
+	injectcontext.SetContext(ctx)
+	defer injectcontext.ClearContext()
+	return handler(ctx, message)
+}
+
 func convertToConfigMap(m map[string]interface{}) kafka.ConfigMap {
 	cm := make(kafka.ConfigMap, len(m))
 	for k, v := range m {
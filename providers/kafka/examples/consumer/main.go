@@ -18,7 +18,8 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"time"
+
+	confluent "github.com/confluentinc/confluent-kafka-go/kafka"
 
 	"github.com/erda-project/erda-infra/base/logs"
 	"github.com/erda-project/erda-infra/base/servicehub"
@@ -36,7 +37,7 @@ type provider struct {
 }
 
 func (p *provider) Run(ctx context.Context) error {
-	p.Kafka.NewConsumer(&p.Cfg.Input, p.invoke)
+	p.Kafka.NewMessageConsumer(&p.Cfg.Input, p.invoke)
 	for {
 		select {
 		case <-ctx.Done():
@@ -45,8 +46,8 @@ func (p *provider) Run(ctx context.Context) error {
 	}
 }
 
-func (p *provider) invoke(key []byte, value []byte, topic *string, timestamp time.Time) error {
-	fmt.Println(string(value))
+func (p *provider) invoke(ctx context.Context, msg *confluent.Message) error {
+	fmt.Println(string(msg.Value), msg.Headers)
 	return nil
 }
 
@@ -15,12 +15,15 @@
 package kafka
 
 import (
+	"fmt"
 	"reflect"
 
 	"github.com/confluentinc/confluent-kafka-go/kafka"
 	"github.com/erda-project/erda-infra/base/logs"
 	"github.com/erda-project/erda-infra/base/servicehub"
 	writer "github.com/erda-project/erda-infra/pkg/parallel-writer"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Interface .
@@ -28,6 +31,8 @@ type Interface interface {
 	NewBatchReader(c *BatchReaderConfig, options ...BatchReaderOption) (BatchReader, error)
 	NewConsumer(c *ConsumerConfig, handler ConsumerFunc, options ...ConsumerOption) error
 	NewConsumerWitchCreator(c *ConsumerConfig, creator func(i int) (ConsumerFunc, error), options ...ConsumerOption) error
+	NewMessageConsumer(c *ConsumerConfig, handler MessageHandler, options ...ConsumerOption) error
+	NewMessageConsumerWithCreator(c *ConsumerConfig, creator func(i int) (MessageHandler, error), options ...ConsumerOption) error
 	NewProducer(c *ProducerConfig, options ...ProducerOption) (writer.Writer, error)
 	Servers() string
 	ProduceChannelSize() int
@@ -59,12 +64,27 @@ type config struct {
 type provider struct {
 	Cfg      *config
 	Log      logs.Logger
+	Metrics  prometheus.Registerer `autowired:"metrics" optional:"true"`
+	Tracer   trace.TracerProvider  `autowired:"tracer-provider" optional:"true"`
 	producer sharedProducer
+	metrics  *kafkaMetrics
+	writers  *writer.Metrics
+	tracer   tracer
 }
 
 // Init .
 func (p *provider) Init(ctx servicehub.Context) error {
+	if p.Metrics != nil {
+		m := newKafkaMetrics()
+		if err := p.Metrics.Register(m); err != nil {
+			return fmt.Errorf("fail to register kafka metrics: %s", err)
+		}
+		p.metrics = m
+		p.writers = writer.NewMetrics(p.Metrics)
+	}
+	p.tracer = tracer{tp: p.Tracer}
 	p.producer.log = p.Log
+	p.producer.metrics = p.metrics
 	return nil
 }
 
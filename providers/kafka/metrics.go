@@ -0,0 +1,149 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// kafkaMetrics records metrics of producers and consumers, it's safe to call methods on nil.
+type kafkaMetrics struct {
+	produced        *prometheus.CounterVec
+	queueDepth      *prometheus.Desc
+	consumed        *prometheus.CounterVec
+	processDuration *prometheus.HistogramVec
+	lag             *prometheus.GaugeVec
+
+	lock      sync.Mutex
+	producers map[*kafka.Producer]string
+}
+
+func newKafkaMetrics() *kafkaMetrics {
+	return &kafkaMetrics{
+		produced: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "producer_messages_total",
+			Help: "Total number of delivery reports of produced messages.",
+		}, []string{"topic", "status"}),
+		queueDepth: prometheus.NewDesc("producer_queue_depth",
+			"Number of messages waiting to be delivered.", []string{"producer"}, nil),
+		consumed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "consumer_messages_total",
+			Help: "Total number of processed messages.",
+		}, []string{"group", "topic", "status"}),
+		processDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "consumer_process_duration_seconds",
+			Help: "Latency of processing messages.",
+		}, []string{"group", "topic"}),
+		lag: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "consumer_lag",
+			Help: "Number of messages behind the high watermark of partitions.",
+		}, []string{"group", "topic", "partition"}),
+		producers: make(map[*kafka.Producer]string),
+	}
+}
+
+// Describe .
+func (m *kafkaMetrics) Describe(ch chan<- *prometheus.Desc) {
+	m.produced.Describe(ch)
+	ch <- m.queueDepth
+	m.consumed.Describe(ch)
+	m.processDuration.Describe(ch)
+	m.lag.Describe(ch)
+}
+
+// Collect .
+func (m *kafkaMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.produced.Collect(ch)
+	m.consumed.Collect(ch)
+	m.processDuration.Collect(ch)
+	m.lag.Collect(ch)
+
+	depth := make(map[string]int)
+	m.lock.Lock()
+	for kp, name := range m.producers {
+		depth[name] += kp.Len()
+	}
+	m.lock.Unlock()
+	for name, n := range depth {
+		ch <- prometheus.MustNewConstMetric(m.queueDepth, prometheus.GaugeValue, float64(n), name)
+	}
+}
+
+func (m *kafkaMetrics) addProducer(name string, kp *kafka.Producer) {
+	if m == nil {
+		return
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.producers[kp] = name
+}
+
+func (m *kafkaMetrics) removeProducer(kp *kafka.Producer) {
+	if m == nil {
+		return
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	delete(m.producers, kp)
+}
+
+func (m *kafkaMetrics) observeDelivery(msg *kafka.Message) {
+	if m == nil {
+		return
+	}
+	var topic string
+	if msg.TopicPartition.Topic != nil {
+		topic = *msg.TopicPartition.Topic
+	}
+	m.produced.WithLabelValues(topic, statusOf(msg.TopicPartition.Error)).Inc()
+}
+
+func (m *kafkaMetrics) observeProcess(group string, msg *kafka.Message, d time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	topic := *msg.TopicPartition.Topic
+	m.consumed.WithLabelValues(group, topic, statusOf(err)).Inc()
+	m.processDuration.WithLabelValues(group, topic).Observe(d.Seconds())
+}
+
+// observeLag records lag of the partition of msg by the cached high watermark of consumer.
+func (m *kafkaMetrics) observeLag(consumer *kafka.Consumer, group string, msg *kafka.Message) {
+	if m == nil {
+		return
+	}
+	tp := msg.TopicPartition
+	_, high, err := consumer.GetWatermarkOffsets(*tp.Topic, tp.Partition)
+	if err != nil || high < 0 {
+		return
+	}
+	lag := high - int64(tp.Offset) - 1
+	if lag < 0 {
+		lag = 0
+	}
+	m.lag.WithLabelValues(group, *tp.Topic, strconv.Itoa(int(tp.Partition))).Set(float64(lag))
+}
+
+func statusOf(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "success"
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestKafkaMetrics(t *testing.T) {
+	topic := "t1"
+	newMessage := func(err error) *kafka.Message {
+		return &kafka.Message{TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: 1, Error: err}}
+	}
+
+	var nilMetrics *kafkaMetrics
+	nilMetrics.observeDelivery(newMessage(nil))
+	nilMetrics.observeProcess("g1", newMessage(nil), time.Second, nil)
+
+	reg := prometheus.NewRegistry()
+	m := newKafkaMetrics()
+	reg.MustRegister(m)
+	m.observeDelivery(newMessage(nil))
+	m.observeDelivery(newMessage(errors.New("failed")))
+	m.observeProcess("g1", newMessage(nil), time.Second, nil)
+	m.observeProcess("g1", newMessage(nil), time.Second, errors.New("failed"))
+
+	want := `
+# HELP consumer_messages_total Total number of processed messages.
+# TYPE consumer_messages_total counter
+consumer_messages_total{group="g1",status="error",topic="t1"} 1
+consumer_messages_total{group="g1",status="success",topic="t1"} 1
+# HELP producer_messages_total Total number of delivery reports of produced messages.
+# TYPE producer_messages_total counter
+producer_messages_total{status="error",topic="t1"} 1
+producer_messages_total{status="success",topic="t1"} 1
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want), "consumer_messages_total", "producer_messages_total"); err != nil {
+		t.Error(err)
+	}
+}
@@ -15,12 +15,14 @@
 package kafka
 
 import (
+	"context"
 	"encoding/json"
 	"sync"
 	"time"
 
 	"github.com/confluentinc/confluent-kafka-go/kafka"
 	"github.com/recallsong/go-utils/reflectx"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/erda-project/erda-infra/base/logs"
 	writer "github.com/erda-project/erda-infra/pkg/parallel-writer"
@@ -28,9 +30,12 @@ import (
 
 // Message .
 type Message struct {
-	Topic *string
-	Data  []byte
-	Key   []byte
+	Topic   *string
+	Data    []byte
+	Key     []byte
+	Headers []kafka.Header
+	// Context carries the span of the message, its trace context is injected into headers.
+	Context context.Context
 }
 
 // StringMessage .
@@ -65,7 +70,7 @@ func WithAsyncWriteErrorHandler(eh func(error) error) ProducerOption {
 	return &producerOption{_eh: eh}
 }
 
-func newProducer(servers string, extra map[string]interface{}, log logs.Logger) (*kafka.Producer, error) {
+func newProducer(servers string, extra map[string]interface{}, log logs.Logger, m *kafkaMetrics, name string) (*kafka.Producer, error) {
 	kc := kafka.ConfigMap{"go.batch.producer": true}
 	if extra != nil {
 		for k, v := range extra {
@@ -77,14 +82,16 @@ func newProducer(servers string, extra map[string]interface{}, log logs.Logger)
 	if err != nil {
 		return nil, err
 	}
-	go consumeEvents(kp, log)
+	m.addProducer(name, kp)
+	go consumeEvents(kp, log, m)
 	return kp, err
 }
 
-func consumeEvents(kp *kafka.Producer, log logs.Logger) {
+func consumeEvents(kp *kafka.Producer, log logs.Logger, m *kafkaMetrics) {
 	for e := range kp.Events() {
 		switch ev := e.(type) {
 		case *kafka.Message:
+			m.observeDelivery(ev)
 			if ev.TopicPartition.Error != nil {
 				log.Errorf("Kafka delivery failed: %v", ev.TopicPartition)
 			}
@@ -98,6 +105,7 @@ type sharedProducer struct {
 	instance *kafka.Producer
 	refs     int
 	log      logs.Logger
+	metrics  *kafkaMetrics
 }
 
 func (p *sharedProducer) release() error {
@@ -108,6 +116,7 @@ func (p *sharedProducer) release() error {
 	}
 	p.refs--
 	if p.refs == 0 {
+		p.metrics.removeProducer(p.instance)
 		p.instance.Close()
 	}
 	return nil
@@ -117,7 +126,7 @@ func (p *sharedProducer) get(servers string, extra map[string]interface{}) (*kaf
 	p.lock.Lock()
 	defer p.lock.Unlock()
 	if p.refs == 0 {
-		kp, err := newProducer(servers, extra, p.log)
+		kp, err := newProducer(servers, extra, p.log, p.metrics, "shared")
 		if err != nil {
 			return nil, err
 		}
@@ -128,9 +137,10 @@ func (p *sharedProducer) get(servers string, extra map[string]interface{}) (*kaf
 }
 
 type producer struct {
-	kp    *kafka.Producer
-	close func() error
-	topic string
+	kp     *kafka.Producer
+	close  func() error
+	topic  string
+	tracer tracer
 }
 
 func (p *producer) ProduceChannelSize() int {
@@ -157,8 +167,10 @@ func (p *producer) WriteN(data ...interface{}) (int, error) {
 
 func (p *producer) publish(data interface{}) error {
 	var (
-		bytes []byte
-		key   []byte
+		bytes   []byte
+		key     []byte
+		headers []kafka.Header
+		ctx     context.Context
 	)
 	topic := &p.topic
 	switch val := data.(type) {
@@ -168,6 +180,8 @@ func (p *producer) publish(data interface{}) error {
 		}
 		bytes = val.Data
 		key = val.Key
+		headers = append(headers, val.Headers...)
+		ctx = val.Context
 	case *StringMessage:
 		if val.Topic != nil {
 			topic = val.Topic
@@ -184,11 +198,17 @@ func (p *producer) publish(data interface{}) error {
 		}
 		bytes = data
 	}
-	p.kp.ProduceChannel() <- &kafka.Message{
+	msg := &kafka.Message{
 		Value:          bytes,
 		Key:            key,
+		Headers:        headers,
 		TopicPartition: kafka.TopicPartition{Topic: topic, Partition: kafka.PartitionAny},
 	}
+	if ctx != nil && trace.SpanContextFromContext(ctx).IsValid() {
+		span := p.tracer.startPublish(ctx, msg)
+		defer span.End()
+	}
+	p.kp.ProduceChannel() <- msg
 	return nil
 }
 
@@ -210,13 +230,14 @@ func (s *service) NewProducer(c *ProducerConfig, options ...ProducerOption) (wri
 		}
 		return writer.ParallelBatch(func(uint64) writer.Writer {
 			return &producer{
-				kp:    kp,
-				close: s.p.producer.release,
-				topic: c.Topic,
+				kp:     kp,
+				close:  s.p.producer.release,
+				topic:  c.Topic,
+				tracer: s.p.tracer,
 			}
-		}, c.Parallelism, c.Batch.Size, c.Batch.Timeout, eh), nil
+		}, c.Parallelism, c.Batch.Size, c.Batch.Timeout, eh, writer.WithMetrics(s.p.writers, s.name)), nil
 	}
-	kp, err := newProducer(s.p.Cfg.Servers, c.Options, s.log)
+	kp, err := newProducer(s.p.Cfg.Servers, c.Options, s.log, s.p.metrics, s.name)
 	if err != nil {
 		return nil, err
 	}
@@ -224,12 +245,14 @@ func (s *service) NewProducer(c *ProducerConfig, options ...ProducerOption) (wri
 		return &producer{
 			kp: kp,
 			close: func() error {
+				s.p.metrics.removeProducer(kp)
 				kp.Close()
 				return nil
 			},
-			topic: c.Topic,
+			topic:  c.Topic,
+			tracer: s.p.tracer,
 		}
-	}, c.Parallelism, c.Batch.Size, c.Batch.Timeout, eh), nil
+	}, c.Parallelism, c.Batch.Size, c.Batch.Timeout, eh, writer.WithMetrics(s.p.writers, s.name)), nil
 }
 
 func (s *service) producerError(err error) error {
@@ -0,0 +1,79 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"context"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+
+	tracekafka "github.com/erda-project/erda-infra/pkg/trace/instrument/kafka"
+)
+
+const tracerName = "github.com/erda-project/erda-infra/providers/kafka"
+
+type tracer struct {
+	tp trace.TracerProvider
+}
+
+func (t tracer) tracer() trace.Tracer {
+	tp := t.tp
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return tp.Tracer(tracerName)
+}
+
+// startPublish starts a producer span as child of the span in ctx, and injects its context into headers of msg.
+func (t tracer) startPublish(ctx context.Context, msg *kafka.Message) trace.Span {
+	var topic string
+	if msg.TopicPartition.Topic != nil {
+		topic = *msg.TopicPartition.Topic
+	}
+	return tracekafka.StartPublish(ctx, t.tracer(), topic, tracekafka.HeaderCarrier[kafka.Header]{Headers: &msg.Headers})
+}
+
+// startConsume extracts the trace context from headers of msg, and starts a consumer span continuing it.
+func (t tracer) startConsume(group string, msg *kafka.Message, operation attribute.KeyValue) (context.Context, trace.Span) {
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), tracekafka.HeaderCarrier[kafka.Header]{Headers: &msg.Headers})
+	var topic string
+	if msg.TopicPartition.Topic != nil {
+		topic = *msg.TopicPartition.Topic
+	}
+	return t.tracer().Start(ctx, topic+" "+operation.Value.AsString(),
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			operation,
+			semconv.MessagingDestinationName(topic),
+			semconv.MessagingKafkaConsumerGroup(group),
+			semconv.MessagingKafkaDestinationPartition(int(msg.TopicPartition.Partition)),
+			semconv.MessagingKafkaMessageOffset(int(msg.TopicPartition.Offset)),
+		),
+	)
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
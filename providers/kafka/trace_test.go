@@ -0,0 +1,66 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"context"
+	"testing"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+
+	tracekafka "github.com/erda-project/erda-infra/pkg/trace/instrument/kafka"
+)
+
+func TestTracePropagation(t *testing.T) {
+	propagator := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTextMapPropagator(propagator)
+
+	sr := tracetest.NewSpanRecorder()
+	tr := tracer{tp: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))}
+	ctx, parent := tr.tracer().Start(context.Background(), "parent")
+
+	topic := "events"
+	msg := &kafka.Message{
+		Headers:        []kafka.Header{{Key: "user", Value: []byte("x")}},
+		TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: 1, Offset: 10},
+	}
+	tr.startPublish(ctx, msg).End()
+	parent.End()
+	assert.NotEmpty(t, tracekafka.HeaderCarrier[kafka.Header]{Headers: &msg.Headers}.Get("traceparent"))
+	assert.Equal(t, "x", tracekafka.HeaderCarrier[kafka.Header]{Headers: &msg.Headers}.Get("user"))
+
+	cctx, span := tr.startConsume("group", msg, semconv.MessagingOperationDeliver)
+	endSpan(span, nil)
+	assert.Equal(t, parent.SpanContext().TraceID(), trace.SpanContextFromContext(cctx).TraceID())
+
+	spans := sr.Ended()
+	if !assert.Len(t, spans, 3) {
+		return
+	}
+	publish, consume := spans[0], spans[2]
+	assert.Equal(t, "events publish", publish.Name())
+	assert.Equal(t, trace.SpanKindProducer, publish.SpanKind())
+	assert.Equal(t, "events deliver", consume.Name())
+	assert.Equal(t, trace.SpanKindConsumer, consume.SpanKind())
+	assert.Equal(t, publish.SpanContext().SpanID(), consume.Parent().SpanID())
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/erda-project/erda-infra/base/logs"
 	"github.com/erda-project/erda-infra/base/servicehub"
 	writer "github.com/erda-project/erda-infra/pkg/parallel-writer"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Interface .
@@ -39,8 +40,9 @@ type config struct {
 
 // provider .
 type provider struct {
-	Cfg *config
-	Log logs.Logger
+	Cfg    *config
+	Log    logs.Logger
+	Tracer trace.TracerProvider `autowired:"tracer-provider" optional:"true"`
 }
 
 // Init .
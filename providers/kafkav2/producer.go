@@ -22,6 +22,8 @@ import (
 
 	"github.com/recallsong/go-utils/reflectx"
 	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/erda-project/erda-infra/base/logs"
 	writer "github.com/erda-project/erda-infra/pkg/parallel-writer"
@@ -29,9 +31,12 @@ import (
 
 // Message .
 type Message struct {
-	Topic string
-	Data  []byte
-	Key   []byte
+	Topic   string
+	Data    []byte
+	Key     []byte
+	Headers []kafka.Header
+	// Context carries the span of the message, its trace context is injected into headers.
+	Context context.Context
 }
 
 // ProducerConfig .
@@ -61,9 +66,10 @@ func WithAsyncWriteErrorHandler(eh func(error) error) ProducerOption {
 	return &producerOption{_eh: eh}
 }
 
-func newProducer(servers string, cfg ProducerConfig, log logs.Logger) (*producer, error) {
+func newProducer(servers string, cfg ProducerConfig, log logs.Logger, tp trace.TracerProvider) (*producer, error) {
 	prod := &producer{
 		logger: log,
+		tp:     tp,
 	}
 	pw := &kafka.Writer{
 		Addr:                   kafka.TCP(strings.Split(servers, ",")...),
@@ -85,6 +91,7 @@ func newProducer(servers string, cfg ProducerConfig, log logs.Logger) (*producer
 type producer struct {
 	logger logs.Logger
 	pw     *kafka.Writer
+	tp     trace.TracerProvider
 }
 
 func (p *producer) Write(data interface{}) error {
@@ -103,10 +110,12 @@ func (p *producer) WriteN(data ...interface{}) (int, error) {
 
 func (p *producer) publish(data interface{}) error {
 	var (
-		value []byte
-		key   []byte
+		value   []byte
+		key     []byte
+		headers []kafka.Header
 	)
 	topic := ""
+	var ctx context.Context
 
 	switch val := data.(type) {
 	case Message:
@@ -115,6 +124,8 @@ func (p *producer) publish(data interface{}) error {
 		}
 		value = val.Data
 		key = val.Key
+		headers = append(headers, val.Headers...)
+		ctx = val.Context
 	case []byte:
 		value = val
 	case string:
@@ -126,26 +137,28 @@ func (p *producer) publish(data interface{}) error {
 		}
 		value = data
 	}
+	msg := kafka.Message{
+		Key:     key,
+		Value:   value,
+		Headers: headers,
+	}
 	if p.pw.Topic == "" {
-		err := p.pw.WriteMessages(context.TODO(), kafka.Message{
-			Topic: topic,
-			Key:   key,
-			Value: value,
-		})
-		if err != nil {
-			return err
-		}
-	} else {
-		err := p.pw.WriteMessages(context.TODO(), kafka.Message{
-			Key:   key,
-			Value: value,
-		})
-		if err != nil {
-			return err
-		}
+		msg.Topic = topic
 	}
-
-	return nil
+	if ctx == nil || !trace.SpanContextFromContext(ctx).IsValid() {
+		return p.pw.WriteMessages(context.TODO(), msg)
+	}
+	if p.pw.Topic != "" {
+		topic = p.pw.Topic
+	}
+	span := startPublish(ctx, p.tp, topic, &msg)
+	defer span.End()
+	err := p.pw.WriteMessages(context.TODO(), msg)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
 }
 
 func (p *producer) Close() error {
@@ -159,7 +172,7 @@ func (s *service) NewProducer(cfg ProducerConfig, options ...ProducerOption) (wr
 			eh = item.errHandler()
 		}
 	}
-	prod, err := newProducer(s.p.Cfg.Servers, cfg, s.log)
+	prod, err := newProducer(s.p.Cfg.Servers, cfg, s.log, s.p.Tracer)
 	if err != nil {
 		return nil, err
 	}
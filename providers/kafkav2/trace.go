@@ -0,0 +1,35 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafkav2
+
+import (
+	"context"
+
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+
+	tracekafka "github.com/erda-project/erda-infra/pkg/trace/instrument/kafka"
+)
+
+const tracerName = "github.com/erda-project/erda-infra/providers/kafkav2"
+
+// startPublish starts a producer span as child of the span in ctx, and injects its context into headers of msg.
+func startPublish(ctx context.Context, tp trace.TracerProvider, topic string, msg *kafka.Message) trace.Span {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return tracekafka.StartPublish(ctx, tp.Tracer(tracerName), topic, tracekafka.HeaderCarrier[kafka.Header]{Headers: &msg.Headers})
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafkav2
+
+import (
+	"context"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracePropagation(t *testing.T) {
+	propagator := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTextMapPropagator(propagator)
+
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	ctx, parent := tp.Tracer(tracerName).Start(context.Background(), "parent")
+
+	msg := &kafka.Message{Headers: []kafka.Header{{Key: "user", Value: []byte("x")}}}
+	startPublish(ctx, tp, "events", msg).End()
+	parent.End()
+
+	// the consumer continues the trace from headers
+	cctx := otel.GetTextMapPropagator().Extract(context.Background(), propagation.MapCarrier{
+		"traceparent": string(headerValue(msg, "traceparent")),
+	})
+	assert.Equal(t, parent.SpanContext().TraceID(), trace.SpanContextFromContext(cctx).TraceID())
+	assert.Equal(t, "x", string(headerValue(msg, "user")))
+
+	spans := sr.Ended()
+	if !assert.Len(t, spans, 2) {
+		return
+	}
+	publish := spans[0]
+	assert.Equal(t, "events publish", publish.Name())
+	assert.Equal(t, trace.SpanKindProducer, publish.SpanKind())
+	assert.Equal(t, parent.SpanContext().SpanID(), publish.Parent().SpanID())
+	assert.Equal(t, publish.SpanContext().SpanID(), trace.SpanContextFromContext(cctx).SpanID())
+}
+
+func headerValue(msg *kafka.Message, key string) []byte {
+	for _, h := range msg.Headers {
+		if h.Key == key {
+			return h.Value
+		}
+	}
+	return nil
+}
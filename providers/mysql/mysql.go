@@ -21,11 +21,15 @@ import (
 
 	_ "github.com/go-sql-driver/mysql" // mysql client driver package
 	"github.com/jinzhu/gorm"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 
 	"github.com/erda-project/erda-infra/base/logs"
 	"github.com/erda-project/erda-infra/base/servicehub"
 	"github.com/erda-project/erda-infra/pkg/mysqldriver"
 	"github.com/erda-project/erda-infra/pkg/numutil"
+	"github.com/erda-project/erda-infra/pkg/trace/inject/hook"
+	tracesql "github.com/erda-project/erda-infra/pkg/trace/instrument/sql"
 )
 
 // Interface .
@@ -54,6 +58,7 @@ type config struct {
 	MySQLCaCertPath     string        `file:"ca_cert_path" env:"MYSQL_CACERTPATH"`
 	MySQLClientCertPath string        `file:"client_cert_path" env:"MYSQL_CLIENTCERTPATH"`
 	MySQLClientKeyPath  string        `file:"client_key_path" env:"MYSQL_CLIENTKEYPATH"`
+	MySQLTrace          bool          `file:"trace" env:"MYSQL_TRACE" default:"false"`
 }
 
 func (c *config) url() string {
@@ -71,9 +76,10 @@ func (c *config) url() string {
 
 // provider .
 type provider struct {
-	Cfg *config
-	Log logs.Logger
-	db  *gorm.DB
+	Cfg     *config
+	Log     logs.Logger
+	Metrics prometheus.Registerer `autowired:"metrics" optional:"true"`
+	db      *gorm.DB
 }
 
 // Init .
@@ -83,7 +89,7 @@ func (p *provider) Init(ctx servicehub.Context) error {
 		return err
 	}
 
-	db, err := gorm.Open("mysql", p.Cfg.url())
+	db, err := p.open()
 	if err != nil {
 		return fmt.Errorf("fail to connect mysql: %s", err)
 	}
@@ -92,6 +98,11 @@ func (p *provider) Init(ctx servicehub.Context) error {
 	db.DB().SetMaxIdleConns(numutil.MustInt(p.Cfg.MySQLMaxIdleConns))
 	db.DB().SetMaxOpenConns(numutil.MustInt(p.Cfg.MySQLMaxOpenConns))
 	db.DB().SetConnMaxLifetime(p.Cfg.MySQLMaxLifeTime)
+	if p.Metrics != nil {
+		if err := p.Metrics.Register(collectors.NewDBStatsCollector(db.DB(), p.Cfg.MySQLDatabase)); err != nil {
+			return fmt.Errorf("fail to register mysql metrics: %s", err)
+		}
+	}
 	p.db = db
 	if p.Cfg.MySQLDebug {
 		p.db = p.db.Debug()
@@ -99,6 +110,18 @@ func (p *provider) Init(ctx servicehub.Context) error {
 	return nil
 }
 
+// open opens by the traced driver if tracing is enabled and sql.Open isn't hooked.
+func (p *provider) open() (*gorm.DB, error) {
+	if !p.Cfg.MySQLTrace || hook.Enabled() {
+		return gorm.Open("mysql", p.Cfg.url())
+	}
+	sqlDB, err := tracesql.Open("mysql", p.Cfg.url())
+	if err != nil {
+		return nil, err
+	}
+	return gorm.Open("mysql", sqlDB)
+}
+
 func (p *provider) DB() *gorm.DB { return p.db }
 
 func (p *provider) Provide(ctx servicehub.DependencyContext, args ...interface{}) interface{} {
@@ -26,6 +26,8 @@ import (
 	"github.com/erda-project/erda-infra/base/servicehub"
 	"github.com/erda-project/erda-infra/pkg/mysqldriver"
 	"github.com/erda-project/erda-infra/pkg/numutil"
+	"github.com/erda-project/erda-infra/pkg/trace/inject/hook"
+	tracesql "github.com/erda-project/erda-infra/pkg/trace/instrument/sql"
 )
 
 var (
@@ -76,6 +78,7 @@ type config struct {
 	MySQLCaCertPath     string        `file:"ca_cert_path" env:"MYSQL_CACERTPATH"`
 	MySQLClientCertPath string        `file:"client_cert_path" env:"MYSQL_CLIENTCERTPATH"`
 	MySQLClientKeyPath  string        `file:"client_key_path" env:"MYSQL_CLIENTKEYPATH"`
+	MySQLTrace          bool          `file:"trace" env:"MYSQL_TRACE" default:"false"`
 }
 
 func (c *config) url() string {
@@ -99,6 +102,18 @@ type provider struct {
 }
 
 // Init implements servicehub.ProviderInitializer
+// dialector opens by the traced driver if tracing is enabled and sql.Open isn't hooked.
+func (p *provider) dialector() (gorm.Dialector, error) {
+	if !p.Cfg.MySQLTrace || hook.Enabled() {
+		return mysql.Open(p.Cfg.url()), nil
+	}
+	sqlDB, err := tracesql.Open("mysql", p.Cfg.url())
+	if err != nil {
+		return nil, err
+	}
+	return mysql.New(mysql.Config{DSN: p.Cfg.url(), Conn: sqlDB}), nil
+}
+
 func (p *provider) Init(ctx servicehub.Context) error {
 	err := mysqldriver.OpenTLS(p.Cfg.MySQLTLS, p.Cfg.MySQLCaCertPath, p.Cfg.MySQLClientCertPath, p.Cfg.MySQLClientKeyPath)
 	if err != nil {
@@ -106,7 +121,11 @@ func (p *provider) Init(ctx servicehub.Context) error {
 	}
 
 	logrus.WithField("provider", name).Infoln("init")
-	db, err := gorm.Open(mysql.Open(p.Cfg.url()))
+	dialector, err := p.dialector()
+	if err != nil {
+		return fmt.Errorf("fail to connect mysql: %s", err)
+	}
+	db, err := gorm.Open(dialector)
 	if err != nil {
 		return fmt.Errorf("fail to connect mysql: %s", err)
 	}
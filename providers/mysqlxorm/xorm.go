@@ -21,7 +21,10 @@ import (
 	"time"
 
 	_ "github.com/go-sql-driver/mysql" // mysql client driver package
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"xorm.io/xorm"
+	"xorm.io/xorm/core"
 	xormlog "xorm.io/xorm/log"
 	"xorm.io/xorm/names"
 
@@ -29,6 +32,8 @@ import (
 	"github.com/erda-project/erda-infra/base/servicehub"
 	"github.com/erda-project/erda-infra/pkg/mysqldriver"
 	"github.com/erda-project/erda-infra/pkg/numutil"
+	"github.com/erda-project/erda-infra/pkg/trace/inject/hook"
+	tracesql "github.com/erda-project/erda-infra/pkg/trace/instrument/sql"
 )
 
 // Interface .
@@ -60,6 +65,7 @@ type config struct {
 	MySQLCaCertPath     string        `file:"ca_cert_path" env:"MYSQL_CACERTPATH"`
 	MySQLClientCertPath string        `file:"client_cert_path" env:"MYSQL_CLIENTCERTPATH"`
 	MySQLClientKeyPath  string        `file:"client_key_path" env:"MYSQL_CLIENTKEYPATH"`
+	MySQLTrace          bool          `file:"trace" env:"MYSQL_TRACE" default:"false"`
 
 	MySQLPingWhenInit   bool   `file:"ping_when_init" env:"MYSQL_PING_WHEN_INIT" default:"true"`
 	MySQLPingTimeoutSec uint64 `file:"ping_timeout_sec" env:"MYSQL_PING_TIMEOUT_SEC" default:"10"`
@@ -80,9 +86,22 @@ func (c *config) url() string {
 
 // provider .
 type provider struct {
-	Cfg *config
-	Log logs.Logger
-	db  *xorm.Engine
+	Cfg     *config
+	Log     logs.Logger
+	Metrics prometheus.Registerer `autowired:"metrics" optional:"true"`
+	db      *xorm.Engine
+}
+
+// newEngine opens by the traced driver if tracing is enabled and sql.Open isn't hooked.
+func (p *provider) newEngine() (*xorm.Engine, error) {
+	if !p.Cfg.MySQLTrace || hook.Enabled() {
+		return xorm.NewEngine("mysql", p.Cfg.url())
+	}
+	sqlDB, err := tracesql.Open("mysql", p.Cfg.url())
+	if err != nil {
+		return nil, err
+	}
+	return xorm.NewEngineWithDB("mysql", p.Cfg.url(), core.FromDB(sqlDB))
 }
 
 // Init .
@@ -92,7 +111,7 @@ func (p *provider) Init(ctx servicehub.Context) error {
 		return err
 	}
 
-	db, err := xorm.NewEngine("mysql", p.Cfg.url())
+	db, err := p.newEngine()
 	if err != nil {
 		return fmt.Errorf("failed to connect to mysql server, err: %v", err)
 	}
@@ -108,6 +127,11 @@ func (p *provider) Init(ctx servicehub.Context) error {
 	db.SetMaxOpenConns(numutil.MustInt(p.Cfg.MySQLMaxOpenConns))
 	db.SetConnMaxLifetime(p.Cfg.MySQLMaxLifeTime)
 	db.SetDisableGlobalCache(true)
+	if p.Metrics != nil {
+		if err := p.Metrics.Register(collectors.NewDBStatsCollector(db.DB().DB, p.Cfg.MySQLDatabase)); err != nil {
+			return fmt.Errorf("failed to register mysql metrics, err: %v", err)
+		}
+	}
 
 	// ping when init
 	if p.Cfg.MySQLPingWhenInit {
@@ -15,13 +15,41 @@
 package main
 
 import (
+	"context"
 	"os"
+	"time"
 
 	"github.com/erda-project/erda-infra/base/servicehub"
 	_ "github.com/erda-project/erda-infra/providers/httpserver"
-	_ "github.com/erda-project/erda-infra/providers/prometheus"
+	"github.com/erda-project/erda-infra/providers/prometheus"
 )
 
+type provider struct {
+	Metrics prometheus.Metrics `autowired:"metrics"`
+}
+
+func (p *provider) Run(ctx context.Context) error {
+	// exposed as example_ticks_total{app="examples",instance="...",provider="example"}
+	ticks := p.Metrics.Counter("ticks_total", "Total number of ticks.")
+	tick := time.NewTicker(time.Second)
+	defer tick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-tick.C:
+			ticks.WithLabelValues().Inc()
+		}
+	}
+}
+
+func init() {
+	servicehub.Register("example", &servicehub.Spec{
+		Services: []string{"example"},
+		Creator:  func() servicehub.Provider { return &provider{} },
+	})
+}
+
 func main() {
 	hub := servicehub.New()
 	hub.Run("examples", "", os.Args...)
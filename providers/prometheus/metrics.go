@@ -0,0 +1,114 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	pkgmetrics "github.com/erda-project/erda-infra/pkg/metrics"
+)
+
+// Metrics registers metrics of a provider, the names are prefixed by the namespace of the provider,
+// and the constant labels of the application are added.
+type Metrics interface {
+	prometheus.Registerer
+	// Namespace returns the prefix of metric names.
+	Namespace() string
+	// Counter returns a counter registered with name, the existing one is returned if it's already registered.
+	Counter(name, help string, labels ...string) *prometheus.CounterVec
+	// Gauge returns a gauge registered with name, the existing one is returned if it's already registered.
+	Gauge(name, help string, labels ...string) *prometheus.GaugeVec
+	// Histogram returns a histogram registered with name, the existing one is returned if it's already registered.
+	Histogram(name, help string, buckets []float64, labels ...string) *prometheus.HistogramVec
+}
+
+var metricsType = reflect.TypeOf((*Metrics)(nil)).Elem()
+
+type metrics struct {
+	prometheus.Registerer
+	namespace string
+}
+
+func newMetrics(reg prometheus.Registerer, namespace string, labels prometheus.Labels) *metrics {
+	if len(labels) > 0 {
+		reg = prometheus.WrapRegistererWith(labels, reg)
+	}
+	if len(namespace) > 0 {
+		reg = prometheus.WrapRegistererWithPrefix(namespace+"_", reg)
+	}
+	return &metrics{Registerer: reg, namespace: namespace}
+}
+
+func (m *metrics) Namespace() string { return m.namespace }
+
+func (m *metrics) Counter(name, help string, labels ...string) *prometheus.CounterVec {
+	return pkgmetrics.Register(m, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: name,
+		Help: help,
+	}, labels)).(*prometheus.CounterVec)
+}
+
+func (m *metrics) Gauge(name, help string, labels ...string) *prometheus.GaugeVec {
+	return pkgmetrics.Register(m, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: name,
+		Help: help,
+	}, labels)).(*prometheus.GaugeVec)
+}
+
+func (m *metrics) Histogram(name, help string, buckets []float64, labels ...string) *prometheus.HistogramVec {
+	if len(buckets) <= 0 {
+		buckets = prometheus.DefBuckets
+	}
+	return pkgmetrics.Register(m, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    name,
+		Help:    help,
+		Buckets: buckets,
+	}, labels)).(*prometheus.HistogramVec)
+}
+
+// joinNamespace joins parts to a valid metric name prefix, the label of provider is ignored.
+func joinNamespace(parts ...string) string {
+	var sb strings.Builder
+	for _, part := range parts {
+		if idx := strings.Index(part, "@"); idx >= 0 {
+			part = part[:idx]
+		}
+		part = sanitizeName(part)
+		if len(part) <= 0 {
+			continue
+		}
+		if sb.Len() > 0 {
+			sb.WriteByte('_')
+		}
+		sb.WriteString(part)
+	}
+	return sb.String()
+}
+
+func sanitizeName(name string) string {
+	name = strings.Trim(strings.Map(func(c rune) rune {
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == ':' {
+			return c
+		}
+		return '_'
+	}, name), "_")
+	if len(name) > 0 && name[0] >= '0' && name[0] <= '9' {
+		return "_" + name
+	}
+	return name
+}
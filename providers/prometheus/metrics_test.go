@@ -0,0 +1,141 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/erda-project/erda-infra/base/logs/logrusx"
+)
+
+func TestJoinNamespace(t *testing.T) {
+	tests := []struct {
+		parts []string
+		want  string
+	}{
+		{parts: []string{"", "kafka"}, want: "kafka"},
+		{parts: []string{"erda", "kafka-consumer@orders"}, want: "erda_kafka_consumer"},
+		{parts: []string{"", ""}, want: ""},
+		{parts: []string{"1app", "etcd.client"}, want: "_1app_etcd_client"},
+	}
+	for _, tt := range tests {
+		if got := joinNamespace(tt.parts...); got != tt.want {
+			t.Errorf("joinNamespace(%q) got %q, want %q", tt.parts, got, tt.want)
+		}
+	}
+}
+
+type dependencyContext struct {
+	service, caller string
+	typ             reflect.Type
+}
+
+func (dc *dependencyContext) Type() reflect.Type      { return dc.typ }
+func (dc *dependencyContext) Tags() reflect.StructTag { return "" }
+func (dc *dependencyContext) Service() string         { return dc.service }
+func (dc *dependencyContext) Key() string             { return dc.service }
+func (dc *dependencyContext) Label() string           { return "" }
+func (dc *dependencyContext) Caller() string          { return dc.caller }
+
+func TestProvideMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	p := &provider{
+		Cfg: &config{
+			Namespace:      "erda",
+			ProviderPrefix: true,
+			ProviderLabel:  "provider",
+			AppName:        "app1",
+			Instance:       "host1",
+			ConstLabels:    map[string]string{"cluster": "c1"},
+		},
+		registry: reg,
+		gatherer: reg,
+	}
+	if p.Provide(&dependencyContext{service: "prometheus"}) != p {
+		t.Fatalf("prometheus service should be the provider")
+	}
+	m := p.Provide(&dependencyContext{service: "metrics", caller: "kafka-consumer@orders", typ: metricsType}).(Metrics)
+	if m.Namespace() != "erda_kafka_consumer" {
+		t.Errorf("got namespace %q", m.Namespace())
+	}
+	m.Counter("messages_total", "Total number of messages.", "topic").WithLabelValues("t1").Inc()
+	m.Counter("messages_total", "Total number of messages.", "topic").WithLabelValues("t1").Inc()
+
+	want := `
+# HELP erda_kafka_consumer_messages_total Total number of messages.
+# TYPE erda_kafka_consumer_messages_total counter
+erda_kafka_consumer_messages_total{app="app1",cluster="c1",instance="host1",provider="kafka-consumer@orders",topic="t1"} 2
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want), "erda_kafka_consumer_messages_total"); err != nil {
+		t.Error(err)
+	}
+
+	// another provider registers the same name without collision.
+	other := p.Provide(&dependencyContext{service: "metrics", caller: "kafka-producer", typ: metricsType}).(Metrics)
+	other.Counter("messages_total", "Total number of messages.", "topic").WithLabelValues("t1").Inc()
+	if n, _ := testutil.GatherAndCount(reg); n != 2 {
+		t.Errorf("got %d series, want 2", n)
+	}
+}
+
+func TestPush(t *testing.T) {
+	var method, path, body string
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		method, path, body = r.Method, r.URL.Path, string(data)
+		if r.Method == http.MethodDelete {
+			rw.WriteHeader(http.StatusAccepted)
+			return
+		}
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	reg := prometheus.NewRegistry()
+	p := &provider{
+		Cfg: &config{AppName: "app1"},
+		Log: logrusx.New(),
+	}
+	p.Cfg.Push.URL = srv.URL
+	p.Cfg.Push.Grouping = map[string]string{"instance": "host1"}
+	p.pusher = p.newPusher(reg)
+	newMetrics(reg, "job", nil).Gauge("last_success", "Last success time.").WithLabelValues().Set(1)
+
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if method != http.MethodPut || path != "/metrics/job/app1/instance/host1" {
+		t.Errorf("got %s %s", method, path)
+	}
+	if len(body) <= 0 {
+		t.Errorf("got empty body")
+	}
+
+	p.Cfg.Push.DeleteOnClose = true
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if method != http.MethodDelete {
+		t.Errorf("got method %s, want DELETE", method)
+	}
+}
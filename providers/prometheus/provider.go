@@ -15,26 +15,44 @@
 package prometheus
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/push"
 
+	"github.com/erda-project/erda-infra/base/logs"
 	"github.com/erda-project/erda-infra/base/servicehub"
 	"github.com/erda-project/erda-infra/providers/httpserver"
 )
 
 type config struct {
-	MetricsPath       string `file:"metrics_path" default:"/metrics"`
-	RouterLabelEnable bool   `file:"router_label_enable" default:"true"`
-	RouterLabel       string `file:"router_label" default:"admin"`
+	MetricsPath       string            `file:"metrics_path" default:"/metrics"`
+	RouterLabelEnable bool              `file:"router_label_enable" default:"true"`
+	RouterLabel       string            `file:"router_label" default:"admin"`
+	Isolated          bool              `file:"isolated" desc:"use a separate registry for the hub instead of the default registry, only metrics of Metrics service are exposed"`
+	Namespace         string            `file:"namespace" env:"METRICS_NAMESPACE" desc:"prefix of metric names of Metrics service"`
+	ProviderPrefix    bool              `file:"provider_prefix" default:"true" desc:"prefix metric names of Metrics service with the caller provider name"`
+	ProviderLabel     string            `file:"provider_label" default:"provider" desc:"name of constant label for the caller provider, disabled if it's empty"`
+	AppName           string            `file:"app_name" env:"APP_NAME" desc:"value of constant label app, the program name by default"`
+	Instance          string            `file:"instance" env:"POD_NAME" desc:"value of constant label instance, the hostname by default"`
+	ConstLabels       map[string]string `file:"const_labels" desc:"constant labels added to metrics of Metrics service"`
+	Push              pushConfig        `file:"push"`
 }
 
 // provider .
 type provider struct {
-	server *http.Server
-	Cfg    *config
+	Cfg      *config
+	Log      logs.Logger
+	registry prometheus.Registerer
+	gatherer prometheus.Gatherer
+	pusher   *push.Pusher
 }
 
 func findRouter(ctx servicehub.Context, service string) (httpserver.Router, error) {
@@ -51,26 +69,94 @@ func findRouter(ctx servicehub.Context, service string) (httpserver.Router, erro
 
 // Init .
 func (p *provider) Init(ctx servicehub.Context) error {
+	if len(p.Cfg.AppName) <= 0 {
+		p.Cfg.AppName = filepath.Base(os.Args[0])
+	}
+	if len(p.Cfg.Instance) <= 0 {
+		p.Cfg.Instance, _ = os.Hostname()
+	}
+	if p.Cfg.Isolated {
+		reg := prometheus.NewRegistry()
+		reg.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+		p.registry, p.gatherer = reg, reg
+	} else {
+		p.registry, p.gatherer = prometheus.DefaultRegisterer, prometheus.DefaultGatherer
+	}
+	if len(p.Cfg.Push.URL) > 0 {
+		p.pusher = p.newPusher(p.gatherer)
+	}
+
 	svcName := "http-router"
 	if p.Cfg.RouterLabelEnable {
 		svcName += "@" + p.Cfg.RouterLabel
 	}
-
 	router, err := findRouter(ctx, svcName)
 	if err != nil {
+		if p.pusher != nil {
+			p.Log.Infof("metrics are only pushed to %s: %s", p.Cfg.Push.URL, err)
+			return nil
+		}
 		return fmt.Errorf("find router: %w", err)
 	}
+	if p.Cfg.Isolated {
+		router.GET(p.Cfg.MetricsPath, promhttp.InstrumentMetricHandler(p.registry, promhttp.HandlerFor(p.gatherer, promhttp.HandlerOpts{})))
+	} else {
+		router.GET(p.Cfg.MetricsPath, promhttp.Handler())
+	}
+	return nil
+}
+
+// Run .
+func (p *provider) Run(ctx context.Context) error {
+	if p.pusher != nil && p.Cfg.Push.Interval > 0 {
+		p.pushLoop(ctx)
+	}
+	return nil
+}
 
-	router.GET(p.Cfg.MetricsPath, promhttp.Handler())
+// Close .
+func (p *provider) Close() error {
+	if p.pusher != nil {
+		return p.closePusher()
+	}
 	return nil
 }
 
+// Provide .
+func (p *provider) Provide(ctx servicehub.DependencyContext, args ...interface{}) interface{} {
+	if ctx.Service() == "prometheus" && ctx.Type() != metricsType {
+		return p
+	}
+	caller := ctx.Caller()
+	var namespace string
+	if p.Cfg.ProviderPrefix {
+		namespace = joinNamespace(p.Cfg.Namespace, caller)
+	} else {
+		namespace = joinNamespace(p.Cfg.Namespace)
+	}
+	labels := prometheus.Labels{}
+	for k, v := range p.Cfg.ConstLabels {
+		labels[k] = v
+	}
+	if len(p.Cfg.AppName) > 0 {
+		labels["app"] = p.Cfg.AppName
+	}
+	if len(p.Cfg.Instance) > 0 {
+		labels["instance"] = p.Cfg.Instance
+	}
+	if len(p.Cfg.ProviderLabel) > 0 && len(caller) > 0 {
+		labels[p.Cfg.ProviderLabel] = caller
+	}
+	return newMetrics(p.registry, namespace, labels)
+}
+
 func init() {
 	servicehub.Register("prometheus", &servicehub.Spec{
-		Services:     []string{"prometheus"},
-		Description:  "bind prometheus endpoint to http-server",
-		Dependencies: []string{"http-server"},
-		ConfigFunc:   func() interface{} { return &config{} },
-		Creator:      func() servicehub.Provider { return &provider{} },
+		Services:             []string{"prometheus", "metrics"},
+		Types:                []reflect.Type{metricsType},
+		Description:          "bind prometheus endpoint to http-server, and provide Metrics for providers",
+		OptionalDependencies: []string{"http-server"},
+		ConfigFunc:           func() interface{} { return &config{} },
+		Creator:              func() servicehub.Provider { return &provider{} },
 	})
 }
@@ -0,0 +1,74 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+type pushConfig struct {
+	URL           string            `file:"url" env:"PROMETHEUS_PUSHGATEWAY_URL" desc:"url of pushgateway, pushing is disabled if it's empty"`
+	Job           string            `file:"job" desc:"job name to push, app_name by default"`
+	Interval      time.Duration     `file:"interval" desc:"interval to push metrics, metrics are only pushed on close if it's zero"`
+	Timeout       time.Duration     `file:"timeout" default:"10s" desc:"timeout of each push"`
+	Grouping      map[string]string `file:"grouping" desc:"grouping labels of pushed metrics"`
+	Username      string            `file:"username" desc:"username of basic auth"`
+	Password      string            `file:"password" desc:"password of basic auth"`
+	DeleteOnClose bool              `file:"delete_on_close" desc:"delete pushed metrics from pushgateway on close instead of pushing the final values"`
+}
+
+func (p *provider) newPusher(g prometheus.Gatherer) *push.Pusher {
+	cfg := &p.Cfg.Push
+	job := cfg.Job
+	if len(job) <= 0 {
+		job = p.Cfg.AppName
+	}
+	pusher := push.New(cfg.URL, job).Gatherer(g).Client(&http.Client{Timeout: cfg.Timeout})
+	for k, v := range cfg.Grouping {
+		pusher = pusher.Grouping(k, v)
+	}
+	if len(cfg.Username) > 0 {
+		pusher = pusher.BasicAuth(cfg.Username, cfg.Password)
+	}
+	return pusher
+}
+
+func (p *provider) pushLoop(ctx context.Context) {
+	tick := time.NewTicker(p.Cfg.Push.Interval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C:
+		}
+		if err := p.pusher.PushContext(ctx); err != nil && ctx.Err() == nil {
+			p.Log.Warnf("failed to push metrics to %s: %s", p.Cfg.Push.URL, err)
+		}
+	}
+}
+
+// closePusher pushes the final values of metrics, or deletes them if delete_on_close is set.
+func (p *provider) closePusher() error {
+	if p.Cfg.Push.DeleteOnClose {
+		return p.pusher.Delete()
+	}
+	return p.pusher.Push()
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// poolCollector collects pool stats of opened clients.
+type poolCollector struct {
+	p           *provider
+	hits        *prometheus.Desc
+	misses      *prometheus.Desc
+	timeouts    *prometheus.Desc
+	totalConns  *prometheus.Desc
+	idleConns   *prometheus.Desc
+	staleConns  *prometheus.Desc
+	maxPoolSize *prometheus.Desc
+}
+
+func newPoolCollector(p *provider) *poolCollector {
+	labels := []string{"db"}
+	return &poolCollector{
+		p:           p,
+		hits:        prometheus.NewDesc("pool_hits_total", "Number of times free connection was found in the pool.", labels, nil),
+		misses:      prometheus.NewDesc("pool_misses_total", "Number of times free connection was not found in the pool.", labels, nil),
+		timeouts:    prometheus.NewDesc("pool_timeouts_total", "Number of times a wait timeout occurred.", labels, nil),
+		totalConns:  prometheus.NewDesc("pool_total_connections", "Number of total connections in the pool.", labels, nil),
+		idleConns:   prometheus.NewDesc("pool_idle_connections", "Number of idle connections in the pool.", labels, nil),
+		staleConns:  prometheus.NewDesc("pool_stale_connections_total", "Number of stale connections removed from the pool.", labels, nil),
+		maxPoolSize: prometheus.NewDesc("pool_max_connections", "Maximum number of connections of the pool.", labels, nil),
+	}
+}
+
+// Describe .
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.timeouts
+	ch <- c.totalConns
+	ch <- c.idleConns
+	ch <- c.staleConns
+	ch <- c.maxPoolSize
+}
+
+// Collect .
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	c.p.lock.Lock()
+	defer c.p.lock.Unlock()
+	for db, client := range c.p.clients {
+		label := strconv.Itoa(db)
+		stats := client.PoolStats()
+		ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(stats.Hits), label)
+		ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(stats.Misses), label)
+		ch <- prometheus.MustNewConstMetric(c.timeouts, prometheus.CounterValue, float64(stats.Timeouts), label)
+		ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(stats.TotalConns), label)
+		ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stats.IdleConns), label)
+		ch <- prometheus.MustNewConstMetric(c.staleConns, prometheus.CounterValue, float64(stats.StaleConns), label)
+		ch <- prometheus.MustNewConstMetric(c.maxPoolSize, prometheus.GaugeValue, float64(client.Options().PoolSize), label)
+	}
+}
@@ -22,9 +22,12 @@ import (
 	"time"
 
 	"github.com/go-redis/redis"
+	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/erda-project/erda-infra/base/logs"
 	"github.com/erda-project/erda-infra/base/servicehub"
+	"github.com/erda-project/erda-infra/pkg/trace/inject/hook"
+	traceredis "github.com/erda-project/erda-infra/pkg/trace/instrument/redis"
 )
 
 // Interface .
@@ -55,12 +58,15 @@ type config struct {
 	PoolTimeout        time.Duration `file:"pool_timeout" env:"REDIS_POOL_TIMEOUT"`
 	IdleTimeout        time.Duration `file:"idle_timeout" env:"REDIS_IDLE_TIMEOUT"`
 	IdleCheckFrequency time.Duration `file:"idle_check_frequency" env:"REDIS_IDLE_CHECK_FREQUENCY"`
+
+	Trace bool `file:"trace" env:"REDIS_TRACE" default:"false"`
 }
 
 // provider .
 type provider struct {
 	Cfg     *config
 	Log     logs.Logger
+	Metrics prometheus.Registerer `autowired:"metrics" optional:"true"`
 	client  *redis.Client
 	clients map[int]*redis.Client
 	lock    sync.Mutex
@@ -73,6 +79,11 @@ func (p *provider) Init(ctx servicehub.Context) error {
 		return err
 	}
 	p.client = c
+	if p.Metrics != nil {
+		if err := p.Metrics.Register(newPoolCollector(p)); err != nil {
+			return fmt.Errorf("fail to register redis metrics: %s", err)
+		}
+	}
 	return nil
 }
 
@@ -124,6 +135,11 @@ func (p *provider) Open(db int) (*redis.Client, error) {
 		return nil, err
 	}
 
+	// clients are wrapped by the hooked constructors already if hook is enabled
+	if p.Cfg.Trace && !hook.Enabled() {
+		traceredis.Wrap(c)
+	}
+
 	if pong, err := c.Ping().Result(); err != nil {
 		p.Log.Errorf("redis ping error: %s", err)
 		return nil, err
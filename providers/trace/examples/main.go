@@ -0,0 +1,57 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/erda-project/erda-infra/base/servicehub"
+	_ "github.com/erda-project/erda-infra/providers/trace"
+)
+
+type provider struct {
+	TracerProvider trace.TracerProvider `autowired:"trace"`
+}
+
+func (p *provider) Run(ctx context.Context) error {
+	tracer := p.TracerProvider.Tracer("example")
+	tick := time.NewTicker(3 * time.Second)
+	defer tick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-tick.C:
+			_, span := tracer.Start(ctx, "tick")
+			span.End()
+		}
+	}
+}
+
+func init() {
+	servicehub.Register("example", &servicehub.Spec{
+		Services: []string{"example"},
+		Creator:  func() servicehub.Provider { return &provider{} },
+	})
+}
+
+func main() {
+	hub := servicehub.New()
+	hub.Run("examples", "", os.Args...)
+}
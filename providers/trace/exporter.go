@@ -0,0 +1,129 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trace
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+type otlpConfig struct {
+	Enable      bool              `file:"enable" env:"TRACE_OTLP_ENABLE" desc:"export spans by otlp"`
+	Protocol    string            `file:"protocol" default:"grpc" env:"OTEL_EXPORTER_OTLP_PROTOCOL" desc:"grpc or http"`
+	Endpoint    string            `file:"endpoint" env:"TRACE_OTLP_ENDPOINT" desc:"host:port or url of collector, OTEL_EXPORTER_OTLP_ENDPOINT or localhost:4317 for grpc and localhost:4318 for http by default"`
+	URLPath     string            `file:"url_path" desc:"url path of http protocol, /v1/traces by default"`
+	Insecure    bool              `file:"insecure" desc:"disable tls"`
+	Headers     map[string]string `file:"headers" desc:"headers sent with each export"`
+	Compression string            `file:"compression" desc:"gzip or empty for no compression"`
+	Timeout     time.Duration     `file:"timeout" default:"10s" desc:"timeout of each export request"`
+}
+
+type stdoutConfig struct {
+	Enable      bool   `file:"enable" env:"TRACE_STDOUT_ENABLE" desc:"print spans for local debugging"`
+	File        string `file:"file" desc:"file to append spans to, stdout by default"`
+	PrettyPrint bool   `file:"pretty_print" desc:"print spans in indented json"`
+}
+
+func (p *provider) newExporters() (exporters []sdktrace.SpanExporter, err error) {
+	if cfg := &p.Cfg.Exporters.OTLP; cfg.Enable {
+		exp, err := newOTLPExporter(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create otlp exporter: %w", err)
+		}
+		exporters = append(exporters, exp)
+	}
+	if cfg := &p.Cfg.Exporters.Stdout; cfg.Enable {
+		var w io.Writer = os.Stdout
+		if len(cfg.File) > 0 {
+			f, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+			if err != nil {
+				return nil, fmt.Errorf("failed to open trace file: %w", err)
+			}
+			p.closers = append(p.closers, func(context.Context) error { return f.Close() })
+			w = f
+		}
+		opts := []stdouttrace.Option{stdouttrace.WithWriter(w)}
+		if cfg.PrettyPrint {
+			opts = append(opts, stdouttrace.WithPrettyPrint())
+		}
+		exp, err := stdouttrace.New(opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create stdout exporter: %w", err)
+		}
+		exporters = append(exporters, exp)
+	}
+	return exporters, nil
+}
+
+func newOTLPExporter(cfg *otlpConfig) (sdktrace.SpanExporter, error) {
+	switch cfg.Protocol {
+	case "grpc", "":
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithTimeout(cfg.Timeout)}
+		if isURL(cfg.Endpoint) {
+			opts = append(opts, otlptracegrpc.WithEndpointURL(cfg.Endpoint))
+		} else if len(cfg.Endpoint) > 0 {
+			opts = append(opts, otlptracegrpc.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlptracegrpc.WithHeaders(cfg.Headers))
+		}
+		if len(cfg.Compression) > 0 {
+			opts = append(opts, otlptracegrpc.WithCompressor(cfg.Compression))
+		}
+		return otlptracegrpc.New(context.Background(), opts...)
+	case "http", "http/protobuf":
+		opts := []otlptracehttp.Option{otlptracehttp.WithTimeout(cfg.Timeout)}
+		if isURL(cfg.Endpoint) {
+			opts = append(opts, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+		} else if len(cfg.Endpoint) > 0 {
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+		}
+		if len(cfg.URLPath) > 0 {
+			opts = append(opts, otlptracehttp.WithURLPath(cfg.URLPath))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlptracehttp.WithHeaders(cfg.Headers))
+		}
+		switch cfg.Compression {
+		case "gzip":
+			opts = append(opts, otlptracehttp.WithCompression(otlptracehttp.GzipCompression))
+		case "", "none":
+		default:
+			return nil, fmt.Errorf("unsupported compression %q", cfg.Compression)
+		}
+		return otlptracehttp.New(context.Background(), opts...)
+	}
+	return nil, fmt.Errorf("unsupported otlp protocol %q", cfg.Protocol)
+}
+
+// isURL returns true if endpoint is an url with scheme, e.g. http://collector:4317, rather than host:port.
+func isURL(endpoint string) bool {
+	return strings.Contains(endpoint, "://")
+}
@@ -0,0 +1,167 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trace
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/erda-project/erda-infra/base/logs"
+	"github.com/erda-project/erda-infra/base/servicehub"
+	"github.com/erda-project/erda-infra/base/version"
+)
+
+// Interface .
+type Interface interface {
+	trace.TracerProvider
+	// ForceFlush exports all ended spans that have not yet been exported.
+	ForceFlush(ctx context.Context) error
+}
+
+var (
+	interfaceType      = reflect.TypeOf((*Interface)(nil)).Elem()
+	tracerProviderType = reflect.TypeOf((*trace.TracerProvider)(nil)).Elem()
+)
+
+type config struct {
+	ServiceName string            `file:"service_name" env:"OTEL_SERVICE_NAME" desc:"service name of resource, the name of hub by default"`
+	Attributes  map[string]string `file:"attributes" desc:"extra attributes of resource"`
+	Sampler     struct {
+		Ratio float64 `file:"ratio" default:"1" env:"TRACE_SAMPLE_RATIO" desc:"ratio of root spans to sample, other spans follow the decision of their parents"`
+	} `file:"sampler"`
+	Batch struct {
+		MaxQueueSize       int           `file:"max_queue_size" default:"2048" desc:"max number of spans buffered, spans are dropped if it's full"`
+		MaxExportBatchSize int           `file:"max_export_batch_size" default:"512" desc:"max number of spans of each export"`
+		BatchTimeout       time.Duration `file:"batch_timeout" default:"5s" desc:"max delay of spans to be exported"`
+		ExportTimeout      time.Duration `file:"export_timeout" default:"30s" desc:"timeout of each export"`
+	} `file:"batch"`
+	Exporters struct {
+		OTLP   otlpConfig   `file:"otlp"`
+		Stdout stdoutConfig `file:"stdout"`
+	} `file:"exporters"`
+	Global          bool          `file:"global" default:"true" desc:"set as the global TracerProvider and propagator of otel"`
+	ShutdownTimeout time.Duration `file:"shutdown_timeout" default:"10s" desc:"max duration to flush spans on close"`
+}
+
+// provider .
+type provider struct {
+	Cfg     *config
+	Log     logs.Logger
+	tp      *sdktrace.TracerProvider
+	closers []func(context.Context) error
+}
+
+// Init .
+func (p *provider) Init(ctx servicehub.Context) error {
+	return p.init(ctx.Hub().Name())
+}
+
+func (p *provider) init(name string) error {
+	res, err := p.newResource(name)
+	if err != nil {
+		return fmt.Errorf("failed to create trace resource: %w", err)
+	}
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(p.Cfg.Sampler.Ratio))),
+	}
+	exporters, err := p.newExporters()
+	if err != nil {
+		return err
+	}
+	for _, exp := range exporters {
+		opts = append(opts, sdktrace.WithBatcher(exp,
+			sdktrace.WithMaxQueueSize(p.Cfg.Batch.MaxQueueSize),
+			sdktrace.WithMaxExportBatchSize(p.Cfg.Batch.MaxExportBatchSize),
+			sdktrace.WithBatchTimeout(p.Cfg.Batch.BatchTimeout),
+			sdktrace.WithExportTimeout(p.Cfg.Batch.ExportTimeout),
+		))
+	}
+	if len(exporters) <= 0 {
+		p.Log.Warnf("no exporter enabled, spans are sampled but not exported")
+	}
+	p.tp = sdktrace.NewTracerProvider(opts...)
+	if p.Cfg.Global {
+		otel.SetTracerProvider(p.tp)
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	}
+	return nil
+}
+
+func (p *provider) newResource(name string) (*resource.Resource, error) {
+	if len(p.Cfg.ServiceName) > 0 {
+		name = p.Cfg.ServiceName
+	}
+	if len(name) <= 0 {
+		name = filepath.Base(os.Args[0])
+	}
+	attrs := []attribute.KeyValue{semconv.ServiceName(name)}
+	if len(version.Version) > 0 {
+		attrs = append(attrs, semconv.ServiceVersion(version.Version))
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		attrs = append(attrs, semconv.ServiceInstanceID(hostname))
+	}
+	for k, v := range p.Cfg.Attributes {
+		attrs = append(attrs, attribute.String(k, v))
+	}
+	return resource.New(context.Background(),
+		resource.WithSchemaURL(semconv.SchemaURL),
+		resource.WithTelemetrySDK(),
+		resource.WithHost(),
+		resource.WithFromEnv(),
+		resource.WithAttributes(attrs...),
+	)
+}
+
+// Close flushes ended spans and shuts down exporters.
+func (p *provider) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), p.Cfg.ShutdownTimeout)
+	defer cancel()
+	err := p.tp.Shutdown(ctx)
+	for _, fn := range p.closers {
+		if cerr := fn(ctx); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// Provide .
+func (p *provider) Provide(ctx servicehub.DependencyContext, args ...interface{}) interface{} {
+	return p.tp
+}
+
+func init() {
+	servicehub.Register("trace", &servicehub.Spec{
+		Services:    []string{"trace", "tracer-provider"},
+		Types:       []reflect.Type{interfaceType, tracerProviderType},
+		Description: "opentelemetry TracerProvider with exporters and sampling",
+		ConfigFunc:  func() interface{} { return &config{} },
+		Creator:     func() servicehub.Provider { return &provider{} },
+	})
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trace
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/erda-project/erda-infra/base/logs/logrusx"
+)
+
+func TestProviderStdoutExporter(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "spans.json")
+	p := &provider{Cfg: &config{}, Log: logrusx.New()}
+	p.Cfg.Sampler.Ratio = 1
+	p.Cfg.Batch.MaxQueueSize = 16
+	p.Cfg.Batch.MaxExportBatchSize = 16
+	p.Cfg.Attributes = map[string]string{"env": "test"}
+	p.Cfg.Exporters.Stdout.Enable = true
+	p.Cfg.Exporters.Stdout.File = file
+	p.Cfg.ShutdownTimeout = time.Second
+	if err := p.init("trace-test"); err != nil {
+		t.Fatal(err)
+	}
+	_, span := p.tp.Tracer("test").Start(context.Background(), "test-span")
+	span.End()
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"test-span"`, `"trace-test"`, `"env"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("exported spans should contain %s, got %s", want, data)
+		}
+	}
+}
+
+func TestNewOTLPExporter(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     otlpConfig
+		wantErr bool
+	}{
+		{name: "grpc", cfg: otlpConfig{Protocol: "grpc", Endpoint: "localhost:4317", Insecure: true}},
+		{name: "http", cfg: otlpConfig{Protocol: "http", Endpoint: "localhost:4318", Compression: "gzip"}},
+		{name: "grpc url", cfg: otlpConfig{Protocol: "grpc", Endpoint: "http://localhost:4317"}},
+		{name: "http url", cfg: otlpConfig{Protocol: "http", Endpoint: "https://localhost:4318/v1/traces"}},
+		{name: "unsupported compression", cfg: otlpConfig{Protocol: "http", Compression: "zstd"}, wantErr: true},
+		{name: "unsupported protocol", cfg: otlpConfig{Protocol: "thrift"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exp, err := newOTLPExporter(&tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newOTLPExporter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if exp != nil {
+				exp.Shutdown(context.Background())
+			}
+		})
+	}
+}
+
+func TestOTLPExporterEndpointURL(t *testing.T) {
+	paths := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		paths <- r.URL.Path
+	}))
+	defer srv.Close()
+	exp, err := newOTLPExporter(&otlpConfig{Protocol: "http", Endpoint: srv.URL + "/collector/traces", Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("newOTLPExporter() error: %s", err)
+	}
+	defer exp.Shutdown(context.Background())
+	spans := tracetest.SpanStubs{{Name: "test"}}.Snapshots()
+	if err := exp.ExportSpans(context.Background(), spans); err != nil {
+		t.Fatalf("ExportSpans() error: %s", err)
+	}
+	if path := <-paths; path != "/collector/traces" {
+		t.Errorf("path = %q, want %q", path, "/collector/traces")
+	}
+}
@@ -39,3 +39,11 @@ func GetGrpcMethods(methods []*protogen.Method) (grpcMethods []*protogen.Method)
 	}
 	return
 }
+
+// GetMethodAuthOption returns the auth option of method, nil is returned if it's not declared.
+func GetMethodAuthOption(method *protogen.Method) *AuthMethodOption {
+	if !proto.HasExtension(method.Desc.Options(), E_Auth) {
+		return nil
+	}
+	return proto.GetExtension(method.Desc.Options(), E_Auth).(*AuthMethodOption)
+}
@@ -26,9 +26,11 @@ import (
 )
 
 const (
-	contextPackage   = protogen.GoImportPath("context")
-	grpcPackage      = protogen.GoImportPath("google.golang.org/grpc")
-	transgrpcPackage = protogen.GoImportPath("github.com/erda-project/erda-infra/pkg/transport/grpc")
+	contextPackage     = protogen.GoImportPath("context")
+	grpcPackage        = protogen.GoImportPath("google.golang.org/grpc")
+	transgrpcPackage   = protogen.GoImportPath("github.com/erda-project/erda-infra/pkg/transport/grpc")
+	transerrorsPackage = protogen.GoImportPath("github.com/erda-project/erda-infra/pkg/transport/errors")
+	transportPackage   = protogen.GoImportPath("github.com/erda-project/erda-infra/pkg/transport")
 )
 
 func generateFiles(gen *protogen.Plugin, files []*protogen.File) error {
@@ -130,7 +132,12 @@ func genClient(gen *protogen.Plugin, files []*protogen.File, root *protogen.File
 					g.P()
 				} else {
 					g.P("func (s *", typeName, ") ", m.GoName, "(ctx ", contextPackage.Ident("Context"), ",req *", m.Input.GoIdent, ") (*", m.Output.GoIdent, ", error) {")
-					g.P("	return s.client.", m.GoName, "(ctx, req, append(", transgrpcPackage.Ident("CallOptionFromContext"), "(ctx), s.opts...)...)")
+					g.P("	ctx = ", transportPackage.Ident("WithDeadlineHeader"), "(ctx)")
+					g.P("	resp, err := s.client.", m.GoName, "(ctx, req, append(", transgrpcPackage.Ident("CallOptionFromContext"), "(ctx), s.opts...)...)")
+					g.P("	if err != nil {")
+					g.P("		return nil, ", transerrorsPackage.Ident("FromError"), "(err)")
+					g.P("	}")
+					g.P("	return resp, nil")
 					g.P("}")
 					g.P()
 				}
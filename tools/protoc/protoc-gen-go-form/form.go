@@ -24,13 +24,14 @@ import (
 )
 
 const (
-	urlPackage      = protogen.GoImportPath("net/url")
-	urlencPackage   = protogen.GoImportPath("github.com/erda-project/erda-infra/pkg/urlenc")
-	stringsPackage  = protogen.GoImportPath("strings")
-	structpbPackage = protogen.GoImportPath("google.golang.org/protobuf/types/known/structpb")
-	base64Package   = protogen.GoImportPath("encoding/base64")
-	jsonPackage     = protogen.GoImportPath("encoding/json")
-	strconvPackage  = protogen.GoImportPath("strconv")
+	urlPackage       = protogen.GoImportPath("net/url")
+	urlencPackage    = protogen.GoImportPath("github.com/erda-project/erda-infra/pkg/urlenc")
+	stringsPackage   = protogen.GoImportPath("strings")
+	structpbPackage  = protogen.GoImportPath("google.golang.org/protobuf/types/known/structpb")
+	base64Package    = protogen.GoImportPath("encoding/base64")
+	jsonPackage      = protogen.GoImportPath("encoding/json")
+	strconvPackage   = protogen.GoImportPath("strconv")
+	fieldmaskPackage = protogen.GoImportPath("github.com/erda-project/erda-infra/pkg/transport/fieldmask")
 )
 
 func generateFile(gen *protogen.Plugin, file *protogen.File) (*protogen.GeneratedFile, error) {
@@ -342,6 +343,8 @@ func genQueryStringValue(g *protogen.GeneratedFile, path string, desc protorefle
 				g.P("		", path, " = ", structpbPackage.Ident("NewStringValue"), "(vals[0])")
 				g.P("	}")
 				g.P("}")
+			} else if subMsg.Desc.FullName() == "google.protobuf.FieldMask" {
+				g.P(path, " = ", fieldmaskPackage.Ident("New"), "(vals...)")
 			} else {
 				g.P("if ", path, " == nil {")
 				g.P("	", path, " = &", subMsg.GoIdent, "{}")
@@ -389,6 +392,10 @@ func createQueryParams(fields []*protogen.Field) []*queryParam {
 					Name:   fmt.Sprintf("%s%s", parent.Name, field.Desc.Name()),
 				}
 				queryParams = append(queryParams, q)
+				if field.Message.Desc.FullName() == "google.protobuf.FieldMask" {
+					// paths are parsed from comma separated values
+					continue
+				}
 				qp := *q
 				qp.Name, qp.GoName = qp.Name+".", qp.GoName+"."
 				fn(&qp, field.Message.Fields, rootField)
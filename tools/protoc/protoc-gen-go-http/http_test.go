@@ -0,0 +1,144 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// userFile is the descriptor of testdata/user.proto.
+func userFile() *descriptorpb.FileDescriptorProto {
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, typeName string) *descriptorpb.FieldDescriptorProto {
+		fd := &descriptorpb.FieldDescriptorProto{
+			Name:   proto.String(name),
+			Number: proto.Int32(number),
+			Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:   typ.Enum(),
+		}
+		if len(typeName) > 0 {
+			fd.TypeName = proto.String(typeName)
+		}
+		return fd
+	}
+	message := func(name string, fields ...*descriptorpb.FieldDescriptorProto) *descriptorpb.DescriptorProto {
+		return &descriptorpb.DescriptorProto{Name: proto.String(name), Field: fields}
+	}
+	method := func(name, input, output string, rule *annotations.HttpRule) *descriptorpb.MethodDescriptorProto {
+		opts := &descriptorpb.MethodOptions{}
+		proto.SetExtension(opts, annotations.E_Http, rule)
+		return &descriptorpb.MethodDescriptorProto{
+			Name:       proto.String(name),
+			InputType:  proto.String(".erda.infra.example." + input),
+			OutputType: proto.String(".erda.infra.example." + output),
+			Options:    opts,
+		}
+	}
+	const fieldMask = ".google.protobuf.FieldMask"
+	list := field("list", 1, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".erda.infra.example.User")
+	list.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+	return &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("user.proto"),
+		Package:    proto.String("erda.infra.example"),
+		Dependency: []string{"google/api/annotations.proto", "google/protobuf/field_mask.proto"},
+		Syntax:     proto.String("proto3"),
+		Options: &descriptorpb.FileOptions{
+			GoPackage: proto.String("github.com/erda-project/erda-infra/examples/service/protocol/pb"),
+		},
+		MessageType: []*descriptorpb.DescriptorProto{
+			message("User",
+				field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_INT64, ""),
+				field("name", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+				field("age", 3, descriptorpb.FieldDescriptorProto_TYPE_INT32, ""),
+			),
+			message("GetUserRequest",
+				field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_INT64, ""),
+				field("fields", 2, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, fieldMask),
+			),
+			message("ListUsersRequest",
+				field("page", 1, descriptorpb.FieldDescriptorProto_TYPE_INT32, ""),
+			),
+			message("ListUsersResponse", list),
+			message("UpdateUserRequest",
+				field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_INT64, ""),
+				field("user", 2, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".erda.infra.example.User"),
+				field("update_mask", 3, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, fieldMask),
+			),
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("UserService"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				method("GetUser", "GetUserRequest", "User", &annotations.HttpRule{
+					Pattern: &annotations.HttpRule_Get{Get: "/api/users/{id}"},
+				}),
+				method("ListUsers", "ListUsersRequest", "ListUsersResponse", &annotations.HttpRule{
+					Pattern: &annotations.HttpRule_Get{Get: "/api/users"},
+				}),
+				method("UpdateUser", "UpdateUserRequest", "User", &annotations.HttpRule{
+					Pattern: &annotations.HttpRule_Patch{Patch: "/api/users/{id}"},
+					Body:    "user",
+				}),
+			},
+		}},
+	}
+}
+
+func TestGenerateFieldMasks(t *testing.T) {
+	req := &pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{"user.proto"},
+		Parameter:      proto.String("paths=source_relative"),
+		ProtoFile: []*descriptorpb.FileDescriptorProto{
+			protodesc.ToFileDescriptorProto(descriptorpb.File_google_protobuf_descriptor_proto),
+			protodesc.ToFileDescriptorProto(annotations.File_google_api_http_proto),
+			protodesc.ToFileDescriptorProto(annotations.File_google_api_annotations_proto),
+			protodesc.ToFileDescriptorProto(fieldmaskpb.File_google_protobuf_field_mask_proto),
+			userFile(),
+		},
+	}
+	gen, err := protogen.Options{}.New(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	genAll, fieldsQuery = proto.Bool(false), proto.Bool(true)
+	if _, err := generateFile(gen, gen.FilesByPath["user.proto"]); err != nil {
+		t.Fatal(err)
+	}
+	resp := gen.Response()
+	if resp.Error != nil {
+		t.Fatal(resp.GetError())
+	}
+	if len(resp.File) != 1 {
+		t.Fatalf("generated %d files, want 1", len(resp.File))
+	}
+
+	// GetUser is filtered by its fields, ListUsers by the fields query parameter,
+	// and the update_mask of UpdateUser is generated from the request body.
+	want, err := os.ReadFile(filepath.Join("testdata", resp.File[0].GetName()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.File[0].GetContent(); got != string(want) {
+		t.Errorf("generated %s mismatches testdata:\n%s", resp.File[0].GetName(), got)
+	}
+}
@@ -30,6 +30,7 @@ const (
 var (
 	showVersion = flag.Bool("version", false, "print the version and exit")
 	genAll      *bool
+	fieldsQuery *bool
 )
 
 func main() {
@@ -41,6 +42,7 @@ func main() {
 
 	var flags flag.FlagSet
 	genAll = flags.Bool("genall", false, "generate all service function")
+	fieldsQuery = flags.Bool("fields_query", false, "filter responses by the fields query parameter for methods without fields field")
 	protogen.Options{
 		ParamFunc: flags.Set,
 	}.Run(func(p *protogen.Plugin) error {
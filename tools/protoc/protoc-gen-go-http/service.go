@@ -38,6 +38,13 @@ const (
 	runtimePackage   = protogen.GoImportPath("github.com/erda-project/erda-infra/pkg/transport/http/runtime")
 	fmtPackage       = protogen.GoImportPath("fmt")
 	stringsPackage   = protogen.GoImportPath("strings")
+	fieldmaskPackage = protogen.GoImportPath("github.com/erda-project/erda-infra/pkg/transport/fieldmask")
+)
+
+const (
+	fieldMaskType   = "google.protobuf.FieldMask"
+	readMaskField   = "fields"
+	updateMaskField = "update_mask"
 )
 
 type serviceDesc struct {
@@ -127,6 +134,16 @@ func (s *serviceDesc) execute(g *protogen.GeneratedFile) error {
 			g.P("			ctx = ", contextPackage.Ident("WithValue"), "(ctx, ", transportPackage.Ident("ServiceInfoContextKey"), ", ", infoVar, ")")
 			g.P("		}")
 			g.P("		r = r.WithContext(ctx)")
+			updateMask, err := getUpdateMask(m)
+			if err != nil {
+				return fmt.Errorf("service %q, method %q : %s", s.ServiceType, m.Name, err)
+			}
+			if len(updateMask) > 0 {
+				g.P("	body, err := ", fieldmaskPackage.Ident("ReadJSONBody"), "(r)")
+				g.P("	if err != nil {")
+				g.P("		return nil, err")
+				g.P("	}")
+			}
 			g.P("		var in ", m.Request)
 			if len(m.ReqBody) > 0 {
 				path, _, err := protocutils.GetFieldPath(m.ReqBody, m.Meta.Input.Fields)
@@ -145,6 +162,15 @@ func (s *serviceDesc) execute(g *protogen.GeneratedFile) error {
 			g.P("				return nil, err")
 			g.P("			}")
 			g.P("		}")
+			if len(updateMask) > 0 {
+				g.P("	if len(in.", updateMaskGoName(m), ".GetPaths()) <= 0 {")
+				g.P("		mask, err := ", fieldmaskPackage.Ident("FromJSON"), "(body, ", updateMask, ")")
+				g.P("		if err != nil {")
+				g.P("			return nil, err")
+				g.P("		}")
+				g.P("		in.", updateMaskGoName(m), " = mask")
+				g.P("	}")
+			}
 			if len(m.QueryParams) > 0 {
 				g.P("params := r.URL.Query()")
 				for _, key := range m.QueryParamKeys {
@@ -207,6 +233,18 @@ func (s *serviceDesc) execute(g *protogen.GeneratedFile) error {
 			g.P("		if err != nil {")
 			g.P("			return out, err")
 			g.P("		}")
+			if readMask, ok := getReadMask(m); ok {
+				if len(readMask) > 0 {
+					g.P("	if paths := in.", readMask, ".GetPaths(); len(paths) > 0 {")
+				} else {
+					g.P("	if paths := ", fieldmaskPackage.Ident("FromRequest"), "(r); len(paths) > 0 {")
+				}
+				g.P("		out, err = ", fieldmaskPackage.Ident("Filter"), "(out, paths)")
+				g.P("		if err != nil {")
+				g.P("			return nil, err")
+				g.P("		}")
+				g.P("	}")
+			}
 			if len(m.RespBody) > 0 {
 				g.P("	if out != nil {")
 				g.P("		resp := out.(*", m.Response, ")")
@@ -234,6 +272,52 @@ func (s *serviceDesc) execute(g *protogen.GeneratedFile) error {
 	return nil
 }
 
+// getReadMask returns the go name of FieldMask field to prune response,
+// empty name means the mask is read from the fields query parameter, which is enabled by fields_query option.
+// false is returned if the method has no read mask.
+func getReadMask(m *methodDesc) (string, bool) {
+	field, err := getField(readMaskField, m.Meta.Input.Fields)
+	if err != nil {
+		return "", *fieldsQuery
+	}
+	if isFieldMask(field) {
+		return field.GoName, true
+	}
+	return "", false
+}
+
+// getUpdateMask returns the expression of message to generate update mask from the request body of PATCH method,
+// empty if the method has no update mask.
+func getUpdateMask(m *methodDesc) (string, error) {
+	if m.Method != "PATCH" {
+		return "", nil
+	}
+	field, err := getField(updateMaskField, m.Meta.Input.Fields)
+	if err != nil || !isFieldMask(field) {
+		return "", nil
+	}
+	if len(m.ReqBody) <= 0 {
+		return fmt.Sprintf("&in, %q", updateMaskField), nil
+	}
+	path, body, err := protocutils.GetFieldPath(m.ReqBody, m.Meta.Input.Fields)
+	if err != nil {
+		return "", err
+	}
+	if body.Message == nil || body.Desc.IsList() || body.Desc.IsMap() {
+		return "", nil
+	}
+	return "in." + path, nil
+}
+
+func updateMaskGoName(m *methodDesc) string {
+	field, _ := getField(updateMaskField, m.Meta.Input.Fields)
+	return field.GoName
+}
+
+func isFieldMask(field *protogen.Field) bool {
+	return field.Message != nil && !field.Desc.IsList() && string(field.Message.Desc.FullName()) == fieldMaskType
+}
+
 func getField(name string, fields []*protogen.Field) (field *protogen.Field, err error) {
 	for _, fd := range fields {
 		if string(fd.Desc.Name()) == name {
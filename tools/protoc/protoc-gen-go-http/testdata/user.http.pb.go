@@ -0,0 +1,250 @@
+// Code generated by protoc-gen-go-http. DO NOT EDIT.
+// Source: user.proto
+
+package pb
+
+import (
+	context "context"
+	transport "github.com/erda-project/erda-infra/pkg/transport"
+	fieldmask "github.com/erda-project/erda-infra/pkg/transport/fieldmask"
+	http "github.com/erda-project/erda-infra/pkg/transport/http"
+	httprule "github.com/erda-project/erda-infra/pkg/transport/http/httprule"
+	runtime "github.com/erda-project/erda-infra/pkg/transport/http/runtime"
+	urlenc "github.com/erda-project/erda-infra/pkg/urlenc"
+	http1 "net/http"
+	strconv "strconv"
+	strings "strings"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the "github.com/erda-project/erda-infra/pkg/transport/http" package it is being compiled against.
+const _ = http.SupportPackageIsVersion1
+
+// UserServiceHandler is the server API for UserService service.
+type UserServiceHandler interface {
+	// GET /api/users/{id}
+	GetUser(context.Context, *GetUserRequest) (*User, error)
+	// GET /api/users
+	ListUsers(context.Context, *ListUsersRequest) (*ListUsersResponse, error)
+	// PATCH /api/users/{id}
+	UpdateUser(context.Context, *UpdateUserRequest) (*User, error)
+}
+
+// RegisterUserServiceHandler register UserServiceHandler to http.Router.
+func RegisterUserServiceHandler(r http.Router, srv UserServiceHandler, opts ...http.HandleOption) {
+	h := http.DefaultHandleOptions()
+	for _, op := range opts {
+		op(h)
+	}
+	encodeFunc := func(fn func(http1.ResponseWriter, *http1.Request) (interface{}, error)) http.HandlerFunc {
+		handler := func(w http1.ResponseWriter, r *http1.Request) {
+			out, err := fn(w, r)
+			if err != nil {
+				h.Error(w, r, err)
+				return
+			}
+			if err := h.Encode(w, r, out); err != nil {
+				h.Error(w, r, err)
+			}
+		}
+		if h.HTTPInterceptor != nil {
+			handler = h.HTTPInterceptor(handler)
+		}
+		return handler
+	}
+
+	add_GetUser := func(method, path string, fn func(context.Context, *GetUserRequest) (*User, error)) {
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			return fn(ctx, req.(*GetUserRequest))
+		}
+		var GetUser_info transport.ServiceInfo
+		if h.Interceptor != nil {
+			GetUser_info = transport.NewServiceInfo("erda.infra.example.UserService", "GetUser", srv)
+			handler = h.Interceptor(handler)
+		}
+		compiler, _ := httprule.Parse(path)
+		temp := compiler.Compile()
+		pattern, _ := runtime.NewPattern(httprule.SupportPackageIsVersion1, temp.OpCodes, temp.Pool, temp.Verb)
+		r.Add(method, path, encodeFunc(
+			func(w http1.ResponseWriter, r *http1.Request) (interface{}, error) {
+				ctx := http.WithRequest(r.Context(), r)
+				ctx = transport.WithHTTPHeaderForServer(ctx, r.Header)
+				if h.Interceptor != nil {
+					ctx = context.WithValue(ctx, transport.ServiceInfoContextKey, GetUser_info)
+				}
+				r = r.WithContext(ctx)
+				var in GetUserRequest
+				if err := h.Decode(r, &in); err != nil {
+					return nil, err
+				}
+				var input interface{} = &in
+				if u, ok := (input).(urlenc.URLValuesUnmarshaler); ok {
+					if err := u.UnmarshalURLValues("", r.URL.Query()); err != nil {
+						return nil, err
+					}
+				}
+				path := r.URL.Path
+				if len(path) > 0 {
+					components := strings.Split(path[1:], "/")
+					last := len(components) - 1
+					var verb string
+					if idx := strings.LastIndex(components[last], ":"); idx >= 0 {
+						c := components[last]
+						components[last], verb = c[:idx], c[idx+1:]
+					}
+					vars, err := pattern.Match(components, verb)
+					if err != nil {
+						return nil, err
+					}
+					for k, val := range vars {
+						switch k {
+						case "id":
+							val, err := strconv.ParseInt(val, 10, 64)
+							if err != nil {
+								return nil, err
+							}
+							in.Id = val
+						}
+					}
+				}
+				out, err := handler(ctx, &in)
+				if err != nil {
+					return out, err
+				}
+				if paths := in.Fields.GetPaths(); len(paths) > 0 {
+					out, err = fieldmask.Filter(out, paths)
+					if err != nil {
+						return nil, err
+					}
+				}
+				return out, nil
+			}),
+		)
+	}
+
+	add_ListUsers := func(method, path string, fn func(context.Context, *ListUsersRequest) (*ListUsersResponse, error)) {
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			return fn(ctx, req.(*ListUsersRequest))
+		}
+		var ListUsers_info transport.ServiceInfo
+		if h.Interceptor != nil {
+			ListUsers_info = transport.NewServiceInfo("erda.infra.example.UserService", "ListUsers", srv)
+			handler = h.Interceptor(handler)
+		}
+		r.Add(method, path, encodeFunc(
+			func(w http1.ResponseWriter, r *http1.Request) (interface{}, error) {
+				ctx := http.WithRequest(r.Context(), r)
+				ctx = transport.WithHTTPHeaderForServer(ctx, r.Header)
+				if h.Interceptor != nil {
+					ctx = context.WithValue(ctx, transport.ServiceInfoContextKey, ListUsers_info)
+				}
+				r = r.WithContext(ctx)
+				var in ListUsersRequest
+				if err := h.Decode(r, &in); err != nil {
+					return nil, err
+				}
+				var input interface{} = &in
+				if u, ok := (input).(urlenc.URLValuesUnmarshaler); ok {
+					if err := u.UnmarshalURLValues("", r.URL.Query()); err != nil {
+						return nil, err
+					}
+				}
+				out, err := handler(ctx, &in)
+				if err != nil {
+					return out, err
+				}
+				if paths := fieldmask.FromRequest(r); len(paths) > 0 {
+					out, err = fieldmask.Filter(out, paths)
+					if err != nil {
+						return nil, err
+					}
+				}
+				return out, nil
+			}),
+		)
+	}
+
+	add_UpdateUser := func(method, path string, fn func(context.Context, *UpdateUserRequest) (*User, error)) {
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			return fn(ctx, req.(*UpdateUserRequest))
+		}
+		var UpdateUser_info transport.ServiceInfo
+		if h.Interceptor != nil {
+			UpdateUser_info = transport.NewServiceInfo("erda.infra.example.UserService", "UpdateUser", srv)
+			handler = h.Interceptor(handler)
+		}
+		compiler, _ := httprule.Parse(path)
+		temp := compiler.Compile()
+		pattern, _ := runtime.NewPattern(httprule.SupportPackageIsVersion1, temp.OpCodes, temp.Pool, temp.Verb)
+		r.Add(method, path, encodeFunc(
+			func(w http1.ResponseWriter, r *http1.Request) (interface{}, error) {
+				ctx := http.WithRequest(r.Context(), r)
+				ctx = transport.WithHTTPHeaderForServer(ctx, r.Header)
+				if h.Interceptor != nil {
+					ctx = context.WithValue(ctx, transport.ServiceInfoContextKey, UpdateUser_info)
+				}
+				r = r.WithContext(ctx)
+				body, err := fieldmask.ReadJSONBody(r)
+				if err != nil {
+					return nil, err
+				}
+				var in UpdateUserRequest
+				if err := h.Decode(r, &in.User); err != nil {
+					return nil, err
+				}
+				var input interface{} = &in
+				if u, ok := (input).(urlenc.URLValuesUnmarshaler); ok {
+					if err := u.UnmarshalURLValues("", r.URL.Query()); err != nil {
+						return nil, err
+					}
+				}
+				if len(in.UpdateMask.GetPaths()) <= 0 {
+					mask, err := fieldmask.FromJSON(body, in.User)
+					if err != nil {
+						return nil, err
+					}
+					in.UpdateMask = mask
+				}
+				path := r.URL.Path
+				if len(path) > 0 {
+					components := strings.Split(path[1:], "/")
+					last := len(components) - 1
+					var verb string
+					if idx := strings.LastIndex(components[last], ":"); idx >= 0 {
+						c := components[last]
+						components[last], verb = c[:idx], c[idx+1:]
+					}
+					vars, err := pattern.Match(components, verb)
+					if err != nil {
+						return nil, err
+					}
+					for k, val := range vars {
+						switch k {
+						case "id":
+							val, err := strconv.ParseInt(val, 10, 64)
+							if err != nil {
+								return nil, err
+							}
+							in.Id = val
+						}
+					}
+				}
+				out, err := handler(ctx, &in)
+				if err != nil {
+					return out, err
+				}
+				if paths := fieldmask.FromRequest(r); len(paths) > 0 {
+					out, err = fieldmask.Filter(out, paths)
+					if err != nil {
+						return nil, err
+					}
+				}
+				return out, nil
+			}),
+		)
+	}
+
+	add_GetUser("GET", "/api/users/{id}", srv.GetUser)
+	add_ListUsers("GET", "/api/users", srv.ListUsers)
+	add_UpdateUser("PATCH", "/api/users/{id}", srv.UpdateUser)
+}
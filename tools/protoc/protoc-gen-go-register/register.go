@@ -23,6 +23,8 @@ import (
 	"unicode"
 
 	"google.golang.org/protobuf/compiler/protogen"
+
+	"github.com/erda-project/erda-infra/tools/protoc/include/custom/extension"
 )
 
 const (
@@ -30,6 +32,7 @@ const (
 	transhttpPackage = protogen.GoImportPath("github.com/erda-project/erda-infra/pkg/transport/http")
 	transgrpcPackage = protogen.GoImportPath("github.com/erda-project/erda-infra/pkg/transport/grpc")
 	reflectPackage   = protogen.GoImportPath("reflect")
+	authzPackage     = protogen.GoImportPath("github.com/erda-project/erda-infra/pkg/auth/authz")
 )
 
 func generateFiles(gen *protogen.Plugin, flags flag.FlagSet, files []*protogen.File) (*protogen.GeneratedFile, error) {
@@ -174,9 +177,58 @@ func generateFiles(gen *protogen.Plugin, flags flag.FlagSet, files []*protogen.F
 	}
 	g.P("	}")
 	g.P("}")
+	genAuthRules(g, files)
 	return g, nil
 }
 
+// genAuthRules generates the authorization rules of services declared by (custom.extension.auth) option of methods.
+func genAuthRules(g *protogen.GeneratedFile, files []*protogen.File) {
+	for _, file := range files {
+		for _, ser := range file.Services {
+			var methods []*protogen.Method
+			for _, method := range ser.Methods {
+				if extension.GetMethodAuthOption(method) != nil {
+					methods = append(methods, method)
+				}
+			}
+			if len(methods) <= 0 {
+				continue
+			}
+			name := lowerCaptain(ser.GoName + "AuthRules")
+			g.P()
+			g.P("var ", name, " = ", authzPackage.Ident("Rules"), "{")
+			for _, method := range methods {
+				opt := extension.GetMethodAuthOption(method)
+				var fields []string
+				if opt.GetPublic() {
+					fields = append(fields, "Public: true")
+				}
+				if len(opt.GetPermissions()) > 0 {
+					fields = append(fields, "Permissions: []string{"+quoteStrings(opt.GetPermissions())+"}")
+				}
+				if len(opt.GetRoles()) > 0 {
+					fields = append(fields, "Roles: []string{"+quoteStrings(opt.GetRoles())+"}")
+				}
+				g.P("	", strconv.Quote(string(ser.Desc.FullName())+"/"+method.GoName), ": {", strings.Join(fields, ", "), "},")
+			}
+			g.P("}")
+			g.P()
+			g.P("// ", ser.GoName, "AuthRules returns the authorization rules of ", ser.GoName, " declared by (custom.extension.auth) option.")
+			g.P("func ", ser.GoName, "AuthRules() ", authzPackage.Ident("Rules"), " { return ", name, " }")
+			g.P()
+			g.P("func init() { ", authzPackage.Ident("RegisterRules"), "(", name, ") }")
+		}
+	}
+}
+
+func quoteStrings(list []string) string {
+	quoted := make([]string, len(list))
+	for i, s := range list {
+		quoted[i] = strconv.Quote(s)
+	}
+	return strings.Join(quoted, ", ")
+}
+
 func lowerCaptain(name string) string {
 	if len(name) <= 0 {
 		return name
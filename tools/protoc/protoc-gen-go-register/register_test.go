@@ -0,0 +1,106 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+
+	"github.com/erda-project/erda-infra/tools/protoc/include/custom/extension"
+)
+
+// authFile is the descriptor of testdata/auth.proto.
+func authFile() *descriptorpb.FileDescriptorProto {
+	method := func(name string, opt *extension.AuthMethodOption) *descriptorpb.MethodDescriptorProto {
+		m := &descriptorpb.MethodDescriptorProto{
+			Name:       proto.String(name),
+			InputType:  proto.String(".erda.infra.example.Request"),
+			OutputType: proto.String(".erda.infra.example.Response"),
+		}
+		if opt != nil {
+			m.Options = &descriptorpb.MethodOptions{}
+			proto.SetExtension(m.Options, extension.E_Auth, opt)
+		}
+		return m
+	}
+	return &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("auth.proto"),
+		Package:    proto.String("erda.infra.example"),
+		Dependency: []string{"custom/extension/extension.proto"},
+		Syntax:     proto.String("proto3"),
+		Options: &descriptorpb.FileOptions{
+			GoPackage: proto.String("github.com/erda-project/erda-infra/examples/service/protocol/pb"),
+		},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("Request")},
+			{Name: proto.String("Response")},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("AuthService"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				method("Login", &extension.AuthMethodOption{Public: true}),
+				method("GetUser", &extension.AuthMethodOption{Permissions: []string{"user:read"}}),
+				method("list_users", &extension.AuthMethodOption{Permissions: []string{"user:read", "user:list"}, Roles: []string{"admin"}}),
+				method("Ping", nil),
+			},
+		}},
+	}
+}
+
+func TestGenerateAuthRules(t *testing.T) {
+	req := &pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{"auth.proto"},
+		Parameter:      proto.String("paths=source_relative"),
+		ProtoFile: []*descriptorpb.FileDescriptorProto{
+			protodesc.ToFileDescriptorProto(descriptorpb.File_google_protobuf_descriptor_proto),
+			protodesc.ToFileDescriptorProto(extension.File_custom_extension_extension_proto),
+			authFile(),
+		},
+	}
+	gen, err := protogen.Options{}.New(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var flags flag.FlagSet
+	genHTTP = flags.Bool("http", true, "http")
+	genGRPC = flags.Bool("grpc", true, "grpc")
+	if _, err := generateFiles(gen, flags, gen.Files[len(gen.Files)-1:]); err != nil {
+		t.Fatal(err)
+	}
+	resp := gen.Response()
+	if resp.Error != nil {
+		t.Fatal(resp.GetError())
+	}
+	if len(resp.File) != 1 {
+		t.Fatalf("generated %d files, want 1", len(resp.File))
+	}
+
+	// the rules are keyed by the method names of transport.ServiceInfo, which are the Go names of methods
+	want, err := os.ReadFile(filepath.Join("testdata", resp.File[0].GetName()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.File[0].GetContent(); got != string(want) {
+		t.Errorf("generated %s mismatches testdata:\n%s", resp.File[0].GetName(), got)
+	}
+}
@@ -0,0 +1,64 @@
+// Code generated by protoc-gen-go-register. DO NOT EDIT.
+// Sources: auth.proto
+
+package pb
+
+import (
+	authz "github.com/erda-project/erda-infra/pkg/auth/authz"
+	transport "github.com/erda-project/erda-infra/pkg/transport"
+	reflect "reflect"
+)
+
+// RegisterAuthServiceImp auth.proto
+func RegisterAuthServiceImp(regester transport.Register, srv AuthServiceServer, opts ...transport.ServiceOption) {
+	_ops := transport.DefaultServiceOptions()
+	for _, op := range opts {
+		op(_ops)
+	}
+	RegisterAuthServiceHandler(regester, AuthServiceHandler(srv), _ops.HTTP...)
+	RegisterAuthServiceServer(regester, srv, _ops.GRPC...)
+}
+
+// ServiceNames return all service names
+func ServiceNames(svr ...string) []string {
+	return append(svr,
+		"erda.infra.example.AuthService",
+	)
+}
+
+var (
+	authServiceClientType  = reflect.TypeOf((*AuthServiceClient)(nil)).Elem()
+	authServiceServerType  = reflect.TypeOf((*AuthServiceServer)(nil)).Elem()
+	authServiceHandlerType = reflect.TypeOf((*AuthServiceHandler)(nil)).Elem()
+)
+
+// AuthServiceClientType .
+func AuthServiceClientType() reflect.Type { return authServiceClientType }
+
+// AuthServiceServerType .
+func AuthServiceServerType() reflect.Type { return authServiceServerType }
+
+// AuthServiceHandlerType .
+func AuthServiceHandlerType() reflect.Type { return authServiceHandlerType }
+
+func Types() []reflect.Type {
+	return []reflect.Type{
+		// client types
+		authServiceClientType,
+		// server types
+		authServiceServerType,
+		// handler types
+		authServiceHandlerType,
+	}
+}
+
+var authServiceAuthRules = authz.Rules{
+	"erda.infra.example.AuthService/Login":     {Public: true},
+	"erda.infra.example.AuthService/GetUser":   {Permissions: []string{"user:read"}},
+	"erda.infra.example.AuthService/ListUsers": {Permissions: []string{"user:read", "user:list"}, Roles: []string{"admin"}},
+}
+
+// AuthServiceAuthRules returns the authorization rules of AuthService declared by (custom.extension.auth) option.
+func AuthServiceAuthRules() authz.Rules { return authServiceAuthRules }
+
+func init() { authz.RegisterRules(authServiceAuthRules) }